@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/mogenius/punq/dtos"
+	"github.com/mogenius/punq/services"
+	"github.com/mogenius/punq/utils"
+	"github.com/spf13/cobra"
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Inspect the punq audit log.",
+	Long:  `The audit command lets you list and export the recorded api calls and exec sessions.`,
+}
+
+var listAuditCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List audit entries.",
+	Long:  `The list command lets you list the audit entries (newest first).`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dtos.ListAuditEntries(services.ListAuditEntries(auditFilterFromFlags()))
+	},
+}
+
+var exportAuditCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export audit entries as JSON lines.",
+	Long:  `The export command prints the audit entries as JSON lines (e.g. to ship them into a SIEM).`,
+	Run: func(cmd *cobra.Command, args []string) {
+		lines, err := services.AuditEntriesToJsonLines(services.ListAuditEntries(auditFilterFromFlags()))
+		if err != nil {
+			utils.FatalError(err.Error())
+		}
+		fmt.Print(string(lines))
+	},
+}
+
+func auditFilterFromFlags() services.AuditFilter {
+	since, err := services.ParseAuditSince(auditSince)
+	if err != nil {
+		utils.FatalError(err.Error())
+	}
+	return services.AuditFilter{
+		Since:     since,
+		UserId:    userId,
+		ContextId: auditContextId,
+		Verb:      auditVerb,
+		Limit:     auditLimit,
+	}
+}
+
+func init() {
+	auditCmd.AddCommand(listAuditCmd)
+	auditCmd.AddCommand(exportAuditCmd)
+	for _, command := range []*cobra.Command{listAuditCmd, exportAuditCmd} {
+		command.Flags().StringVarP(&userId, "user-id", "u", "", "Only entries of this user")
+		command.Flags().StringVar(&auditContextId, "for-context", "", "Only entries of this context id")
+		command.Flags().StringVar(&auditVerb, "verb", "", "Only entries with this verb (POST, PATCH, PUT, DELETE, EXEC)")
+		command.Flags().StringVar(&auditSince, "since", "24h", "Duration (e.g. 168h) or RFC3339 timestamp")
+	}
+	listAuditCmd.Flags().IntVar(&auditLimit, "limit", 100, "Max number of entries (0: all)")
+
+	rootCmd.AddCommand(auditCmd)
+}
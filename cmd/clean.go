@@ -30,8 +30,8 @@ var cleanCmd = &cobra.Command{
 
 		clusterName := kubernetes.CurrentContextName()
 
+		services.RemoveState()
 		kubernetes.Remove(yellow(clusterName))
-		services.RemoveKeyPair()
 
 		fmt.Printf("\n🚀🚀🚀 Successfully uninstalled punq from '%s'.\n\n", clusterName)
 	},
@@ -23,7 +23,7 @@ var clusterContextCmd = &cobra.Command{
 	Long:  `Print context information and exit.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// init contexts
-		kubernetes.ContextAddMany(services.ListContexts())
+		services.SyncContextRegistry()
 
 		structs.PrettyPrint(kubernetes.ContextForId(contextId))
 	},
@@ -35,7 +35,7 @@ var clusterInfoCmd = &cobra.Command{
 	Long:  `Print information and exit.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// init contexts
-		kubernetes.ContextAddMany(services.ListContexts())
+		services.SyncContextRegistry()
 
 		structs.PrettyPrint(kubernetes.ClusterInfo(&contextId))
 	},
@@ -23,18 +23,28 @@ var contextCmd = &cobra.Command{
 var listContextCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List punq contexts.",
-	Long:  `The list command lets you list all contexts managed by punq.`,
+	Long:  `The list command lets you list all contexts managed by punq (optionally filtered by a label selector).`,
 	Run: func(cmd *cobra.Command, args []string) {
-		dtos.ListContextsToTerminal(services.ListContexts())
+		contexts, err := dtos.FilterContextsBySelector(services.ListContexts(), labelSelector)
+		if err != nil {
+			utils.FatalError(err.Error())
+		}
+		dtos.ListContextsToTerminal(contexts)
 	},
 }
 
 var addContextCmd = &cobra.Command{
 	Use:   "add",
 	Short: "Add punq context.",
-	Long:  `The add command lets you add a context into punq.`,
+	Long: `The add command lets you add a context into punq.
+With --service-account the kubeconfig is only used once to create a "punq-remote-<context-id>"
+ServiceAccount bound to --cluster-role in the cluster. punq stores a kubeconfig with the token of this
+ServiceAccount instead of your own credentials. Deleting the context with --admin-kubeconfig revokes it again.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		RequireStringFlag(filePath, "filepath")
+		if mintServiceAccount {
+			RequireStringFlag(clusterRole, "cluster-role")
+		}
 
 		// load file
 		dataBytes, err := os.ReadFile(filePath)
@@ -69,7 +79,7 @@ var addContextCmd = &cobra.Command{
 		if index > 0 {
 			selectedContext := contexts[index-1]
 			//selectedContext.PrintToTerminal()
-			_, err := services.AddContext(selectedContext)
+			_, err := addContext(selectedContext)
 			if err != nil {
 				utils.FatalError(err.Error())
 			} else {
@@ -80,7 +90,7 @@ var addContextCmd = &cobra.Command{
 		if index == -2 {
 			dtos.ListContextsToTerminal(contexts)
 			for _, ctx := range contexts {
-				_, err := services.AddContext(ctx)
+				_, err := addContext(ctx)
 				if err != nil {
 					utils.PrintError(err.Error())
 				} else {
@@ -91,52 +101,211 @@ var addContextCmd = &cobra.Command{
 	},
 }
 
+func addContext(ctx dtos.PunqContext) (*dtos.PunqContext, error) {
+	if mintServiceAccount {
+		return services.AddServiceAccountContext(ctx, clusterRole)
+	}
+	return services.AddContext(ctx)
+}
+
 var addContextAccessCmd = &cobra.Command{
 	Use:   "add-access",
 	Short: "Add access to punq context.",
-	Long:  `The add-access command lets you add a user to a context in punq.`,
+	Long:  `The add-access command lets you add a user or a group to a context in punq.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		RequireStringFlag(contextId, "context-id")
-		RequireStringFlag(userId, "user-id")
-
-		ctx, _ := services.GetContext(contextId)
-		if ctx == nil {
-			utils.FatalError(fmt.Sprintf("context '%s' not found.", contextId))
-			return
+		if userId == "" && groupId == "" {
+			utils.FatalError("--user-id or --group-id flag is required for this command.")
 		}
 
-		ctx.AddAccess(userId)
-		services.UpdateContext(*ctx)
+		if groupId != "" {
+			if _, err := services.GetGroup(groupId); err != nil {
+				utils.FatalError(fmt.Sprintf("group '%s' not found.", groupId))
+			}
+		}
+		_, err := services.ModifyContext(contextId, func(ctx *dtos.PunqContext) error {
+			if userId != "" {
+				ctx.AddAccess(userId)
+			}
+			if groupId != "" {
+				ctx.AddGroupAccess(groupId)
+			}
+			return nil
+		})
+		if err != nil {
+			utils.FatalError(fmt.Sprintf("context '%s': %s", contextId, err.Error()))
+		}
 	},
 }
 
 var removeContextAccessCmd = &cobra.Command{
 	Use:   "remove-access",
 	Short: "Remove access from punq context.",
-	Long:  `The remove-access command lets you remove a users access level from a context in punq.`,
+	Long:  `The remove-access command lets you remove a users or groups access from a context in punq.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		RequireStringFlag(contextId, "context-id")
-		RequireStringFlag(userId, "user-id")
+		if userId == "" && groupId == "" {
+			utils.FatalError("--user-id or --group-id flag is required for this command.")
+		}
 
-		ctx, _ := services.GetContext(contextId)
-		if ctx == nil {
-			utils.FatalError(fmt.Sprintf("context '%s' not found.", contextId))
+		_, err := services.ModifyContext(contextId, func(ctx *dtos.PunqContext) error {
+			if userId != "" {
+				ctx.RemoveAccess(userId)
+			}
+			if groupId != "" {
+				ctx.RemoveGroupAccess(groupId)
+			}
+			return nil
+		})
+		if err != nil {
+			utils.FatalError(fmt.Sprintf("context '%s': %s", contextId, err.Error()))
+		}
+	},
+}
+
+var impersonationContextCmd = &cobra.Command{
+	Use:   "impersonation",
+	Short: "Enable or disable impersonation for a punq context.",
+	Long: `The impersonation command lets cluster requests of a context run as the punq user (Impersonate-User/-Group)
+so the cluster's RBAC applies. The user name is the email of the user, the groups are "punq:authenticated",
+"punq:role:<accesslevel>" and "punq:group:<name>". The stored kubeconfig identity needs the "impersonate" verb.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		RequireStringFlag(contextId, "context-id")
+
+		ctx, err := services.ModifyContext(contextId, func(ctx *dtos.PunqContext) error {
+			ctx.Impersonate = !impersonationDisable
+			return nil
+		})
+		if err != nil {
+			utils.FatalError(fmt.Sprintf("context '%s': %s", contextId, err.Error()))
+		}
+		utils.PrintInfo(fmt.Sprintf("Impersonation for context '%s': %t", contextId, ctx.Impersonate))
+	},
+}
+
+var labelContextCmd = &cobra.Command{
+	Use:   "label [key=value|key-]...",
+	Short: "Label punq context.",
+	Long: `The label command lets you set (key=value) or remove (key-) labels of a context and set its environment
+(development, staging or production) and color. Labels and the environment can be used in selectors,
+e.g. 'punq context list -l environment=production,team=payments'.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		RequireStringFlag(contextId, "context-id")
+
+		ctx, err := services.ModifyContext(contextId, func(ctx *dtos.PunqContext) error {
+			if cmd.Flags().Changed("environment") {
+				ctx.Environment = contextEnvironment
+			}
+			if cmd.Flags().Changed("color") {
+				ctx.Color = contextColor
+			}
+			return ctx.ApplyLabelArgs(args)
+		})
+		if err != nil {
+			utils.FatalError(fmt.Sprintf("context '%s': %s", contextId, err.Error()))
+		}
+		utils.PrintInfo(fmt.Sprintf("Labels of context '%s': %s", ctx.Name, ctx.LabelsString()))
+	},
+}
+
+// confirmContextDelete asks for the name of contexts matching utils.CONFIG.Kubernetes.ConfirmDeleteSelector.
+func confirmContextDelete(id string) {
+	ctx, _ := services.GetContext(id)
+	if ctx == nil || !services.RequiresDeleteConfirmation(*ctx) {
+		return
+	}
+	if !utils.ConfirmWithInput(fmt.Sprintf("Context '%s' (%s) is protected.", ctx.Name, ctx.LabelsString()), ctx.Name) {
+		utils.FatalError("Aborted.")
+	}
+}
+
+var kubeconfigContextCmd = &cobra.Command{
+	Use:   "kubeconfig",
+	Short: "Print a merged kubeconfig of punq contexts.",
+	Long: `The kubeconfig command prints one kubeconfig containing all punq contexts (optionally filtered by a label
+selector) to use them with plain kubectl. Contexts with disabled kubeconfig export are left out.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		contexts, err := dtos.FilterContextsBySelector(services.ListContexts(), labelSelector)
+		if err != nil {
+			utils.FatalError(err.Error())
+		}
+		kubeconfig, exported, err := services.MergedKubeconfig(nil, contexts)
+		if err != nil {
+			utils.FatalError(err.Error())
+		}
+
+		if filePath == "" {
+			fmt.Print(string(kubeconfig))
 			return
 		}
+		err = os.WriteFile(filePath, kubeconfig, 0600)
+		if err != nil {
+			utils.FatalError(fmt.Sprintf("Error writing file '%s': %s", filePath, err.Error()))
+		}
+		utils.PrintInfo(fmt.Sprintf("Wrote %d contexts to '%s'.", len(exported), filePath))
+	},
+}
+
+var kubeconfigExportContextCmd = &cobra.Command{
+	Use:   "kubeconfig-export",
+	Short: "Enable or disable the kubeconfig export of a punq context.",
+	Long: `The kubeconfig-export command lets you exclude a context from merged kubeconfigs ('punq context kubeconfig'
+and GET /context/kubeconfig), so its credentials are only used by punq itself.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		RequireStringFlag(contextId, "context-id")
+
+		ctx, err := services.ModifyContext(contextId, func(ctx *dtos.PunqContext) error {
+			ctx.DisableKubeconfigExport = kubeconfigExportDisable
+			return nil
+		})
+		if err != nil {
+			utils.FatalError(fmt.Sprintf("context '%s': %s", contextId, err.Error()))
+		}
+		utils.PrintInfo(fmt.Sprintf("Kubeconfig export for context '%s': %t", contextId, !ctx.DisableKubeconfigExport))
+	},
+}
 
-		ctx.RemoveAccess(userId)
-		services.UpdateContext(*ctx)
+var informerCacheContextCmd = &cobra.Command{
+	Use:   "informer-cache",
+	Short: "Enable or disable the informer cache of a punq context.",
+	Long: `The informer-cache command lets the operator serve lists (pods, deployments, services, ...) of a context from
+informers watching the cluster instead of listing on every request. The informers are started on the first list and
+stopped after informer_idle_minutes without lists. Secrets are never cached and contexts with impersonation are
+always listed live.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		RequireStringFlag(contextId, "context-id")
+
+		ctx, err := services.ModifyContext(contextId, func(ctx *dtos.PunqContext) error {
+			ctx.InformerCache = !informerCacheDisable
+			return nil
+		})
+		if err != nil {
+			utils.FatalError(fmt.Sprintf("context '%s': %s", contextId, err.Error()))
+		}
+		utils.PrintInfo(fmt.Sprintf("Informer cache for context '%s': %t", contextId, ctx.InformerCache))
 	},
 }
 
 var deleteContextCmd = &cobra.Command{
 	Use:   "delete",
 	Short: "Delete punq context.",
-	Long:  `The delete command lets you delete a specific context in punq.`,
+	Long: `The delete command lets you delete a specific context in punq.
+The ServiceAccount of a context added with --service-account is revoked with --admin-kubeconfig
+(a kubeconfig of a cluster admin). --keep-service-account deletes the context only.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		RequireStringFlag(contextId, "context-id")
+		confirmContextDelete(contextId)
+
+		adminKubeconfig := []byte{}
+		if adminKubeconfigPath != "" {
+			var err error
+			adminKubeconfig, err = os.ReadFile(adminKubeconfigPath)
+			if err != nil {
+				utils.FatalError(fmt.Sprintf("Error reading file '%s': %s", adminKubeconfigPath, err.Error()))
+			}
+		}
 
-		result, err := services.DeleteContext(contextId)
+		result, err := services.DeleteContext(contextId, string(adminKubeconfig), keepServiceAccount)
 		if err != nil {
 			utils.PrintError(err.Error())
 		}
@@ -181,16 +350,40 @@ var exportNamespaceCmd = &cobra.Command{
 
 func init() {
 	contextCmd.AddCommand(listContextCmd)
+	listContextCmd.Flags().StringVarP(&labelSelector, "selector", "l", "", "Label selector, e.g. environment=production,team=payments")
 
 	contextCmd.AddCommand(addContextAccessCmd)
 	addContextAccessCmd.Flags().StringVarP(&userId, "user-id", "u", "", "Id of the user you want to add")
+	addContextAccessCmd.Flags().StringVarP(&groupId, "group-id", "g", "", "Id of the group you want to add")
 
 	contextCmd.AddCommand(removeContextAccessCmd)
-	removeContextAccessCmd.Flags().StringVarP(&userId, "user-id", "u", "", "Id of the user you want to add")
+	removeContextAccessCmd.Flags().StringVarP(&userId, "user-id", "u", "", "Id of the user you want to remove")
+	removeContextAccessCmd.Flags().StringVarP(&groupId, "group-id", "g", "", "Id of the group you want to remove")
 
 	contextCmd.AddCommand(addContextCmd)
 	addContextCmd.Flags().StringVarP(&filePath, "filepath", "f", "", "FilePath to the context you want to add")
+	addContextCmd.Flags().BoolVar(&mintServiceAccount, "service-account", false, "Create a ServiceAccount for this context in the cluster and store its token instead of your credentials")
+	addContextCmd.Flags().StringVar(&clusterRole, "cluster-role", "view", "ClusterRole bound to the minted ServiceAccount")
+
+	contextCmd.AddCommand(impersonationContextCmd)
+	impersonationContextCmd.Flags().BoolVar(&impersonationDisable, "disable", false, "Use the stored kubeconfig identity again")
+
+	contextCmd.AddCommand(labelContextCmd)
+	labelContextCmd.Flags().StringVar(&contextEnvironment, "environment", "", fmt.Sprintf("Environment of the context (%s)", strings.Join(dtos.Environments, ", ")))
+	labelContextCmd.Flags().StringVar(&contextColor, "color", "", "Color of the context in the ui (default: color of the environment)")
+
+	contextCmd.AddCommand(kubeconfigContextCmd)
+	kubeconfigContextCmd.Flags().StringVarP(&labelSelector, "selector", "l", "", "Label selector, e.g. environment=production,team=payments")
+	kubeconfigContextCmd.Flags().StringVarP(&filePath, "filepath", "f", "", "Write the kubeconfig to this file instead of stdout")
+
+	contextCmd.AddCommand(kubeconfigExportContextCmd)
+	kubeconfigExportContextCmd.Flags().BoolVar(&kubeconfigExportDisable, "disable", false, "Leave the context out of merged kubeconfigs")
+
+	contextCmd.AddCommand(informerCacheContextCmd)
+	informerCacheContextCmd.Flags().BoolVar(&informerCacheDisable, "disable", false, "List the context live again")
 
+	deleteContextCmd.Flags().StringVar(&adminKubeconfigPath, "admin-kubeconfig", "", "Kubeconfig of a cluster admin to revoke the ServiceAccount of the context")
+	deleteContextCmd.Flags().BoolVar(&keepServiceAccount, "keep-service-account", false, "Delete the context without revoking its ServiceAccount")
 	contextCmd.AddCommand(deleteContextCmd)
 
 	contextCmd.AddCommand(getContextCmd)
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/mogenius/punq/kubernetes"
+	"github.com/mogenius/punq/utils"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+var getCmd = &cobra.Command{
+	Use:   "get [resource] [name]",
+	Short: "Display any resource of the cluster.",
+	Long: `Similar to kubectl, the get command lists objects of any resource including custom resources
+(e.g. 'punq get deploy -n default', 'punq get rollouts.argoproj.io -A' or 'punq get prometheusrules my-rules -n monitoring').
+Without arguments it lists all resources of the cluster.`,
+	Args: cobra.MaximumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		RequireStringFlag(contextId, "context-id")
+
+		if len(args) == 0 {
+			resources, err := kubernetes.ListGenericResources(&contextId)
+			if err != nil {
+				utils.FatalError(err.Error())
+			}
+			kubernetes.ListGenericResourcesTerminal(resources)
+			return
+		}
+
+		genericResource, err := kubernetes.ResolveGenericResource(args[0], &contextId)
+		if err != nil {
+			utils.FatalError(err.Error())
+		}
+		if allNamespaces {
+			namespace = ""
+		} else if genericResource.Namespaced && namespace == "" {
+			namespace = "default"
+		}
+
+		if len(args) == 2 {
+			item, err := kubernetes.GetGenericWorkload(*genericResource, namespace, args[1], &contextId)
+			if err != nil {
+				utils.FatalError(err.Error())
+			}
+			if outputFormat == "" {
+				kubernetes.ListGenericWorkloadsTerminal(*genericResource, []unstructured.Unstructured{*item})
+				return
+			}
+			printObject(item.Object)
+			return
+		}
+
+		items, err := kubernetes.ListGenericWorkloads(*genericResource, namespace, labelSelector, &contextId)
+		if err != nil {
+			utils.FatalError(err.Error())
+		}
+		if outputFormat == "" {
+			kubernetes.ListGenericWorkloadsTerminal(*genericResource, items)
+			return
+		}
+		list := unstructured.UnstructuredList{Object: map[string]interface{}{"apiVersion": "v1", "kind": "List"}, Items: items}
+		printObject(list.UnstructuredContent())
+	},
+}
+
+func printObject(object interface{}) {
+	var data []byte
+	var err error
+	switch outputFormat {
+	case "json":
+		data, err = json.MarshalIndent(object, "", "  ")
+	case "yaml":
+		data, err = yaml.Marshal(object)
+	default:
+		err = fmt.Errorf("unknown output format '%s' (json or yaml)", outputFormat)
+	}
+	if err != nil {
+		utils.FatalError(err.Error())
+	}
+	fmt.Println(string(data))
+}
+
+func init() {
+	getCmd.Flags().StringVarP(&namespace, "namespace", "n", "", "Namespace of namespaced resources (default: default)")
+	getCmd.Flags().BoolVarP(&allNamespaces, "all-namespaces", "A", false, "List the objects of all namespaces")
+	getCmd.Flags().StringVarP(&labelSelector, "selector", "l", "", "Label selector, e.g. app=nginx")
+	getCmd.Flags().StringVarP(&outputFormat, "output", "o", "", "Output format (json or yaml)")
+
+	rootCmd.AddCommand(getCmd)
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/mogenius/punq/dtos"
+	"github.com/mogenius/punq/services"
+	"github.com/mogenius/punq/utils"
+	"github.com/spf13/cobra"
+)
+
+var groupCmd = &cobra.Command{
+	Use:   "group",
+	Short: "Manage punq groups.",
+	Long:  `The group command lets you manage all group related task like add, remove, list groups and their members.`,
+}
+
+var listGroupCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List punq groups.",
+	Long:  `The list command lets you list all groups of punq.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dtos.ListGroups(services.ListGroups())
+	},
+}
+
+var addGroupCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add punq group.",
+	Long:  `The add command lets you add a group into punq.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		RequireStringFlag(groupName, "name")
+
+		group, err := services.AddGroup(dtos.PunqGroupCreateInput{
+			Name:        groupName,
+			Description: groupDescription,
+		})
+		if err != nil {
+			utils.FatalError(err.Error())
+		}
+		utils.PrintInfo("Group added succesfully ✅.")
+		group.PrintToTerminal()
+	},
+}
+
+var updateGroupCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Update punq group.",
+	Long:  `The update command lets you update the name or description of a group in punq.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		RequireStringFlag(groupId, "group-id")
+
+		if groupName == "" && groupDescription == "" {
+			utils.FatalError("One of the following options must be used to update a group: -name -description")
+		}
+
+		group, err := services.GetGroup(groupId)
+		if err != nil {
+			utils.FatalError(fmt.Sprintf("Selected groupId '%s' not found.", groupId))
+		}
+		if groupName != "" {
+			group.Name = groupName
+		}
+		if groupDescription != "" {
+			group.Description = groupDescription
+		}
+
+		_, err = services.UpdateGroup(*group)
+		if err != nil {
+			utils.FatalError(err.Error())
+		}
+		utils.PrintInfo("Group updated succesfully ✅.")
+	},
+}
+
+var deleteGroupCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete punq group.",
+	Long:  `The delete command lets you delete a specific group in punq. Context access and role bindings of the group are removed as well.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		RequireStringFlag(groupId, "group-id")
+
+		err := services.DeleteGroup(groupId)
+		if err != nil {
+			utils.FatalError(err.Error())
+		}
+		utils.PrintInfo(fmt.Sprintf("Group %s successfully deleted.", groupId))
+	},
+}
+
+var getGroupCmd = &cobra.Command{
+	Use:   "get",
+	Short: "Get specific punq group.",
+	Long:  `The get command lets you get a specific group of punq including its members.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		RequireStringFlag(groupId, "group-id")
+
+		group, err := services.GetGroup(groupId)
+		if err != nil {
+			utils.FatalError(err.Error())
+		}
+		group.PrintToTerminal()
+
+		members := []dtos.PunqUser{}
+		for _, memberId := range group.UserIds {
+			user, err := services.GetUser(memberId)
+			if err == nil {
+				members = append(members, *user)
+			}
+		}
+		dtos.ListUsers(members)
+	},
+}
+
+var addMemberGroupCmd = &cobra.Command{
+	Use:   "add-member",
+	Short: "Add user to punq group.",
+	Long:  `The add-member command lets you add a user to a group in punq.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		RequireStringFlag(groupId, "group-id")
+		RequireStringFlag(userId, "user-id")
+
+		_, err := services.AddGroupMember(groupId, userId)
+		if err != nil {
+			utils.FatalError(err.Error())
+		}
+		utils.PrintInfo(fmt.Sprintf("User %s added to group %s ✅.", userId, groupId))
+	},
+}
+
+var removeMemberGroupCmd = &cobra.Command{
+	Use:   "remove-member",
+	Short: "Remove user from punq group.",
+	Long:  `The remove-member command lets you remove a user from a group in punq.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		RequireStringFlag(groupId, "group-id")
+		RequireStringFlag(userId, "user-id")
+
+		_, err := services.RemoveGroupMember(groupId, userId)
+		if err != nil {
+			utils.FatalError(err.Error())
+		}
+		utils.PrintInfo(fmt.Sprintf("User %s removed from group %s ✅.", userId, groupId))
+	},
+}
+
+func init() {
+	groupCmd.AddCommand(listGroupCmd)
+
+	groupCmd.AddCommand(addGroupCmd)
+	addGroupCmd.Flags().StringVarP(&groupName, "name", "n", "", "Name of the new group")
+	addGroupCmd.Flags().StringVarP(&groupDescription, "description", "j", "", "Description of the new group")
+
+	groupCmd.AddCommand(updateGroupCmd)
+	updateGroupCmd.Flags().StringVarP(&groupId, "group-id", "g", "", "Id of the group")
+	updateGroupCmd.Flags().StringVarP(&groupName, "name", "n", "", "Name of the group")
+	updateGroupCmd.Flags().StringVarP(&groupDescription, "description", "j", "", "Description of the group")
+
+	groupCmd.AddCommand(deleteGroupCmd)
+	deleteGroupCmd.Flags().StringVarP(&groupId, "group-id", "g", "", "Id of the group")
+
+	groupCmd.AddCommand(getGroupCmd)
+	getGroupCmd.Flags().StringVarP(&groupId, "group-id", "g", "", "Id of the group")
+
+	groupCmd.AddCommand(addMemberGroupCmd)
+	addMemberGroupCmd.Flags().StringVarP(&groupId, "group-id", "g", "", "Id of the group")
+	addMemberGroupCmd.Flags().StringVarP(&userId, "user-id", "u", "", "Id of the user you want to add")
+
+	groupCmd.AddCommand(removeMemberGroupCmd)
+	removeMemberGroupCmd.Flags().StringVarP(&groupId, "group-id", "g", "", "Id of the group")
+	removeMemberGroupCmd.Flags().StringVarP(&userId, "user-id", "u", "", "Id of the user you want to remove")
+
+	rootCmd.AddCommand(groupCmd)
+}
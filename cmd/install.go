@@ -31,7 +31,8 @@ var installCmd = &cobra.Command{
 		clusterName := kubernetes.CurrentContextName()
 
 		kubernetes.Deploy(clusterName, ingressHostname)
-		services.InitUserService()
+		services.InitStore()
+		services.CreateOwnContext()
 		services.InitAuthService()
 		services.CreateAdminUser()
 
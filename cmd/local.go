@@ -9,6 +9,7 @@ import (
 	"github.com/mogenius/punq/kubernetes"
 	"github.com/mogenius/punq/operator"
 	"github.com/mogenius/punq/services"
+	"github.com/mogenius/punq/store"
 	"github.com/mogenius/punq/utils"
 
 	"github.com/spf13/cobra"
@@ -24,6 +25,14 @@ var localCmd = &cobra.Command{
 		fmt.Println("")
 		utils.PrintSettings()
 
+		if utils.CONFIG.Store.Type == store.TypeFile {
+			// without a cluster installation the state lives on this machine
+			services.InitStore()
+			services.CreateOwnContext()
+			services.InitAuthService()
+			services.CreateAdminUser()
+		}
+
 		contexts := services.ListContexts()
 		utils.PrintInfo(fmt.Sprintf("Initialized operator with %d contexts.", len(contexts)))
 
@@ -2,8 +2,10 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
 
-	"github.com/mogenius/punq/kubernetes"
 	"github.com/mogenius/punq/operator"
 	"github.com/mogenius/punq/services"
 
@@ -24,9 +26,21 @@ var operatorCmd = &cobra.Command{
 		println("###############################################\n")
 		utils.PrintSettings()
 
-		contexts := services.ListContexts()
+		contexts := services.SyncContextRegistry()
 		utils.PrintInfo(fmt.Sprintf("Initialized operator with %d contexts.", len(contexts)))
-		kubernetes.ContextAddMany(contexts)
+		services.InitContextRegistrySync()
+		services.InitJwtKeyRotation()
+		services.InitAuditLog()
+		services.InitContextHealthMonitor()
+
+		// the pod receives SIGTERM on shutdown, queued audit entries must not get lost
+		go func() {
+			quit := make(chan os.Signal, 1)
+			signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+			<-quit
+			services.FlushAuditLog()
+			os.Exit(0)
+		}()
 
 		go operator.InitBackend()
 		go operator.InitWebsocket()
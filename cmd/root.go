@@ -6,6 +6,7 @@ import (
 
 	cc "github.com/ivanpirog/coloredcobra"
 	mokubernetes "github.com/mogenius/punq/kubernetes"
+	"github.com/mogenius/punq/services"
 	"github.com/mogenius/punq/utils"
 	"github.com/spf13/cobra"
 )
@@ -27,6 +28,36 @@ var contextId string
 var accessLevel string
 var forceUpgrade bool
 var resources []string
+var bindingId string
+var groupId string
+var groupName string
+var groupDescription string
+var tokenId string
+var tokenName string
+var tokenExpiresIn string
+var tokenContexts []string
+var tokenNamespaces []string
+var tokenVerbs []string
+var sessionId string
+var mfaDisable bool
+var impersonationDisable bool
+var auditContextId string
+var auditVerb string
+var auditSince string
+var auditLimit int
+var storeFrom string
+var storeTo string
+var mintServiceAccount bool
+var clusterRole string
+var adminKubeconfigPath string
+var keepServiceAccount bool
+var labelSelector string
+var contextEnvironment string
+var contextColor string
+var kubeconfigExportDisable bool
+var informerCacheDisable bool
+var allNamespaces bool
+var outputFormat string
 
 var cmdsWithoutContext = []string{
 	"punq",
@@ -59,8 +90,9 @@ var rootCmd = &cobra.Command{
 
 		if !utils.ContainsEqual(cmdsWithoutContext, cmd.CommandPath()) {
 			mokubernetes.InitKubernetes(utils.CONFIG.Kubernetes.RunInCluster)
-			ctxs := mokubernetes.ListAllContexts()
-			mokubernetes.ContextAddMany(ctxs)
+			services.SyncContextRegistry()
+			// the cli acts with the identity of the kubeconfig, also in contexts which impersonate punq users
+			mokubernetes.RegisterSystemIdentity(&contextId)
 			utils.PrintInfo((fmt.Sprintf("Current context: '%s'", contextId)))
 		}
 	},
@@ -8,6 +8,7 @@ import (
 	"os"
 
 	"github.com/mogenius/punq/kubernetes"
+	"github.com/mogenius/punq/services"
 	"github.com/mogenius/punq/utils"
 	"github.com/mogenius/punq/version"
 
@@ -74,10 +75,69 @@ var ingressControllerCmd = &cobra.Command{
 	},
 }
 
+var rotateJwtKeyCmd = &cobra.Command{
+	Use:   "rotate-jwt-key",
+	Short: "Rotate the key used to sign punq tokens.",
+	Long: `
+	This cmd generates a new jwt signing key. Tokens signed by previous keys stay valid until they expire.
+	Use the 'auth.jwt_key_rotation_days' setting to rotate the key automatically.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		keyPairs, err := services.RotateKeyPair()
+		if err != nil {
+			utils.FatalError(err.Error())
+		}
+		utils.PrintInfo(fmt.Sprintf("Jwt signing key rotated. New kid: '%s' (%d verification keys). ✅", keyPairs[len(keyPairs)-1].Id, len(keyPairs)))
+	},
+}
+
+var rotateEncryptionKeyCmd = &cobra.Command{
+	Use:   "rotate-encryption-key",
+	Short: "Re-encrypt all stored kubeconfigs with a new data key.",
+	Long: `
+	This cmd creates a new data key, re-wraps all data keys with the current master key and re-encrypts all contexts.
+	Run it after enabling encryption (to encrypt existing contexts) and after replacing the master key
+	(keep the old key in 'encryption.previous_master_key_files' until this cmd has finished).
+	Retired data keys are dropped after a minute, once all punq processes picked up the new key.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		count, err := services.RotateEncryptionKey()
+		if err != nil {
+			utils.FatalError(err.Error())
+		}
+		utils.PrintInfo(fmt.Sprintf("Re-encrypted %d contexts. ✅", count))
+	},
+}
+
+var migrateStoreCmd = &cobra.Command{
+	Use:   "migrate-store",
+	Short: "Copy users, contexts and keys from one state store into another.",
+	Long: `
+	This cmd copies the complete punq state (e.g. from the secret to the crd store). The source is left untouched.
+	Set 'store.type' to the target afterwards and restart punq.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		yellow := color.New(color.FgYellow).SprintFunc()
+		if !utils.ConfirmTask(fmt.Sprintf("Do you really want to migrate the punq state from '%s' to '%s' store?", yellow(storeFrom), yellow(storeTo))) {
+			os.Exit(0)
+		}
+
+		count, err := services.MigrateStore(storeFrom, storeTo)
+		if err != nil {
+			utils.FatalError(err.Error())
+		}
+		utils.PrintInfo(fmt.Sprintf("Migrated %d entries from %s to %s store. ✅", count, storeFrom, storeTo))
+	},
+}
+
 func init() {
+	migrateStoreCmd.Flags().StringVar(&storeFrom, "from", "secret", "Store to read from (secret, crd, file).")
+	migrateStoreCmd.Flags().StringVar(&storeTo, "to", "", "Store to write to (secret, crd, file).")
+	migrateStoreCmd.MarkFlagRequired("to")
+
 	rootCmd.AddCommand(systemCmd)
 	systemCmd.AddCommand(resetConfig)
 	systemCmd.AddCommand(infoCmd)
 	systemCmd.AddCommand(ingressControllerCmd)
 	systemCmd.AddCommand(checkCmd)
+	systemCmd.AddCommand(rotateJwtKeyCmd)
+	systemCmd.AddCommand(migrateStoreCmd)
+	systemCmd.AddCommand(rotateEncryptionKeyCmd)
 }
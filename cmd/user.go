@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/mogenius/punq/dtos"
 	"github.com/mogenius/punq/services"
@@ -140,6 +141,203 @@ var getUserCmd = &cobra.Command{
 	},
 }
 
+var unlockUserCmd = &cobra.Command{
+	Use:   "unlock",
+	Short: "Unlock punq user.",
+	Long:  `The unlock command lets you reset the failed logins of a user that has been locked out.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		RequireStringFlag(userId, "user-id")
+
+		_, err := services.UnlockUser(userId)
+		if err != nil {
+			utils.FatalError(err.Error())
+		}
+		utils.PrintInfo(fmt.Sprintf("User %s successfully unlocked.", userId))
+	},
+}
+
+var mfaUserCmd = &cobra.Command{
+	Use:   "mfa",
+	Short: "Manage the second factor of punq users.",
+	Long:  `The mfa command lets you reset or enforce the two-factor authentication (TOTP) of users.`,
+}
+
+var resetMfaUserCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Reset the second factor of a punq user.",
+	Long:  `The reset command removes the TOTP secret and recovery codes of a user (e.g. after a lost device).`,
+	Run: func(cmd *cobra.Command, args []string) {
+		RequireStringFlag(userId, "user-id")
+
+		_, err := services.ResetTotp(userId)
+		if err != nil {
+			utils.FatalError(err.Error())
+		}
+		utils.PrintInfo(fmt.Sprintf("Second factor of user %s successfully reset.", userId))
+	},
+}
+
+var requireMfaUserCmd = &cobra.Command{
+	Use:   "require",
+	Short: "Enforce a second factor for a punq user.",
+	Long:  `The require command forces a user to enroll a second factor on the next login (use --disable to lift it).`,
+	Run: func(cmd *cobra.Command, args []string) {
+		RequireStringFlag(userId, "user-id")
+
+		user, err := services.SetMfaRequired(userId, !mfaDisable)
+		if err != nil {
+			utils.FatalError(err.Error())
+		}
+		utils.PrintInfo(fmt.Sprintf("Second factor of user %s: %s", userId, user.MfaStatus()))
+	},
+}
+
+var bindingUserCmd = &cobra.Command{
+	Use:   "binding",
+	Short: "Manage role bindings of punq users.",
+	Long:  `The binding command lets you grant users a role in a specific context (or all contexts using '*') and optionally restrict it to namespaces matching a pattern (e.g. 'team-a-*').`,
+}
+
+var listBindingUserCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List role bindings.",
+	Long:  `The list command lets you list all role bindings (optionally of a single user).`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if userId != "" {
+			dtos.ListRoleBindings(services.ListRoleBindingsForUser(userId))
+		} else {
+			dtos.ListRoleBindings(services.ListRoleBindings())
+		}
+	},
+}
+
+var addBindingUserCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add role binding.",
+	Long:  `The add command lets you bind a user or a group to a role in the context given by --context-id ('*' for all contexts).`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if userId == "" && groupId == "" {
+			utils.FatalError("--user-id or --group-id flag is required for this command.")
+		}
+		RequireStringFlag(accessLevel, "accesslevel")
+		RequireStringFlag(contextId, "context-id")
+
+		binding, err := services.AddRoleBinding(dtos.PunqRoleBindingCreateInput{
+			UserId:    userId,
+			GroupId:   groupId,
+			Role:      dtos.AccessLevelFromString(accessLevel),
+			ContextId: contextId,
+			Namespace: namespace,
+		})
+		if err != nil {
+			utils.FatalError(err.Error())
+		}
+		utils.PrintInfo("Role binding added succesfully ✅.")
+		dtos.ListRoleBindings([]dtos.PunqRoleBinding{*binding})
+	},
+}
+
+var deleteBindingUserCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete role binding.",
+	Long:  `The delete command lets you delete a specific role binding.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		RequireStringFlag(bindingId, "binding-id")
+
+		err := services.DeleteRoleBinding(bindingId)
+		if err != nil {
+			utils.FatalError(err.Error())
+		}
+		utils.PrintInfo(fmt.Sprintf("Role binding %s successfully deleted.", bindingId))
+	},
+}
+
+var tokenUserCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Manage personal access tokens of punq users.",
+	Long:  `The token command lets you manage named api tokens (e.g. for CI pipelines). Use them with the header 'Authorization: Token <token>'.`,
+}
+
+var createTokenUserCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create api token.",
+	Long:  `The create command lets you create an api token for a user. The token is only printed once.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		RequireStringFlag(userId, "user-id")
+		RequireStringFlag(tokenName, "name")
+
+		expiresAt := ""
+		if tokenExpiresIn != "" {
+			duration, err := time.ParseDuration(tokenExpiresIn)
+			if err != nil {
+				utils.FatalError(fmt.Sprintf("Invalid duration '%s': %s", tokenExpiresIn, err.Error()))
+			}
+			expiresAt = time.Now().Add(duration).Format(time.RFC3339)
+		}
+
+		token, err := services.AddApiToken(userId, dtos.PunqApiTokenCreateInput{
+			Name:       tokenName,
+			Contexts:   tokenContexts,
+			Namespaces: tokenNamespaces,
+			Verbs:      tokenVerbs,
+			ExpiresAt:  expiresAt,
+		})
+		if err != nil {
+			utils.FatalError(err.Error())
+		}
+		utils.PrintInfo("Api token created succesfully ✅. Please store it in a safe place, it will not be shown again:")
+		fmt.Println(token.Token)
+	},
+}
+
+var listTokenUserCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List api tokens.",
+	Long:  `The list command lets you list all api tokens (optionally of a single user).`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dtos.ListApiTokens(services.ListApiTokens(userId))
+	},
+}
+
+var revokeTokenUserCmd = &cobra.Command{
+	Use:   "revoke",
+	Short: "Revoke api token.",
+	Long:  `The revoke command lets you revoke (delete) a specific api token.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		RequireStringFlag(tokenId, "token-id")
+
+		err := services.RevokeApiToken(tokenId, "")
+		if err != nil {
+			utils.FatalError(err.Error())
+		}
+		utils.PrintInfo(fmt.Sprintf("Api token %s successfully revoked.", tokenId))
+	},
+}
+
+var sessionsUserCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "List active sessions of punq users.",
+	Long:  `The sessions command lists all active login sessions (optionally of a single user). Use 'sessions revoke' to sign a session out.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dtos.ListSessions(services.ListSessions(userId))
+	},
+}
+
+var revokeSessionsUserCmd = &cobra.Command{
+	Use:   "revoke",
+	Short: "Sign out session.",
+	Long:  `The revoke command lets you sign out a specific session (e.g. of a lost laptop). Its tokens are rejected and open shells are closed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		RequireStringFlag(sessionId, "session-id")
+
+		err := services.DeleteSession(sessionId, "")
+		if err != nil {
+			utils.FatalError(err.Error())
+		}
+		utils.PrintInfo(fmt.Sprintf("Session %s successfully signed out.", sessionId))
+	},
+}
+
 func init() {
 	userCmd.AddCommand(listUserCmd)
 
@@ -162,5 +360,44 @@ func init() {
 	userCmd.AddCommand(getUserCmd)
 	getUserCmd.Flags().StringVarP(&userId, "userid", "u", "", "UserId of the user")
 
+	userCmd.AddCommand(unlockUserCmd)
+	unlockUserCmd.Flags().StringVarP(&userId, "user-id", "u", "", "UserId of the user")
+
+	userCmd.AddCommand(mfaUserCmd)
+	mfaUserCmd.AddCommand(resetMfaUserCmd)
+	resetMfaUserCmd.Flags().StringVarP(&userId, "user-id", "u", "", "UserId of the user")
+	mfaUserCmd.AddCommand(requireMfaUserCmd)
+	requireMfaUserCmd.Flags().StringVarP(&userId, "user-id", "u", "", "UserId of the user")
+	requireMfaUserCmd.Flags().BoolVar(&mfaDisable, "disable", false, "No longer require a second factor")
+
+	userCmd.AddCommand(bindingUserCmd)
+	bindingUserCmd.AddCommand(listBindingUserCmd)
+	listBindingUserCmd.Flags().StringVarP(&userId, "user-id", "u", "", "UserId of the user")
+	bindingUserCmd.AddCommand(addBindingUserCmd)
+	addBindingUserCmd.Flags().StringVarP(&userId, "user-id", "u", "", "UserId of the user")
+	addBindingUserCmd.Flags().StringVarP(&groupId, "group-id", "g", "", "Id of the group (instead of a user)")
+	addBindingUserCmd.Flags().StringVarP(&accessLevel, "accesslevel", "a", "", "Role within the context: valid values are READER, USER, or ADMIN")
+	addBindingUserCmd.Flags().StringVarP(&namespace, "namespace", "n", "*", "Namespace pattern the binding is restricted to (e.g. 'team-a-*')")
+	bindingUserCmd.AddCommand(deleteBindingUserCmd)
+	deleteBindingUserCmd.Flags().StringVarP(&bindingId, "binding-id", "b", "", "Id of the role binding")
+
+	userCmd.AddCommand(tokenUserCmd)
+	tokenUserCmd.AddCommand(createTokenUserCmd)
+	createTokenUserCmd.Flags().StringVarP(&userId, "user-id", "u", "", "UserId of the token owner")
+	createTokenUserCmd.Flags().StringVarP(&tokenName, "name", "n", "", "Name of the token")
+	createTokenUserCmd.Flags().StringVarP(&tokenExpiresIn, "expires-in", "e", "", "Lifetime of the token (e.g. 720h), default: never expires")
+	createTokenUserCmd.Flags().StringSliceVar(&tokenContexts, "contexts", []string{}, "Restrict the token to these context ids (separated by comma)")
+	createTokenUserCmd.Flags().StringSliceVar(&tokenNamespaces, "namespaces", []string{}, "Restrict the token to these namespace patterns (separated by comma)")
+	createTokenUserCmd.Flags().StringSliceVar(&tokenVerbs, "verbs", []string{}, "Restrict the token to these HTTP verbs, e.g. GET,PATCH (separated by comma)")
+	tokenUserCmd.AddCommand(listTokenUserCmd)
+	listTokenUserCmd.Flags().StringVarP(&userId, "user-id", "u", "", "UserId of the token owner")
+	tokenUserCmd.AddCommand(revokeTokenUserCmd)
+	revokeTokenUserCmd.Flags().StringVarP(&tokenId, "token-id", "t", "", "Id of the token")
+
+	userCmd.AddCommand(sessionsUserCmd)
+	sessionsUserCmd.Flags().StringVarP(&userId, "user-id", "u", "", "UserId of the session owner")
+	sessionsUserCmd.AddCommand(revokeSessionsUserCmd)
+	revokeSessionsUserCmd.Flags().StringVar(&sessionId, "session-id", "", "Id of the session")
+
 	rootCmd.AddCommand(userCmd)
 }
@@ -68,6 +68,8 @@ var podDeleteCmd = &cobra.Command{
 		RequireStringFlag(resource, "resource")
 		RequireStringFlag(contextId, "context-id")
 
+		confirmContextDelete(contextId)
+
 		pod := kubernetes.GetPod(namespace, resource, &contextId)
 		if pod != nil {
 			kubernetes.DeleteK8sPod(*pod, &contextId)
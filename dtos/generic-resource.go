@@ -0,0 +1,30 @@
+package dtos
+
+import "fmt"
+
+// GenericResource is a resource (built-in or of a CRD) served by the api server of a context.
+type GenericResource struct {
+	Group      string   `json:"group"` // empty for the core group ("core" in routes)
+	Version    string   `json:"version"`
+	Resource   string   `json:"resource"` // plural, e.g. "rollouts"
+	Kind       string   `json:"kind"`
+	Namespaced bool     `json:"namespaced"`
+	ShortNames []string `json:"shortNames,omitempty"`
+	Verbs      []string `json:"verbs"`
+}
+
+// FullName is the resource as used by kubectl, e.g. "pods" or "rollouts.argoproj.io".
+func (r GenericResource) FullName() string {
+	if r.Group == "" {
+		return r.Resource
+	}
+	return fmt.Sprintf("%s.%s", r.Resource, r.Group)
+}
+
+// GroupVersion as written in apiVersion, e.g. "v1" or "argoproj.io/v1alpha1".
+func (r GenericResource) GroupVersion() string {
+	if r.Group == "" {
+		return r.Version
+	}
+	return fmt.Sprintf("%s/%s", r.Group, r.Version)
+}
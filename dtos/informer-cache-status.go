@@ -0,0 +1,16 @@
+package dtos
+
+type InformerCacheStatus struct {
+	ContextId string                    `json:"contextId"`
+	Started   string                    `json:"started"`
+	LastUsed  string                    `json:"lastUsed"`
+	Kinds     []InformerCacheKindStatus `json:"kinds"`
+}
+
+type InformerCacheKindStatus struct {
+	Kind      string `json:"kind"`
+	Synced    bool   `json:"synced"` // lists are served live until the informer has synced
+	Items     int    `json:"items"`
+	Started   string `json:"started"`
+	LastError string `json:"lastError,omitempty"` // last failed list/watch (e.g. missing permissions)
+}
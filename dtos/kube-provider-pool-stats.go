@@ -0,0 +1,20 @@
+package dtos
+
+type KubeProviderPoolStats struct {
+	Qps           float32                        `json:"qps"` // shared by all clients of a context
+	Burst         int                            `json:"burst"`
+	Hits          uint64                         `json:"hits"`
+	Misses        uint64                         `json:"misses"`
+	Invalidations uint64                         `json:"invalidations"` // contexts dropped because they changed
+	Evictions     uint64                         `json:"evictions"`     // clients dropped because they were idle
+	Contexts      []KubeProviderPoolContextStats `json:"contexts"`
+}
+
+type KubeProviderPoolContextStats struct {
+	ContextId  string   `json:"contextId"`  // empty for the cluster punq runs in (or the local default kubeconfig)
+	Identities int      `json:"identities"` // own identity plus impersonated users
+	Clients    []string `json:"clients"`    // typed clientsets created so far
+	Hits       uint64   `json:"hits"`
+	Created    string   `json:"created"`
+	LastUsed   string   `json:"lastUsed"`
+}
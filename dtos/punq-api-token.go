@@ -0,0 +1,114 @@
+package dtos
+
+import (
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/mogenius/punq/utils"
+)
+
+// A PunqApiToken is a named personal access token of a user (e.g. for CI). Only the hash of the secret part is stored.
+// Empty Contexts/Namespaces/Verbs mean "no restriction". Verbs are HTTP methods (GET, POST, PATCH, DELETE).
+type PunqApiToken struct {
+	Id         string   `json:"id" validate:"required"`
+	UserId     string   `json:"userId" validate:"required"`
+	Name       string   `json:"name" validate:"required"`
+	TokenHash  string   `json:"tokenHash,omitempty"`
+	Contexts   []string `json:"contexts"`
+	Namespaces []string `json:"namespaces"`
+	Verbs      []string `json:"verbs"`
+	ExpiresAt  string   `json:"expiresAt,omitempty"`
+	LastUsed   string   `json:"lastUsed,omitempty"`
+	Created    string   `json:"createdAt" validate:"required"`
+}
+
+type PunqApiTokenCreateInput struct {
+	Name       string   `json:"name" validate:"required"`
+	Contexts   []string `json:"contexts"`
+	Namespaces []string `json:"namespaces"`
+	Verbs      []string `json:"verbs"`
+	ExpiresAt  string   `json:"expiresAt,omitempty"` // RFC3339, empty = never
+}
+
+// PunqApiTokenCreated is returned once after creation. The plain token cannot be retrieved again.
+type PunqApiTokenCreated struct {
+	PunqApiToken
+	Token string `json:"token" validate:"required"`
+}
+
+func (t *PunqApiToken) IsExpired() bool {
+	if t.ExpiresAt == "" {
+		return false
+	}
+	expiresAt, err := time.Parse(time.RFC3339, t.ExpiresAt)
+	if err != nil {
+		return true
+	}
+	return time.Now().After(expiresAt)
+}
+
+func (t *PunqApiToken) AllowsContext(contextId *string) bool {
+	if len(t.Contexts) == 0 {
+		return true
+	}
+	return contextId != nil && utils.ContainsEqual(t.Contexts, *contextId)
+}
+
+// AllowsNamespace: an empty namespace (cluster-scoped or all namespaces) is only allowed for unrestricted tokens.
+func (t *PunqApiToken) AllowsNamespace(namespace string) bool {
+	if len(t.Namespaces) == 0 {
+		return true
+	}
+	for _, pattern := range t.Namespaces {
+		if pattern == RoleBindingWildcard {
+			return true
+		}
+		if namespace == "" {
+			continue
+		}
+		if matched, err := path.Match(pattern, namespace); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *PunqApiToken) AllowsVerb(method string) bool {
+	if len(t.Verbs) == 0 {
+		return true
+	}
+	// HEAD/OPTIONS are treated like GET
+	if method == http.MethodHead || method == http.MethodOptions {
+		method = http.MethodGet
+	}
+	for _, verb := range t.Verbs {
+		if strings.EqualFold(verb, method) {
+			return true
+		}
+	}
+	return false
+}
+
+func ListApiTokens(tokens []PunqApiToken) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"#", "ID", "Name", "UserId", "Contexts", "Namespaces", "Verbs", "Expires", "LastUsed", "Created"})
+	for index, token := range tokens {
+		expires := "never"
+		if token.ExpiresAt != "" {
+			expires = token.ExpiresAt
+		}
+		lastUsed := "never"
+		if token.LastUsed != "" {
+			lastUsed = utils.JsonStringToHumanDuration(token.LastUsed)
+		}
+		t.AppendRow(
+			table.Row{index + 1, token.Id, token.Name, token.UserId, strings.Join(token.Contexts, ","), strings.Join(token.Namespaces, ","), strings.Join(token.Verbs, ","), expires, lastUsed, utils.JsonStringToHumanDuration(token.Created)},
+		)
+	}
+	t.Render()
+}
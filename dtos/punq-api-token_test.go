@@ -0,0 +1,99 @@
+package dtos
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestApiTokenAllowsContext(t *testing.T) {
+	staging := "staging"
+	production := "production"
+	tests := []struct {
+		name      string
+		contexts  []string
+		contextId *string
+		want      bool
+	}{
+		{"unrestricted", nil, &production, true},
+		{"unrestricted without context", nil, nil, true},
+		{"listed context", []string{"staging"}, &staging, true},
+		{"other context", []string{"staging"}, &production, false},
+		{"restricted without context", []string{"staging"}, nil, false},
+	}
+
+	for _, test := range tests {
+		token := PunqApiToken{Contexts: test.contexts}
+		if got := token.AllowsContext(test.contextId); got != test.want {
+			t.Errorf("%s: AllowsContext() = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestApiTokenAllowsNamespace(t *testing.T) {
+	tests := []struct {
+		namespaces []string
+		namespace  string
+		want       bool
+	}{
+		{nil, "team-a", true},
+		{nil, "", true},
+		{[]string{RoleBindingWildcard}, "", true},
+		{[]string{"team-a"}, "team-a", true},
+		{[]string{"team-a"}, "team-b", false},
+		{[]string{"team-a"}, "", false},
+		{[]string{"team-*"}, "team-b", true},
+		{[]string{"team-*"}, "kube-system", false},
+		{[]string{"["}, "[", false},
+	}
+
+	for _, test := range tests {
+		token := PunqApiToken{Namespaces: test.namespaces}
+		if got := token.AllowsNamespace(test.namespace); got != test.want {
+			t.Errorf("AllowsNamespace(%q) with %v = %v, want %v", test.namespace, test.namespaces, got, test.want)
+		}
+	}
+}
+
+func TestApiTokenAllowsVerb(t *testing.T) {
+	tests := []struct {
+		verbs  []string
+		method string
+		want   bool
+	}{
+		{nil, http.MethodDelete, true},
+		{[]string{"GET"}, http.MethodGet, true},
+		{[]string{"get"}, http.MethodGet, true},
+		{[]string{"GET"}, http.MethodHead, true},
+		{[]string{"GET"}, http.MethodOptions, true},
+		{[]string{"GET"}, http.MethodPost, false},
+		{[]string{"POST", "PATCH"}, http.MethodPatch, true},
+		{[]string{"POST", "PATCH"}, http.MethodDelete, false},
+	}
+
+	for _, test := range tests {
+		token := PunqApiToken{Verbs: test.verbs}
+		if got := token.AllowsVerb(test.method); got != test.want {
+			t.Errorf("AllowsVerb(%s) with %v = %v, want %v", test.method, test.verbs, got, test.want)
+		}
+	}
+}
+
+func TestApiTokenIsExpired(t *testing.T) {
+	tests := []struct {
+		expiresAt string
+		want      bool
+	}{
+		{"", false},
+		{time.Now().Add(time.Hour).Format(time.RFC3339), false},
+		{time.Now().Add(-time.Hour).Format(time.RFC3339), true},
+		{"tomorrow", true},
+	}
+
+	for _, test := range tests {
+		token := PunqApiToken{ExpiresAt: test.expiresAt}
+		if got := token.IsExpired(); got != test.want {
+			t.Errorf("IsExpired() with %q = %v, want %v", test.expiresAt, got, test.want)
+		}
+	}
+}
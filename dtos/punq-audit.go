@@ -0,0 +1,71 @@
+package dtos
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/mogenius/punq/utils"
+)
+
+const (
+	AuditResultSuccess = "success"
+	AuditResultFailure = "failure"
+	AuditResultDenied  = "denied"
+)
+
+type PunqAuditEntry struct {
+	Id          string `json:"id" validate:"required"`
+	Timestamp   string `json:"timestamp" validate:"required"`
+	UserId      string `json:"userId"`
+	UserEmail   string `json:"userEmail,omitempty"`
+	ApiTokenId  string `json:"apiTokenId,omitempty"`
+	ClientIp    string `json:"clientIp"`
+	ContextId   string `json:"contextId,omitempty"`
+	Verb        string `json:"verb" validate:"required"`
+	Path        string `json:"path" validate:"required"`
+	Kind        string `json:"kind,omitempty"`
+	Namespace   string `json:"namespace,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Subresource string `json:"subresource,omitempty"`
+	BodyHash    string `json:"bodyHash,omitempty"` // sha256 of the request body
+	Status      int    `json:"status"`
+	Result      string `json:"result" validate:"required"`
+	Duration    string `json:"duration,omitempty"`
+}
+
+func AuditResultForStatus(status int) string {
+	switch {
+	case status == 401 || status == 403:
+		return AuditResultDenied
+	case status >= 400:
+		return AuditResultFailure
+	default:
+		return AuditResultSuccess
+	}
+}
+
+func ListAuditEntries(entries []PunqAuditEntry) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"#", "Time", "User", "Context", "Verb", "Resource", "Status", "Result"})
+	for index, entry := range entries {
+		resource := entry.Kind
+		if entry.Namespace != "" {
+			resource = fmt.Sprintf("%s %s/%s", resource, entry.Namespace, entry.Name)
+		} else if entry.Name != "" {
+			resource = fmt.Sprintf("%s %s", resource, entry.Name)
+		}
+		if entry.Subresource != "" {
+			resource = fmt.Sprintf("%s (%s)", resource, entry.Subresource)
+		}
+		user := entry.UserEmail
+		if user == "" {
+			user = entry.UserId
+		}
+		t.AppendRow(
+			table.Row{index + 1, utils.JsonStringToHumanDuration(entry.Timestamp), user, entry.ContextId, entry.Verb, resource, entry.Status, entry.Result},
+		)
+	}
+	t.Render()
+}
@@ -0,0 +1,164 @@
+package dtos
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mogenius/punq/utils"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+const (
+	CredentialsClientCertificate = "client-certificate"
+	CredentialsToken             = "token"
+	CredentialsExec              = "exec"
+	CredentialsAuthProvider      = "auth-provider"
+	CredentialsBasic             = "basic"
+	CredentialsNone              = "none"
+)
+
+// PunqContextCredentials describes the auth material of a kubeconfig. It is derived from the kubeconfig and never
+// persisted.
+type PunqContextCredentials struct {
+	Type        string   `json:"type"`
+	ExpiresAt   string   `json:"expiresAt,omitempty"`
+	Expired     bool     `json:"expired"`
+	ExpiresSoon bool     `json:"expiresSoon"` // within utils.CONFIG.Kubernetes.CredentialWarningDays
+	Warnings    []string `json:"warnings,omitempty"`
+}
+
+func (c *PunqContextCredentials) setExpiry(expiresAt time.Time, what string) {
+	c.ExpiresAt = expiresAt.Format(time.RFC3339)
+	if time.Now().After(expiresAt) {
+		c.Expired = true
+		c.Warnings = append(c.Warnings, fmt.Sprintf("%s expired %s ago", what, utils.HumanDuration(time.Since(expiresAt))))
+		return
+	}
+	warningDays := utils.CONFIG.Kubernetes.CredentialWarningDays
+	if warningDays > 0 && time.Until(expiresAt) < time.Duration(warningDays)*24*time.Hour {
+		c.ExpiresSoon = true
+		c.Warnings = append(c.Warnings, fmt.Sprintf("%s expires in %s", what, utils.HumanDuration(time.Until(expiresAt))))
+	}
+}
+
+// ParseContextCredentials inspects the credentials of the current context of a kubeconfig.
+func ParseContextCredentials(kubeconfig string) PunqContextCredentials {
+	result := PunqContextCredentials{Type: CredentialsNone}
+
+	config, err := clientcmd.Load([]byte(kubeconfig))
+	if err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("kubeconfig cannot be parsed: %s", err.Error()))
+		return result
+	}
+	context, exists := config.Contexts[config.CurrentContext]
+	if !exists {
+		result.Warnings = append(result.Warnings, "kubeconfig has no current context")
+		return result
+	}
+	authInfo, exists := config.AuthInfos[context.AuthInfo]
+	if !exists {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("user '%s' not found in kubeconfig", context.AuthInfo))
+		return result
+	}
+
+	parseAuthInfoCredentials(authInfo, &result)
+	return result
+}
+
+func parseAuthInfoCredentials(authInfo *api.AuthInfo, result *PunqContextCredentials) {
+	switch {
+	case len(authInfo.ClientCertificateData) > 0:
+		result.Type = CredentialsClientCertificate
+		notAfter, err := certificateNotAfter(authInfo.ClientCertificateData)
+		if err != nil {
+			result.Warnings = append(result.Warnings, err.Error())
+			return
+		}
+		result.setExpiry(notAfter, "client certificate")
+	case authInfo.ClientCertificate != "":
+		result.Type = CredentialsClientCertificate
+		result.Warnings = append(result.Warnings, fmt.Sprintf("client certificate is referenced by path '%s' which is not available in cluster", authInfo.ClientCertificate))
+	case authInfo.Token != "":
+		result.Type = CredentialsToken
+		exp, isJwt := jwtExpiry(authInfo.Token)
+		if exp != nil {
+			result.setExpiry(*exp, "token")
+		} else if isJwt {
+			result.Warnings = append(result.Warnings, "token never expires")
+		}
+	case authInfo.TokenFile != "":
+		result.Type = CredentialsToken
+		result.Warnings = append(result.Warnings, fmt.Sprintf("token is referenced by path '%s' which is not available in cluster", authInfo.TokenFile))
+	case authInfo.Exec != nil:
+		result.Type = CredentialsExec
+		result.Warnings = append(result.Warnings, fmt.Sprintf("exec plugin '%s' cannot run in cluster", authInfo.Exec.Command))
+	case authInfo.AuthProvider != nil:
+		result.Type = CredentialsAuthProvider
+		if exp, _ := jwtExpiry(authInfo.AuthProvider.Config["id-token"]); exp != nil {
+			result.setExpiry(*exp, "id-token")
+		}
+		result.Warnings = append(result.Warnings, fmt.Sprintf("auth-provider '%s' cannot refresh its credentials in cluster", authInfo.AuthProvider.Name))
+	case authInfo.Username != "":
+		result.Type = CredentialsBasic
+	}
+}
+
+func certificateNotAfter(data []byte) (time.Time, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return time.Time{}, fmt.Errorf("client certificate is no valid PEM")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("client certificate cannot be parsed: %s", err.Error())
+	}
+	return cert.NotAfter, nil
+}
+
+// jwtExpiry reads the exp claim of a JWT without verifying it. isJwt is false for opaque tokens.
+func jwtExpiry(token string) (exp *time.Time, isJwt bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, false
+	}
+	claims := struct {
+		Exp *int64 `json:"exp"`
+	}{}
+	if json.Unmarshal(payload, &claims) != nil {
+		return nil, false
+	}
+	if claims.Exp == nil {
+		return nil, true
+	}
+	expiresAt := time.Unix(*claims.Exp, 0)
+	return &expiresAt, true
+}
+
+// Summary is a short description for terminal tables.
+func (c *PunqContextCredentials) Summary() string {
+	summary := c.Type
+	if c.ExpiresAt != "" {
+		expiresAt, err := time.Parse(time.RFC3339, c.ExpiresAt)
+		if err == nil {
+			if c.Expired {
+				summary = fmt.Sprintf("%s, expired ❌", summary)
+			} else {
+				summary = fmt.Sprintf("%s, expires in %s", summary, utils.HumanDuration(time.Until(expiresAt)))
+			}
+		}
+	}
+	if c.ExpiresSoon || (len(c.Warnings) > 0 && !c.Expired) {
+		summary = fmt.Sprintf("%s ⚠️", summary)
+	}
+	return summary
+}
@@ -0,0 +1,115 @@
+package dtos
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mogenius/punq/utils"
+)
+
+func testCertificate(t *testing.T, notAfter time.Time) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "punq-test"},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func testJwt(payload string) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	return fmt.Sprintf("%s.%s.%s", encode([]byte(`{"alg":"none"}`)), encode([]byte(payload)), "signature")
+}
+
+func testKubeconfig(user string) string {
+	return `apiVersion: v1
+kind: Config
+current-context: test
+clusters:
+- name: test
+  cluster:
+    server: https://127.0.0.1:6443
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+users:
+- name: test
+  user:
+` + user
+}
+
+func TestParseContextCredentials(t *testing.T) {
+	previousWarningDays := utils.CONFIG.Kubernetes.CredentialWarningDays
+	utils.CONFIG.Kubernetes.CredentialWarningDays = 14
+	t.Cleanup(func() { utils.CONFIG.Kubernetes.CredentialWarningDays = previousWarningDays })
+
+	now := time.Now()
+	tests := []struct {
+		name        string
+		kubeconfig  string
+		wantType    string
+		wantExpiry  bool
+		expired     bool
+		expiresSoon bool
+		warning     string
+	}{
+		{"not parsable", "{", CredentialsNone, false, false, false, "cannot be parsed"},
+		{"no current context", "apiVersion: v1\nkind: Config\n", CredentialsNone, false, false, false, "no current context"},
+		{"unknown user", strings.Replace(testKubeconfig("    username: admin\n"), "    user: test", "    user: other", 1), CredentialsNone, false, false, false, "user 'other' not found"},
+		{"valid certificate", testKubeconfig("    client-certificate-data: " + testCertificate(t, now.Add(365*24*time.Hour)) + "\n"), CredentialsClientCertificate, true, false, false, ""},
+		{"expiring certificate", testKubeconfig("    client-certificate-data: " + testCertificate(t, now.Add(3*24*time.Hour)) + "\n"), CredentialsClientCertificate, true, false, true, "client certificate expires in"},
+		{"expired certificate", testKubeconfig("    client-certificate-data: " + testCertificate(t, now.Add(-time.Hour)) + "\n"), CredentialsClientCertificate, true, true, false, "client certificate expired"},
+		{"invalid certificate", testKubeconfig("    client-certificate-data: " + base64.StdEncoding.EncodeToString([]byte("garbage")) + "\n"), CredentialsClientCertificate, false, false, false, "no valid PEM"},
+		{"certificate path", testKubeconfig("    client-certificate: /tmp/client.crt\n"), CredentialsClientCertificate, false, false, false, "referenced by path"},
+		{"opaque token", testKubeconfig("    token: abcdef\n"), CredentialsToken, false, false, false, ""},
+		{"jwt without exp", testKubeconfig("    token: " + testJwt(`{"sub":"test"}`) + "\n"), CredentialsToken, false, false, false, "never expires"},
+		{"expired jwt", testKubeconfig("    token: " + testJwt(fmt.Sprintf(`{"exp":%d}`, now.Add(-time.Hour).Unix())) + "\n"), CredentialsToken, true, true, false, "token expired"},
+		{"valid jwt", testKubeconfig("    token: " + testJwt(fmt.Sprintf(`{"exp":%d}`, now.Add(90*24*time.Hour).Unix())) + "\n"), CredentialsToken, true, false, false, ""},
+		{"token file", testKubeconfig("    tokenFile: /var/run/token\n"), CredentialsToken, false, false, false, "referenced by path"},
+		{"exec plugin", testKubeconfig("    exec:\n      apiVersion: client.authentication.k8s.io/v1\n      command: aws\n"), CredentialsExec, false, false, false, "exec plugin 'aws'"},
+		{"auth provider", testKubeconfig("    auth-provider:\n      name: oidc\n      config:\n        id-token: " + testJwt(fmt.Sprintf(`{"exp":%d}`, now.Add(-time.Hour).Unix())) + "\n"), CredentialsAuthProvider, true, true, false, "auth-provider 'oidc'"},
+		{"basic auth", testKubeconfig("    username: admin\n    password: secret\n"), CredentialsBasic, false, false, false, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := ParseContextCredentials(test.kubeconfig)
+			if got.Type != test.wantType {
+				t.Errorf("Type = %s, want %s", got.Type, test.wantType)
+			}
+			if (got.ExpiresAt != "") != test.wantExpiry {
+				t.Errorf("ExpiresAt = '%s', want expiry %v", got.ExpiresAt, test.wantExpiry)
+			}
+			if got.Expired != test.expired || got.ExpiresSoon != test.expiresSoon {
+				t.Errorf("Expired = %v, ExpiresSoon = %v, want %v, %v", got.Expired, got.ExpiresSoon, test.expired, test.expiresSoon)
+			}
+			if test.warning == "" && len(got.Warnings) > 0 {
+				t.Errorf("unexpected warnings %v", got.Warnings)
+			}
+			if test.warning != "" && !strings.Contains(strings.Join(got.Warnings, "\n"), test.warning) {
+				t.Errorf("warnings %v do not contain '%s'", got.Warnings, test.warning)
+			}
+		})
+	}
+}
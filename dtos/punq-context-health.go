@@ -0,0 +1,21 @@
+package dtos
+
+// A PunqContextProbe is the result of a single health check of a context.
+type PunqContextProbe struct {
+	Time          string `json:"time" validate:"required"`
+	Reachable     bool   `json:"reachable"`
+	LatencyMs     int64  `json:"latencyMs"`
+	ServerVersion string `json:"serverVersion,omitempty"`
+	NodeCount     int    `json:"nodeCount"`
+	Provider      string `json:"provider,omitempty"`
+	AuthError     bool   `json:"authError,omitempty"` // credentials expired or revoked
+	Error         string `json:"error,omitempty"`
+}
+
+type PunqContextHealth struct {
+	ContextId string             `json:"contextId" validate:"required"`
+	Name      string             `json:"name" validate:"required"`
+	Reachable bool               `json:"reachable"`
+	LastProbe *PunqContextProbe  `json:"lastProbe,omitempty"`
+	History   []PunqContextProbe `json:"history"` // oldest first
+}
@@ -0,0 +1,130 @@
+package dtos
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	EnvironmentDevelopment = "development"
+	EnvironmentStaging     = "staging"
+	EnvironmentProduction  = "production"
+
+	// the environment is selectable like a label (e.g. "environment=production,team=payments")
+	EnvironmentLabel = "environment"
+)
+
+var Environments = []string{EnvironmentDevelopment, EnvironmentStaging, EnvironmentProduction}
+
+// default colors of the environments if a context has no own color
+var EnvironmentColors = map[string]string{
+	EnvironmentDevelopment: "#2e7d32",
+	EnvironmentStaging:     "#ef6c00",
+	EnvironmentProduction:  "#c62828",
+}
+
+// LabelSet returns the labels of the context including its environment.
+func (c *PunqContext) LabelSet() labels.Set {
+	set := labels.Set{}
+	for key, value := range c.Labels {
+		set[key] = value
+	}
+	if c.Environment != "" {
+		set[EnvironmentLabel] = c.Environment
+	}
+	return set
+}
+
+// DisplayColor is the color of the context or the default color of its environment.
+func (c *PunqContext) DisplayColor() string {
+	if c.Color != "" {
+		return c.Color
+	}
+	return EnvironmentColors[c.Environment]
+}
+
+// LabelsString formats the labels sorted by key ("env=prod,team=payments").
+func (c *PunqContext) LabelsString() string {
+	return c.LabelSet().String()
+}
+
+// MatchesSelector checks the context against a label selector like kubectl ("env=prod,region in (eu,us),!legacy").
+func (c *PunqContext) MatchesSelector(selector string) (bool, error) {
+	parsed, err := labels.Parse(selector)
+	if err != nil {
+		return false, fmt.Errorf("invalid selector '%s': %s", selector, err.Error())
+	}
+	return parsed.Matches(c.LabelSet()), nil
+}
+
+// FilterContextsBySelector returns the contexts matching selector (all contexts for an empty selector).
+func FilterContextsBySelector(contexts []PunqContext, selector string) ([]PunqContext, error) {
+	parsed, err := labels.Parse(selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector '%s': %s", selector, err.Error())
+	}
+	result := []PunqContext{}
+	for _, ctx := range contexts {
+		if parsed.Matches(ctx.LabelSet()) {
+			result = append(result, ctx)
+		}
+	}
+	return result, nil
+}
+
+// ValidateLabels checks keys and values with the rules of kubernetes labels.
+func (c *PunqContext) ValidateLabels() error {
+	keys := []string{}
+	for key := range c.Labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if key == EnvironmentLabel {
+			return fmt.Errorf("label '%s' is reserved, please set the environment instead", EnvironmentLabel)
+		}
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("invalid label key '%s': %s", key, strings.Join(errs, "; "))
+		}
+		if errs := validation.IsValidLabelValue(c.Labels[key]); len(errs) > 0 {
+			return fmt.Errorf("invalid value of label '%s': %s", key, strings.Join(errs, "; "))
+		}
+	}
+	if c.Environment != "" && !isEnvironment(c.Environment) {
+		return fmt.Errorf("invalid environment '%s' (valid: %s)", c.Environment, strings.Join(Environments, ", "))
+	}
+	return nil
+}
+
+// ApplyLabelArgs applies kubectl style label arguments: "key=value" sets a label, "key-" removes it.
+func (c *PunqContext) ApplyLabelArgs(args []string) error {
+	if c.Labels == nil {
+		c.Labels = map[string]string{}
+	}
+	for _, arg := range args {
+		if strings.HasSuffix(arg, "-") && !strings.Contains(arg, "=") {
+			delete(c.Labels, strings.TrimSuffix(arg, "-"))
+			continue
+		}
+		key, value, found := strings.Cut(arg, "=")
+		if !found || key == "" {
+			return fmt.Errorf("invalid label '%s' (expected key=value or key-)", arg)
+		}
+		c.Labels[key] = value
+	}
+	return c.ValidateLabels()
+}
+
+func isEnvironment(environment string) bool {
+	for _, env := range Environments {
+		if env == environment {
+			return true
+		}
+	}
+	return false
+}
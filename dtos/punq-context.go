@@ -19,7 +19,26 @@ type PunqContext struct {
 	Provider    string      `json:"provider" validate:"required"`
 	Reachable   bool        `json:"reachable" validate:"required"`
 	Users       []string    `json:"users" validate:"required"`
+	Groups      []string    `json:"groups"`
 	AccessLevel AccessLevel `json:"accessLevel" validate:"required"`
+	// if set, requests are sent with the identity of the punq user (Impersonate-User/-Group) so the cluster RBAC applies
+	Impersonate bool `json:"impersonate,omitempty"`
+	// "<namespace>/<name>" of the ServiceAccount punq minted in the cluster for this context (revoked on delete)
+	RemoteServiceAccount string `json:"remoteServiceAccount,omitempty"`
+	// arbitrary labels (team=payments, region=eu) usable in selectors
+	Labels map[string]string `json:"labels,omitempty"`
+	// development, staging or production (selectable as label "environment")
+	Environment string `json:"environment,omitempty"`
+	// color in the ui, defaults to the color of the environment
+	Color string `json:"color,omitempty"`
+	// if set, the context is left out of merged kubeconfigs (GET /context/kubeconfig)
+	DisableKubeconfigExport bool `json:"disableKubeconfigExport,omitempty"`
+	// if set, lists are served from informers watching the cluster (started on first use, stopped when idle)
+	InformerCache bool `json:"informerCache,omitempty"`
+	// stored form of Context if encryption at rest is enabled ("v1:<data key id>:<base64 nonce+ciphertext>")
+	EncryptedContext string `json:"encryptedContext,omitempty"`
+	// derived from Context when contexts are listed (never persisted)
+	Credentials *PunqContextCredentials `json:"credentials,omitempty"`
 }
 
 func CreateContext(id string, name string, context string, provider string, minAccessLevel AccessLevel) PunqContext {
@@ -35,6 +54,8 @@ func CreateContext(id string, name string, context string, provider string, minA
 
 	ctx.Context = context
 	ctx.ContextHash = utils.HashString(context)
+	credentials := ParseContextCredentials(context)
+	ctx.Credentials = &credentials
 
 	if provider == "" {
 		ctx.Provider = "UNKNOWN"
@@ -44,6 +65,7 @@ func CreateContext(id string, name string, context string, provider string, minA
 
 	ctx.AccessLevel = minAccessLevel
 	ctx.Users = []string{}
+	ctx.Groups = []string{}
 
 	return ctx
 }
@@ -69,12 +91,33 @@ func (c *PunqContext) RemoveAccess(userIdToRemove string) {
 	c.Users = resultingArray
 }
 
+func (c *PunqContext) AddGroupAccess(newGroupId string) {
+	for _, group := range c.Groups {
+		if group == newGroupId {
+			// ALREADY EXISTS
+			return
+		}
+	}
+	// CREATE NEW
+	c.Groups = append(c.Groups, newGroupId)
+}
+
+func (c *PunqContext) RemoveGroupAccess(groupIdToRemove string) {
+	resultingArray := []string{}
+	for _, groupId := range c.Groups {
+		if groupId != groupIdToRemove {
+			resultingArray = append(resultingArray, groupId)
+		}
+	}
+	c.Groups = resultingArray
+}
+
 func (c *PunqContext) PrintToTerminal() {
 	t := table.NewWriter()
 	t.SetOutputMirror(os.Stdout)
-	t.AppendHeader(table.Row{"ID", "Name", "Min. AccessLevel", "Users with ", "Hash"})
+	t.AppendHeader(table.Row{"ID", "Name", "Min. AccessLevel", "Users with ", "Groups with ", "Hash"})
 	t.AppendRow(
-		table.Row{c.Id, c.Name, c.AccessLevel.String(), len(c.Users), c.ContextHash},
+		table.Row{c.Id, c.Name, c.AccessLevel.String(), len(c.Users), len(c.Groups), c.ContextHash},
 	)
 	t.Render()
 }
@@ -82,13 +125,25 @@ func (c *PunqContext) PrintToTerminal() {
 func ListContextsToTerminal(contexts []PunqContext) {
 	t := table.NewWriter()
 	t.SetOutputMirror(os.Stdout)
-	t.AppendHeader(table.Row{"#", "ID", "Name", "Reachable", "Provider", "Min. AccessLevel"})
+	t.AppendHeader(table.Row{"#", "ID", "Name", "Reachable", "Provider", "Credentials", "Labels", "Min. AccessLevel"})
+	warnings := []string{}
 	for index, context := range contexts {
+		credentials := context.Credentials
+		if credentials == nil {
+			parsed := ParseContextCredentials(context.Context)
+			credentials = &parsed
+		}
+		for _, warning := range credentials.Warnings {
+			warnings = append(warnings, fmt.Sprintf("%s: %s", context.Name, warning))
+		}
 		t.AppendRow(
-			table.Row{index + 1, context.Id, context.Name, utils.StatusEmoji(context.Reachable), context.Provider, context.AccessLevel.String()},
+			table.Row{index + 1, context.Id, context.Name, utils.StatusEmoji(context.Reachable), context.Provider, credentials.Summary(), context.LabelsString(), context.AccessLevel.String()},
 		)
 	}
 	t.Render()
+	for _, warning := range warnings {
+		fmt.Printf("⚠️  %s\n", warning)
+	}
 }
 
 func ExtractSingleConfigFromContext(config *api.Config, contextName string) (*api.Config, error) {
@@ -116,6 +171,41 @@ func ExtractSingleConfigFromContext(config *api.Config, contextName string) (*ap
 	return singleConfig, nil
 }
 
+// MergeContextsToConfig is the reverse of ExtractSingleConfigFromContext: the kubeconfigs of the contexts are merged
+// into one config. Contexts, clusters and users are named after the punq context (suffixed with the id if the name
+// is taken). The first context becomes the current context.
+func MergeContextsToConfig(contexts []PunqContext) (*api.Config, error) {
+	mergedConfig := api.NewConfig()
+	for _, ctx := range contexts {
+		config, err := clientcmd.Load([]byte(ctx.Context))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load kubeconfig of context %s: %s\n", ctx.Name, err.Error())
+		}
+		singleConfig, err := ExtractSingleConfigFromContext(config, config.CurrentContext)
+		if err != nil {
+			return nil, err
+		}
+		context := singleConfig.Contexts[singleConfig.CurrentContext]
+
+		name := ctx.Name
+		if _, exists := mergedConfig.Contexts[name]; exists {
+			name = fmt.Sprintf("%s-%s", ctx.Name, ctx.Id)
+		}
+		mergedConfig.Clusters[name] = singleConfig.Clusters[context.Cluster]
+		mergedConfig.AuthInfos[name] = singleConfig.AuthInfos[context.AuthInfo].DeepCopy()
+
+		mergedContext := context.DeepCopy()
+		mergedContext.Cluster = name
+		mergedContext.AuthInfo = name
+		mergedConfig.Contexts[name] = mergedContext
+
+		if mergedConfig.CurrentContext == "" {
+			mergedConfig.CurrentContext = name
+		}
+	}
+	return mergedConfig, nil
+}
+
 func WriteSingleConfigFileFromContext(config *api.Config, contextName string) error {
 	fileName := fmt.Sprintf("%s.yaml", contextName)
 
@@ -149,7 +239,11 @@ func ParseConfigToPunqContexts(data []byte) ([]PunqContext, error) {
 		if err != nil {
 			return result, err
 		}
-		result = append(result, CreateContext("", contextName, string(configBytes), "", ADMIN))
+		ctx := CreateContext("", contextName, string(configBytes), "", ADMIN)
+		for _, warning := range ctx.Credentials.Warnings {
+			fmt.Printf("Context '%s': %s\n", contextName, warning)
+		}
+		result = append(result, ctx)
 	}
 	sort.Slice(result, func(i, j int) bool {
 		return result[i].Name < result[j].Name
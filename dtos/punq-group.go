@@ -0,0 +1,69 @@
+package dtos
+
+import (
+	"os"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/mogenius/punq/utils"
+)
+
+type PunqGroup struct {
+	Id          string   `json:"id" validate:"required"`
+	Name        string   `json:"name" validate:"required"`
+	Description string   `json:"description"`
+	UserIds     []string `json:"userIds" validate:"required"`
+	Created     string   `json:"createdAt" validate:"required"`
+}
+
+type PunqGroupCreateInput struct {
+	Name        string   `json:"name" validate:"required"`
+	Description string   `json:"description"`
+	UserIds     []string `json:"userIds"`
+}
+
+func (g *PunqGroup) AddMember(newUserId string) {
+	for _, userId := range g.UserIds {
+		if userId == newUserId {
+			// ALREADY EXISTS
+			return
+		}
+	}
+	// CREATE NEW
+	g.UserIds = append(g.UserIds, newUserId)
+}
+
+func (g *PunqGroup) RemoveMember(userIdToRemove string) {
+	resultingArray := []string{}
+	for _, userId := range g.UserIds {
+		if userId != userIdToRemove {
+			resultingArray = append(resultingArray, userId)
+		}
+	}
+	g.UserIds = resultingArray
+}
+
+func (g *PunqGroup) HasMember(userId string) bool {
+	return utils.ContainsEqual(g.UserIds, userId)
+}
+
+func ListGroups(groups []PunqGroup) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"#", "ID", "Name", "Description", "Members", "Created"})
+	for index, group := range groups {
+		t.AppendRow(
+			table.Row{index + 1, group.Id, group.Name, group.Description, len(group.UserIds), utils.JsonStringToHumanDuration(group.Created)},
+		)
+	}
+	t.Render()
+}
+
+func (g *PunqGroup) PrintToTerminal() {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"ID", "Name", "Description", "Members"})
+	t.AppendRow(
+		table.Row{g.Id, g.Name, g.Description, len(g.UserIds)},
+	)
+	t.Render()
+}
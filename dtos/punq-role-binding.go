@@ -0,0 +1,69 @@
+package dtos
+
+import (
+	"os"
+	"path"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/mogenius/punq/utils"
+)
+
+// matches every context / every namespace including cluster-scoped resources
+const RoleBindingWildcard = "*"
+
+// A PunqRoleBinding grants a user or a group a role in a context, optionally restricted to namespaces matching a glob pattern (e.g. "team-a-*").
+// Bindings for a context override the global AccessLevel of the user in that context.
+type PunqRoleBinding struct {
+	Id        string      `json:"id" validate:"required"`
+	UserId    string      `json:"userId,omitempty"`
+	GroupId   string      `json:"groupId,omitempty"`
+	Role      AccessLevel `json:"role" validate:"required"`
+	ContextId string      `json:"contextId" validate:"required"`
+	Namespace string      `json:"namespace" validate:"required"`
+	Created   string      `json:"createdAt" validate:"required"`
+}
+
+type PunqRoleBindingCreateInput struct {
+	UserId    string      `json:"userId,omitempty"`
+	GroupId   string      `json:"groupId,omitempty"`
+	Role      AccessLevel `json:"role" validate:"required"`
+	ContextId string      `json:"contextId" validate:"required"`
+	Namespace string      `json:"namespace" validate:"required"`
+}
+
+// AppliesTo checks whether the binding targets the user directly or one of the given groups.
+func (binding *PunqRoleBinding) AppliesTo(userId string, groupIds []string) bool {
+	if binding.UserId != "" {
+		return binding.UserId == userId
+	}
+	return binding.GroupId != "" && utils.ContainsEqual(groupIds, binding.GroupId)
+}
+
+func (binding *PunqRoleBinding) MatchesContext(contextId string) bool {
+	return binding.ContextId == RoleBindingWildcard || binding.ContextId == contextId
+}
+
+// MatchesNamespace checks the namespace pattern. An empty namespace (cluster-scoped resources or
+// requests across all namespaces) is only matched by the wildcard.
+func (binding *PunqRoleBinding) MatchesNamespace(namespace string) bool {
+	if binding.Namespace == RoleBindingWildcard || binding.Namespace == "" {
+		return true
+	}
+	if namespace == "" {
+		return false
+	}
+	matched, err := path.Match(binding.Namespace, namespace)
+	return err == nil && matched
+}
+
+func ListRoleBindings(bindings []PunqRoleBinding) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"#", "ID", "UserId", "GroupId", "Role", "Context", "Namespace", "Created"})
+	for index, binding := range bindings {
+		t.AppendRow(
+			table.Row{index + 1, binding.Id, binding.UserId, binding.GroupId, binding.Role.String(), binding.ContextId, binding.Namespace, utils.JsonStringToHumanDuration(binding.Created)},
+		)
+	}
+	t.Render()
+}
@@ -0,0 +1,41 @@
+package dtos
+
+import "testing"
+
+func TestRoleBindingMatchesNamespace(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		namespace string
+		want      bool
+	}{
+		{RoleBindingWildcard, "team-a", true},
+		{RoleBindingWildcard, "", true},
+		{"", "team-a", true},
+		{"team-a", "team-a", true},
+		{"team-a", "team-b", false},
+		{"team-a", "", false},
+		{"team-*", "team-a", true},
+		{"team-*", "kube-system", false},
+		{"team-*", "", false},
+		{"[", "[", false},
+	}
+
+	for _, test := range tests {
+		binding := PunqRoleBinding{Namespace: test.pattern}
+		if got := binding.MatchesNamespace(test.namespace); got != test.want {
+			t.Errorf("MatchesNamespace(%q) with pattern %q = %v, want %v", test.namespace, test.pattern, got, test.want)
+		}
+	}
+}
+
+func TestRoleBindingAppliesTo(t *testing.T) {
+	userBinding := PunqRoleBinding{UserId: "u1"}
+	groupBinding := PunqRoleBinding{GroupId: "g1"}
+
+	if !userBinding.AppliesTo("u1", nil) || userBinding.AppliesTo("u2", []string{"g1"}) {
+		t.Error("user binding must only apply to its user")
+	}
+	if !groupBinding.AppliesTo("u2", []string{"g0", "g1"}) || groupBinding.AppliesTo("u1", []string{"g2"}) {
+		t.Error("group binding must only apply to members of its group")
+	}
+}
@@ -0,0 +1,45 @@
+package dtos
+
+import (
+	"os"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/mogenius/punq/utils"
+)
+
+// A PunqSession is created by a login and shared by all access/refresh tokens issued for it (claim "sid").
+type PunqSession struct {
+	Id           string `json:"id" validate:"required"`
+	UserId       string `json:"userId" validate:"required"`
+	ClientIp     string `json:"clientIp"`
+	UserAgent    string `json:"userAgent"`
+	Created      string `json:"createdAt" validate:"required"`
+	LastActivity string `json:"lastActivity,omitempty"`
+	ExpiresAt    string `json:"expiresAt" validate:"required"` // expiry of the latest refresh token
+	Current      bool   `json:"current,omitempty"`             // session of the requesting token (never persisted)
+}
+
+func (s *PunqSession) IsExpired() bool {
+	expiresAt, err := time.Parse(time.RFC3339, s.ExpiresAt)
+	if err != nil {
+		return true
+	}
+	return time.Now().After(expiresAt)
+}
+
+func ListSessions(sessions []PunqSession) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"#", "ID", "UserId", "ClientIp", "UserAgent", "LastActivity", "Expires", "Created"})
+	for index, session := range sessions {
+		lastActivity := "never"
+		if session.LastActivity != "" {
+			lastActivity = utils.JsonStringToHumanDuration(session.LastActivity)
+		}
+		t.AppendRow(
+			table.Row{index + 1, session.Id, session.UserId, session.ClientIp, session.UserAgent, lastActivity, session.ExpiresAt, utils.JsonStringToHumanDuration(session.Created)},
+		)
+	}
+	t.Render()
+}
@@ -1,11 +1,36 @@
 package dtos
 
+import "time"
+
 type PunqToken struct {
-	Token string `json:"token" validate:"required"`
+	Token        string `json:"token" validate:"required"`
+	RefreshToken string `json:"refreshToken" validate:"required"`
+	ExpiresAt    string `json:"expiresAt" validate:"required"`
 }
 
-func CreateToken(token string) *PunqToken {
+func CreateToken(token string, refreshToken string, expiresAt time.Time) *PunqToken {
 	return &PunqToken{
-		Token: token,
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresAt:    expiresAt.Format(time.RFC3339),
 	}
 }
+
+// PunqMfaChallenge is returned by the login instead of a PunqToken if the user has (or must enroll) a second factor.
+type PunqMfaChallenge struct {
+	MfaRequired        bool   `json:"mfaRequired"`
+	EnrollmentRequired bool   `json:"enrollmentRequired"`
+	MfaToken           string `json:"mfaToken" validate:"required"`
+	ExpiresAt          string `json:"expiresAt" validate:"required"`
+}
+
+type PunqTotpEnrollment struct {
+	Secret string `json:"secret" validate:"required"`
+	Url    string `json:"url" validate:"required"` // otpauth:// url for QR codes
+}
+
+type PunqMfaVerifyResult struct {
+	PunqToken
+	// only set once when the enrollment has been confirmed
+	RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+}
@@ -1,8 +1,9 @@
 package dtos
 
 import (
-	"errors"
+	"encoding/json"
 	"os"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 
@@ -17,22 +18,72 @@ type PunqUser struct {
 	DisplayName string      `json:"displayName" validate:"required"`
 	AccessLevel AccessLevel `json:"accessLevel" validate:"required"`
 	Created     string      `json:"createdAt" validate:"required"`
+	OidcIssuer  string      `json:"oidcIssuer,omitempty"`
+	OidcSubject string      `json:"oidcSubject,omitempty"`
+	// namespace/name of the ServiceAccount this user has been created for (login via TokenReview)
+	ServiceAccount string `json:"serviceAccount,omitempty"`
+	// tokens issued before this unix timestamp or for another generation are rejected (both are set on password or
+	// access-level changes, the generation also covers tokens issued within the same second)
+	TokensValidAfter int64 `json:"tokensValidAfter,omitempty"`
+	TokenGeneration  int64 `json:"tokenGeneration,omitempty"`
+	// consecutive failed logins and the RFC3339 time until which logins are rejected
+	FailedLogins int    `json:"failedLogins,omitempty"`
+	LockedUntil  string `json:"lockedUntil,omitempty"`
+	// second factor (RFC 6238 TOTP). The secret is pending until TotpEnabled is set by a confirmed code.
+	// Secret and recovery codes are only persisted (see PunqUserStored), never part of api responses.
+	TotpSecret        string   `json:"-"`
+	TotpEnabled       bool     `json:"totpEnabled,omitempty"`
+	TotpLastStep      int64    `json:"totpLastStep,omitempty"`
+	TotpRecoveryCodes []string `json:"-"`                     // sha256 hashes
+	MfaRequired       bool     `json:"mfaRequired,omitempty"` // enforced by an admin
+}
+
+// PunqUserStored is the persisted form of a PunqUser including its second factor.
+type PunqUserStored struct {
+	PunqUser
+	TotpSecret        string   `json:"totpSecret,omitempty"`
+	TotpRecoveryCodes []string `json:"totpRecoveryCodes,omitempty"`
+}
+
+// MarshalStoredUser returns the persisted form of the user.
+func MarshalStoredUser(user PunqUser) ([]byte, error) {
+	return json.Marshal(PunqUserStored{
+		PunqUser:          user,
+		TotpSecret:        user.TotpSecret,
+		TotpRecoveryCodes: user.TotpRecoveryCodes,
+	})
+}
+
+// UnmarshalStoredUser reads the persisted form of a user.
+func UnmarshalStoredUser(data []byte, user *PunqUser) error {
+	stored := PunqUserStored{}
+	err := json.Unmarshal(data, &stored)
+	if err != nil {
+		return err
+	}
+	*user = stored.PunqUser
+	user.TotpSecret = stored.TotpSecret
+	user.TotpRecoveryCodes = stored.TotpRecoveryCodes
+	return nil
 }
 
 type PunqUserCreateInput struct {
-	Email       string      `json:"email" validate:"required"`
-	Password    string      `json:"password" validate:"required"`
-	DisplayName string      `json:"displayName" validate:"required"`
-	AccessLevel AccessLevel `json:"accessLevel" validate:"required"`
+	Email          string      `json:"email" validate:"required"`
+	Password       string      `json:"password" validate:"required"`
+	DisplayName    string      `json:"displayName" validate:"required"`
+	AccessLevel    AccessLevel `json:"accessLevel" validate:"required"`
+	OidcIssuer     string      `json:"oidcIssuer,omitempty"`
+	OidcSubject    string      `json:"oidcSubject,omitempty"`
+	ServiceAccount string      `json:"serviceAccount,omitempty"`
 }
 
 func ListUsers(users []PunqUser) {
 	t := table.NewWriter()
 	t.SetOutputMirror(os.Stdout)
-	t.AppendHeader(table.Row{"#", "ID", "DisplayName", "Email", "AccessLevel", "Created"})
+	t.AppendHeader(table.Row{"#", "ID", "DisplayName", "Email", "AccessLevel", "MFA", "Locked", "Created"})
 	for index, user := range users {
 		t.AppendRow(
-			table.Row{index + 1, user.Id, user.DisplayName, user.Email, user.AccessLevel.String(), utils.JsonStringToHumanDuration(user.Created)},
+			table.Row{index + 1, user.Id, user.DisplayName, user.Email, user.AccessLevel.String(), user.MfaStatus(), user.IsLocked(), utils.JsonStringToHumanDuration(user.Created)},
 		)
 	}
 	t.Render()
@@ -61,8 +112,30 @@ func (user *PunqUser) PrintToTerminalWithPwd() {
 func (user *PunqUser) PasswordCheck(password string) (bool, error) {
 	err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
 
-	if err != nil && errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+	// any error (including malformed or empty hashes of oidc users) is a failed check
+	if err != nil {
 		return false, err
 	}
 	return true, nil
 }
+
+func (user *PunqUser) IsLocked() bool {
+	if user.LockedUntil == "" {
+		return false
+	}
+	lockedUntil, err := time.Parse(time.RFC3339, user.LockedUntil)
+	if err != nil {
+		return false
+	}
+	return time.Now().Before(lockedUntil)
+}
+
+func (user *PunqUser) MfaStatus() string {
+	if user.TotpEnabled {
+		return "enabled"
+	}
+	if user.MfaRequired {
+		return "required"
+	}
+	return "-"
+}
@@ -0,0 +1,40 @@
+package dtos
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPunqUserTotpNotSerialized(t *testing.T) {
+	user := PunqUser{Id: "u1", Email: "a@b.c", TotpSecret: "JBSWY3DPEHPK3PXP", TotpEnabled: true, TotpRecoveryCodes: []string{"hash1", "hash2"}}
+
+	response, err := json.Marshal(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(response), user.TotpSecret) || strings.Contains(string(response), "hash1") {
+		t.Errorf("api response contains the second factor: %s", response)
+	}
+
+	stored, err := MarshalStoredUser(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := PunqUser{}
+	if err := UnmarshalStoredUser(stored, &restored); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(user, restored) {
+		t.Errorf("stored user = %+v, want %+v", restored, user)
+	}
+
+	// an update sent by a client must not overwrite the second factor
+	if err := json.Unmarshal([]byte(`{"id":"u1","totpSecret":"AAAA","totpRecoveryCodes":[]}`), &restored); err != nil {
+		t.Fatal(err)
+	}
+	if restored.TotpSecret != user.TotpSecret || len(restored.TotpRecoveryCodes) != 2 {
+		t.Error("client json overwrote the second factor")
+	}
+}
@@ -2,7 +2,6 @@ package kubernetes
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
@@ -23,7 +22,6 @@ import (
 	applyconfapp "k8s.io/client-go/applyconfigurations/apps/v1"
 	applyconfcore "k8s.io/client-go/applyconfigurations/core/v1"
 	applyconfmeta "k8s.io/client-go/applyconfigurations/meta/v1"
-	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
 )
 
 func Deploy(clusterName string, ingressHostname string) {
@@ -38,11 +36,6 @@ func Deploy(clusterName string, ingressHostname string) {
 	addRbac(provider)
 	addDeployment(provider)
 
-	_, err = CreateContextSecretIfNotExist(provider)
-	if err != nil {
-		logger.Log.Fatalf("Error creating context secret. Aborting: %s.", err.Error())
-	}
-
 	if ingressHostname != "" {
 		addService(provider)
 		addIngress(provider, clusterName, ingressHostname)
@@ -130,9 +123,9 @@ func addTraefikMiddleware(provider *KubeProvider, ingressHostname string) {
 
 	var cmd *exec.Cmd
 	if runtime.GOOS == "windows" {
-		cmd = utils.RunOnLocalShell(fmt.Sprintf("kubectl apply -f %s%s", fileName, ContextFlag(nil)))
+		cmd = utils.RunOnLocalShell(fmt.Sprintf("kubectl apply -f %s", fileName))
 	} else {
-		cmd = utils.RunOnLocalShell(fmt.Sprintf("kubectl apply -f %s%s", fileName, ContextFlag(nil)))
+		cmd = utils.RunOnLocalShell(fmt.Sprintf("kubectl apply -f %s", fileName))
 	}
 
 	output, err := cmd.CombinedOutput()
@@ -241,14 +234,8 @@ func applyNamespace(provider *KubeProvider) {
 	fmt.Println("Created punq namespace. ✅")
 }
 
-func CreateContextSecretIfNotExist(provider *KubeProvider) (*dtos.PunqContext, error) {
-	secretClient := provider.ClientSet.CoreV1().Secrets(utils.CONFIG.Kubernetes.OwnNamespace)
-
-	existingSecret, getErr := secretClient.Get(context.TODO(), utils.CONTEXTSSECRET, metav1.GetOptions{})
-	return writeContextSecret(secretClient, existingSecret, getErr)
-}
-
-func writeContextSecret(secretClient v1.SecretInterface, existingSecret *core.Secret, getErr error) (*dtos.PunqContext, error) {
+// CurrentOwnContext builds the own-context from the current context of the default kubeconfig.
+func CurrentOwnContext() dtos.PunqContext {
 	kubeconfigEnvVar := utils.GetDefaultKubeConfig()
 
 	kubeconfigData, err := os.ReadFile(kubeconfigEnvVar)
@@ -272,29 +259,7 @@ func writeContextSecret(secretClient v1.SecretInterface, existingSecret *core.Se
 
 	ownContext.Id = utils.CONTEXTOWN
 	ownContext.Name = utils.CONTEXTOWN
-
-	rawAdmin, err := json.Marshal(ownContext)
-	if err != nil {
-		logger.Log.Errorf("Error marshaling %s", err)
-	}
-
-	secret := utils.InitSecret()
-	secret.ObjectMeta.Name = utils.CONTEXTSSECRET
-	secret.ObjectMeta.Namespace = utils.CONFIG.Kubernetes.OwnNamespace
-	delete(secret.StringData, "exampleData") // delete example data
-	secret.StringData[utils.CONTEXTOWN] = string(rawAdmin)
-
-	if existingSecret == nil || getErr != nil {
-		fmt.Println("Creating new punq-context secret ...")
-		_, err := secretClient.Create(context.TODO(), &secret, MoCreateOptions())
-		if err != nil {
-			logger.Log.Error(err)
-			return nil, err
-		}
-		fmt.Println("Created new punq-context secret. ✅")
-		return &ownContext, nil
-	}
-	return nil, nil
+	return ownContext
 }
 
 func addDeployment(provider *KubeProvider) {
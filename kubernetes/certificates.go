@@ -2,7 +2,6 @@ package kubernetes
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/mogenius/punq/utils"
 
@@ -103,15 +102,7 @@ func DeleteK8sCertificateBy(namespace string, name string, contextId *string) er
 }
 
 func DescribeK8sCertificate(namespace string, name string, contextId *string) utils.K8sWorkloadResult {
-	cmd := utils.RunOnLocalShell(fmt.Sprintf("kubectl describe certificate %s -n %s%s", name, namespace, ContextFlag(contextId)))
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		logger.Log.Errorf("Failed to execute command (%s): %v", cmd.String(), err)
-		logger.Log.Errorf("Error: %s", string(output))
-		return WorkloadResult(nil, string(output))
-	}
-	return WorkloadResult(string(output), nil)
+	return describeK8s("Certificate", namespace, name, contextId)
 }
 
 func CreateK8sCertificate(data cmapi.Certificate, contextId *string) utils.K8sWorkloadResult {
@@ -2,7 +2,6 @@ package kubernetes
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/mogenius/punq/logger"
 	"github.com/mogenius/punq/utils"
@@ -75,15 +74,7 @@ func DeleteK8sClusterRoleBindingBy(name string, contextId *string) error {
 }
 
 func DescribeK8sClusterRoleBinding(name string, contextId *string) utils.K8sWorkloadResult {
-	cmd := utils.RunOnLocalShell(fmt.Sprintf("kubectl describe clusterrolebinding %s%s", name, ContextFlag(contextId)))
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		logger.Log.Errorf("Failed to execute command (%s): %v", cmd.String(), err)
-		logger.Log.Errorf("Error: %s", string(output))
-		return WorkloadResult(nil, string(output))
-	}
-	return WorkloadResult(string(output), nil)
+	return describeK8s("ClusterRoleBinding", "", name, contextId)
 }
 
 func CreateK8sClusterRoleBinding(data v1.ClusterRoleBinding, contextId *string) utils.K8sWorkloadResult {
@@ -2,7 +2,6 @@ package kubernetes
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/mogenius/punq/utils"
 
@@ -78,15 +77,7 @@ func DeleteK8sClusterRoleBy(name string, contextId *string) error {
 }
 
 func DescribeK8sClusterRole(name string, contextId *string) utils.K8sWorkloadResult {
-	cmd := utils.RunOnLocalShell(fmt.Sprintf("kubectl describe clusterrole %s%s", name, ContextFlag(contextId)))
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		logger.Log.Errorf("Failed to execute command (%s): %v", cmd.String(), err)
-		logger.Log.Errorf("Error: %s", string(output))
-		return WorkloadResult(nil, string(output))
-	}
-	return WorkloadResult(string(output), nil)
+	return describeK8s("ClusterRole", "", name, contextId)
 }
 
 func CreateK8sClusterRole(data v1.ClusterRole, contextId *string) utils.K8sWorkloadResult {
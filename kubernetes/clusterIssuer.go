@@ -2,7 +2,6 @@ package kubernetes
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/mogenius/punq/utils"
 
@@ -77,15 +76,7 @@ func DeleteK8sClusterIssuerBy(name string, contextId *string) error {
 }
 
 func DescribeK8sClusterIssuer(name string, contextId *string) utils.K8sWorkloadResult {
-	cmd := utils.RunOnLocalShell(fmt.Sprintf("kubectl describe clusterissuer %s%s", name, ContextFlag(contextId)))
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		logger.Log.Errorf("Failed to execute command (%s): %v", cmd.String(), err)
-		logger.Log.Errorf("Error: %s", string(output))
-		return WorkloadResult(nil, string(output))
-	}
-	return WorkloadResult(string(output), nil)
+	return describeK8s("ClusterIssuer", "", name, contextId)
 }
 
 func CreateK8sClusterIssuer(data cmapi.ClusterIssuer, contextId *string) utils.K8sWorkloadResult {
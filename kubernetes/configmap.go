@@ -2,7 +2,6 @@ package kubernetes
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/mogenius/punq/utils"
 
@@ -35,7 +34,7 @@ func AllConfigmaps(namespaceName string, contextId *string) []v1.ConfigMap {
 	if err != nil {
 		return result
 	}
-	configmapList, err := provider.ClientSet.CoreV1().ConfigMaps(namespaceName).List(context.TODO(), metav1.ListOptions{FieldSelector: "metadata.namespace!=kube-system"})
+	configmapList, err := cachedList(contextId, informerConfigMaps, namespaceName, metav1.ListOptions{FieldSelector: "metadata.namespace!=kube-system"}, provider.ClientSet.CoreV1().ConfigMaps(namespaceName).List)
 	if err != nil {
 		logger.Log.Errorf("AllConfigmaps ERROR: %s", err.Error())
 		return result
@@ -56,7 +55,7 @@ func AllK8sConfigmaps(namespaceName string, contextId *string) utils.K8sWorkload
 	if err != nil {
 		return WorkloadResult(nil, err)
 	}
-	configmapList, err := provider.ClientSet.CoreV1().ConfigMaps(namespaceName).List(context.TODO(), metav1.ListOptions{FieldSelector: "metadata.namespace!=kube-system"})
+	configmapList, err := cachedList(contextId, informerConfigMaps, namespaceName, metav1.ListOptions{FieldSelector: "metadata.namespace!=kube-system"}, provider.ClientSet.CoreV1().ConfigMaps(namespaceName).List)
 	if err != nil {
 		logger.Log.Errorf("AllConfigmaps ERROR: %s", err.Error())
 		return WorkloadResult(nil, err)
@@ -115,15 +114,7 @@ func DeleteK8sConfigmapBy(namespaceName string, name string, contextId *string)
 }
 
 func DescribeK8sConfigmap(namespace string, name string, contextId *string) utils.K8sWorkloadResult {
-	cmd := utils.RunOnLocalShell(fmt.Sprintf("kubectl describe configmap %s -n %s%s", name, namespace, ContextFlag(contextId)))
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		logger.Log.Errorf("Failed to execute command (%s): %v", cmd.String(), err)
-		logger.Log.Errorf("Error: %s", string(output))
-		return WorkloadResult(nil, string(output))
-	}
-	return WorkloadResult(string(output), nil)
+	return describeK8s("ConfigMap", namespace, name, contextId)
 }
 
 func CreateK8sConfigMap(data v1.ConfigMap, contextId *string) utils.K8sWorkloadResult {
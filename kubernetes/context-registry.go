@@ -0,0 +1,149 @@
+package kubernetes
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/mogenius/punq/dtos"
+)
+
+type ContextChangeType string
+
+const (
+	ContextAdded   ContextChangeType = "added"
+	ContextUpdated ContextChangeType = "updated"
+	ContextRemoved ContextChangeType = "removed"
+)
+
+type ContextChange struct {
+	Type    ContextChangeType
+	Context dtos.PunqContext // removed contexts carry their last known state
+	// the kubeconfig differs from the previous one (always true for added and removed contexts)
+	KubeconfigChanged bool
+}
+
+// ContextRegistry holds the contexts used for cluster requests of this process. It is safe for concurrent use.
+// Listeners are notified synchronously after the registry has been changed (outside of the lock).
+type ContextRegistry struct {
+	lock      sync.RWMutex
+	contexts  map[string]dtos.PunqContext
+	listeners []func(ContextChange)
+}
+
+func NewContextRegistry() *ContextRegistry {
+	return &ContextRegistry{contexts: map[string]dtos.PunqContext{}}
+}
+
+// Get returns a copy of the context or nil.
+func (r *ContextRegistry) Get(id string) *dtos.PunqContext {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	ctx, exists := r.contexts[id]
+	if !exists {
+		return nil
+	}
+	ctx = cloneContext(ctx)
+	return &ctx
+}
+
+// List returns all contexts sorted by name.
+func (r *ContextRegistry) List() []dtos.PunqContext {
+	r.lock.RLock()
+	result := make([]dtos.PunqContext, 0, len(r.contexts))
+	for _, ctx := range r.contexts {
+		result = append(result, cloneContext(ctx))
+	}
+	r.lock.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// Upsert adds the context or replaces the context with the same id.
+func (r *ContextRegistry) Upsert(ctx dtos.PunqContext) {
+	ctx = cloneContext(ctx)
+	r.lock.Lock()
+	previous, exists := r.contexts[ctx.Id]
+	r.contexts[ctx.Id] = ctx
+	r.lock.Unlock()
+
+	if !exists {
+		r.notify(ContextChange{Type: ContextAdded, Context: ctx, KubeconfigChanged: true})
+		return
+	}
+	r.notify(ContextChange{Type: ContextUpdated, Context: ctx, KubeconfigChanged: previous.Context != ctx.Context})
+}
+
+// Remove deletes the context. It returns false if the id is unknown.
+func (r *ContextRegistry) Remove(id string) bool {
+	r.lock.Lock()
+	previous, exists := r.contexts[id]
+	delete(r.contexts, id)
+	r.lock.Unlock()
+
+	if exists {
+		r.notify(ContextChange{Type: ContextRemoved, Context: previous, KubeconfigChanged: true})
+	}
+	return exists
+}
+
+// Sync makes the registry match ctxs (e.g. the contexts of the store): new and changed contexts are upserted,
+// missing ones removed.
+func (r *ContextRegistry) Sync(ctxs []dtos.PunqContext) {
+	ids := map[string]bool{}
+	for _, ctx := range ctxs {
+		ids[ctx.Id] = true
+		if current := r.Get(ctx.Id); current == nil || !sameContext(*current, ctx) {
+			r.Upsert(ctx)
+		}
+	}
+	for _, ctx := range r.List() {
+		if !ids[ctx.Id] {
+			r.Remove(ctx.Id)
+		}
+	}
+}
+
+// OnChange registers a listener for all following changes.
+func (r *ContextRegistry) OnChange(listener func(ContextChange)) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.listeners = append(r.listeners, listener)
+}
+
+func (r *ContextRegistry) notify(change ContextChange) {
+	r.lock.RLock()
+	listeners := append([]func(ContextChange){}, r.listeners...)
+	r.lock.RUnlock()
+
+	for _, listener := range listeners {
+		listener(change)
+	}
+}
+
+// cloneContext copies slices and maps so callers cannot modify the registry through them.
+func cloneContext(ctx dtos.PunqContext) dtos.PunqContext {
+	ctx.Users = append([]string{}, ctx.Users...)
+	ctx.Groups = append([]string{}, ctx.Groups...)
+	if ctx.Labels != nil {
+		labels := make(map[string]string, len(ctx.Labels))
+		for key, value := range ctx.Labels {
+			labels[key] = value
+		}
+		ctx.Labels = labels
+	}
+	return ctx
+}
+
+// sameContext ignores the derived Credentials (and nil vs. empty slices).
+func sameContext(a dtos.PunqContext, b dtos.PunqContext) bool {
+	a = cloneContext(a)
+	b = cloneContext(b)
+	a.Credentials = nil
+	b.Credentials = nil
+	return reflect.DeepEqual(a, b)
+}
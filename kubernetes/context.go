@@ -2,70 +2,40 @@ package kubernetes
 
 import (
 	"context"
-	"errors"
-	"fmt"
-	"os"
+	"time"
 
 	"github.com/mogenius/punq/dtos"
-	"github.com/mogenius/punq/utils"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
-var allContexts []dtos.PunqContext = []dtos.PunqContext{}
+const ProbeTimeout = 10 * time.Second
 
-func ContextForId(id string) *dtos.PunqContext {
-	for _, ctx := range allContexts {
-		if ctx.Id == id {
-			return &ctx
-		}
-	}
-	return nil
-}
+// Contexts of this process (kept in sync with the store by the services)
+var Contexts = NewContextRegistry()
 
-func ContextAddOne(ctx dtos.PunqContext) {
-	if ContextForId(ctx.Id) != nil {
-		return
-	}
-	allContexts = append(allContexts, ctx)
-	contextWrite(ctx.Id)
+func ContextForId(id string) *dtos.PunqContext {
+	return Contexts.Get(id)
 }
 
-func ContextAddMany(ctxs []dtos.PunqContext) {
-	for _, ctx := range ctxs {
-		ContextAddOne(ctx)
-	}
+// ContextUpsert adds the context or replaces the context with the same id.
+func ContextUpsert(ctx dtos.PunqContext) {
+	Contexts.Upsert(ctx)
 }
 
-func ContextList() []dtos.PunqContext {
-	return allContexts
+func ContextRemove(id string) {
+	Contexts.Remove(id)
 }
 
-func ContextFlag(id *string) string {
-	if id == nil {
-		return ""
-	}
-	return fmt.Sprintf(" --kubeconfig=%s.yaml", *id)
+// ContextSync replaces all contexts (e.g. with the contexts of the store).
+func ContextSync(ctxs []dtos.PunqContext) {
+	Contexts.Sync(ctxs)
 }
 
-func contextWrite(id string) error {
-	if !utils.CONFIG.Kubernetes.RunInCluster {
-		return nil
-	}
-
-	ctx := ContextForId(id)
-	if ctx == nil {
-		return errors.New("context not found")
-	}
-
-	// write ctx.context into file
-	err := os.WriteFile(fmt.Sprintf("%s.yaml", ctx.Id), []byte(ctx.Context), 0644)
-	if err != nil {
-		_ = os.Remove(ctx.Id)
-		return err
-	}
-	return nil
+func ContextList() []dtos.PunqContext {
+	return Contexts.List()
 }
 
 func CheckContext(ctx dtos.PunqContext) (bool, dtos.KubernetesProvider, error) {
@@ -96,3 +66,53 @@ func CheckContext(ctx dtos.PunqContext) (bool, dtos.KubernetesProvider, error) {
 		return true, provider, nil
 	}
 }
+
+// ProbeContext checks whether the cluster of a context answers and the credentials are (still) accepted.
+func ProbeContext(ctx dtos.PunqContext) dtos.PunqContextProbe {
+	probe := dtos.PunqContextProbe{Time: time.Now().Format(time.RFC3339)}
+
+	configFromString, err := clientcmd.NewClientConfigFromBytes([]byte(ctx.Context))
+	if err != nil {
+		probe.Error = err.Error()
+		return probe
+	}
+	config, err := configFromString.ClientConfig()
+	if err != nil {
+		probe.Error = err.Error()
+		return probe
+	}
+	config.Timeout = ProbeTimeout
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		probe.Error = err.Error()
+		return probe
+	}
+
+	start := time.Now()
+	version, err := clientset.Discovery().ServerVersion()
+	probe.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		probe.AuthError = apierrors.IsUnauthorized(err) || apierrors.IsForbidden(err)
+		probe.Error = err.Error()
+		return probe
+	}
+	probe.ServerVersion = version.GitVersion
+
+	nodeList, err := clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		// the api server answered, the credentials might just not allow listing nodes
+		probe.Reachable = !apierrors.IsUnauthorized(err)
+		probe.AuthError = apierrors.IsUnauthorized(err)
+		probe.Error = err.Error()
+		return probe
+	}
+	probe.Reachable = true
+	probe.NodeCount = len(nodeList.Items)
+
+	provider, err := GuessCluserProviderFromNodeList(nodeList)
+	if err == nil {
+		probe.Provider = string(provider)
+	}
+	return probe
+}
@@ -1,11 +1,8 @@
 package kubernetes
 
 import (
-	"fmt"
-
 	"github.com/mogenius/punq/utils"
 
-	"github.com/mogenius/punq/logger"
 	apiExt "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
 )
 
@@ -56,15 +53,7 @@ func DeleteK8sCustomResourceDefinition(data apiExt.CustomResourceDefinition) uti
 }
 
 func DescribeK8sCustomResourceDefinition(name string, contextId *string) utils.K8sWorkloadResult {
-	cmd := utils.RunOnLocalShell(fmt.Sprintf("kubectl describe crds %s%s", name, ContextFlag(contextId)))
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		logger.Log.Errorf("Failed to execute command (%s): %v", cmd.String(), err)
-		logger.Log.Errorf("Error: %s", string(output))
-		return WorkloadResult(nil, string(output))
-	}
-	return WorkloadResult(string(output), nil)
+	return describeK8s("CustomResourceDefinition", "", name, contextId)
 }
 
 func CreateK8sCustomResourceDefinition(data apiExt.CustomResourceDefinition) utils.K8sWorkloadResult {
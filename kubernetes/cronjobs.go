@@ -2,7 +2,6 @@ package kubernetes
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/mogenius/punq/utils"
 
@@ -19,7 +18,7 @@ func AllCronjobs(namespaceName string, contextId *string) utils.K8sWorkloadResul
 	if err != nil {
 		return WorkloadResult(nil, err)
 	}
-	cronJobList, err := provider.ClientSet.BatchV1().CronJobs(namespaceName).List(context.TODO(), metav1.ListOptions{FieldSelector: "metadata.namespace!=kube-system"})
+	cronJobList, err := cachedList(contextId, informerCronJobs, namespaceName, metav1.ListOptions{FieldSelector: "metadata.namespace!=kube-system"}, provider.ClientSet.BatchV1().CronJobs(namespaceName).List)
 	if err != nil {
 		logger.Log.Errorf("AllCronjobs ERROR: %s", err.Error())
 		return WorkloadResult(nil, err)
@@ -77,15 +76,7 @@ func DeleteK8sCronJobBy(namespace string, name string, contextId *string) error
 }
 
 func DescribeK8sCronJob(namespace string, name string, contextId *string) utils.K8sWorkloadResult {
-	cmd := utils.RunOnLocalShell(fmt.Sprintf("kubectl describe cronjob %s -n %s%s", name, namespace, ContextFlag(contextId)))
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		logger.Log.Errorf("Failed to execute command (%s): %v", cmd.String(), err)
-		logger.Log.Errorf("Error: %s", string(output))
-		return WorkloadResult(nil, string(output))
-	}
-	return WorkloadResult(string(output), nil)
+	return describeK8s("CronJob", namespace, name, contextId)
 }
 
 func CreateK8sCronJob(data v1.CronJob, contextId *string) utils.K8sWorkloadResult {
@@ -2,7 +2,6 @@ package kubernetes
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/mogenius/punq/utils"
 
@@ -19,7 +18,7 @@ func AllDaemonsets(namespaceName string, contextId *string) []v1.DaemonSet {
 	if err != nil {
 		return result
 	}
-	daemonsetList, err := provider.ClientSet.AppsV1().DaemonSets(namespaceName).List(context.TODO(), metav1.ListOptions{FieldSelector: "metadata.namespace!=kube-system"})
+	daemonsetList, err := cachedList(contextId, informerDaemonSets, namespaceName, metav1.ListOptions{FieldSelector: "metadata.namespace!=kube-system"}, provider.ClientSet.AppsV1().DaemonSets(namespaceName).List)
 	if err != nil {
 		logger.Log.Errorf("AllDaemonsets ERROR: %s", err.Error())
 		return result
@@ -40,7 +39,7 @@ func AllK8sDaemonsets(namespaceName string, contextId *string) utils.K8sWorkload
 	if err != nil {
 		return WorkloadResult(nil, err)
 	}
-	daemonsetList, err := provider.ClientSet.AppsV1().DaemonSets(namespaceName).List(context.TODO(), metav1.ListOptions{FieldSelector: "metadata.namespace!=kube-system"})
+	daemonsetList, err := cachedList(contextId, informerDaemonSets, namespaceName, metav1.ListOptions{FieldSelector: "metadata.namespace!=kube-system"}, provider.ClientSet.AppsV1().DaemonSets(namespaceName).List)
 	if err != nil {
 		logger.Log.Errorf("AllDaemonsets ERROR: %s", err.Error())
 		return WorkloadResult(nil, err)
@@ -98,15 +97,7 @@ func DeleteK8sDaemonSetBy(namespace string, name string, contextId *string) erro
 }
 
 func DescribeK8sDaemonSet(namespace string, name string, contextId *string) utils.K8sWorkloadResult {
-	cmd := utils.RunOnLocalShell(fmt.Sprintf("kubectl describe daemonset %s -n %s%s", name, namespace, ContextFlag(contextId)))
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		logger.Log.Errorf("Failed to execute command (%s): %v", cmd.String(), err)
-		logger.Log.Errorf("Error: %s", string(output))
-		return WorkloadResult(nil, string(output))
-	}
-	return WorkloadResult(string(output), nil)
+	return describeK8s("DaemonSet", namespace, name, contextId)
 }
 
 func CreateK8sDaemonSet(data v1.DaemonSet, contextId *string) utils.K8sWorkloadResult {
@@ -23,8 +23,6 @@ func Remove(clusterName string) {
 	// namespace is not deleted on purpose
 	removeRbac(provider)
 	removeDeployment(provider)
-	removeContextsSecret(provider)
-	removeUsersSecret(provider)
 	removeService(provider)
 	removeIngress(provider)
 }
@@ -121,33 +119,3 @@ func removeRbac(provider *KubeProvider) {
 	}
 	fmt.Printf("Deleted %s RBAC. ✅\n", version.Name)
 }
-
-func removeUsersSecret(provider *KubeProvider) {
-	secretClient := provider.ClientSet.CoreV1().Secrets(utils.CONFIG.Kubernetes.OwnNamespace)
-
-	fmt.Printf("Deleting %s/%s secret ...\n", utils.CONFIG.Kubernetes.OwnNamespace, utils.USERSSECRET)
-	deletePolicy := metav1.DeletePropagationForeground
-	err := secretClient.Delete(context.TODO(), utils.USERSSECRET, metav1.DeleteOptions{PropagationPolicy: &deletePolicy})
-	if err != nil {
-		if !apierrors.IsNotFound(err) {
-			logger.Log.Error(err)
-			return
-		}
-	}
-	fmt.Printf("Deleted %s/%s secret. ✅\n", utils.CONFIG.Kubernetes.OwnNamespace, utils.USERSSECRET)
-}
-
-func removeContextsSecret(provider *KubeProvider) {
-	secretClient := provider.ClientSet.CoreV1().Secrets(utils.CONFIG.Kubernetes.OwnNamespace)
-
-	fmt.Printf("Deleting %s/%s secret ...\n", utils.CONFIG.Kubernetes.OwnNamespace, utils.CONTEXTSSECRET)
-	deletePolicy := metav1.DeletePropagationForeground
-	err := secretClient.Delete(context.TODO(), utils.CONTEXTSSECRET, metav1.DeleteOptions{PropagationPolicy: &deletePolicy})
-	if err != nil {
-		if !apierrors.IsNotFound(err) {
-			logger.Log.Error(err)
-			return
-		}
-	}
-	fmt.Printf("Deleted %s/%s secret. ✅\n", utils.CONFIG.Kubernetes.OwnNamespace, utils.CONTEXTSSECRET)
-}
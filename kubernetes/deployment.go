@@ -2,7 +2,6 @@ package kubernetes
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/mogenius/punq/logger"
 	"github.com/mogenius/punq/utils"
@@ -18,7 +17,7 @@ func AllDeployments(namespaceName string, contextId *string) []v1.Deployment {
 	if err != nil {
 		return result
 	}
-	deploymentList, err := provider.ClientSet.AppsV1().Deployments(namespaceName).List(context.TODO(), metav1.ListOptions{})
+	deploymentList, err := cachedList(contextId, informerDeployments, namespaceName, metav1.ListOptions{}, provider.ClientSet.AppsV1().Deployments(namespaceName).List)
 	if err != nil {
 		logger.Log.Errorf("AllDeployments ERROR: %s", err.Error())
 		return result
@@ -37,7 +36,7 @@ func AllDeploymentsIncludeIgnored(namespaceName string, contextId *string) []v1.
 	if err != nil {
 		return []v1.Deployment{}
 	}
-	deploymentList, err := provider.ClientSet.AppsV1().Deployments(namespaceName).List(context.TODO(), metav1.ListOptions{})
+	deploymentList, err := cachedList(contextId, informerDeployments, namespaceName, metav1.ListOptions{}, provider.ClientSet.AppsV1().Deployments(namespaceName).List)
 	if err != nil {
 		logger.Log.Errorf("AllDeployments ERROR: %s", err.Error())
 		return deploymentList.Items
@@ -53,7 +52,7 @@ func AllK8sDeployments(namespaceName string, contextId *string) utils.K8sWorkloa
 	if err != nil {
 		return WorkloadResult(nil, err)
 	}
-	deploymentList, err := provider.ClientSet.AppsV1().Deployments(namespaceName).List(context.TODO(), metav1.ListOptions{})
+	deploymentList, err := cachedList(contextId, informerDeployments, namespaceName, metav1.ListOptions{}, provider.ClientSet.AppsV1().Deployments(namespaceName).List)
 	if err != nil {
 		logger.Log.Errorf("AllDeployments ERROR: %s", err.Error())
 		return WorkloadResult(nil, err)
@@ -111,15 +110,7 @@ func DeleteK8sDeploymentBy(namespace string, name string, contextId *string) err
 }
 
 func DescribeK8sDeployment(namespace string, name string, contextId *string) utils.K8sWorkloadResult {
-	cmd := utils.RunOnLocalShell(fmt.Sprintf("kubectl describe deployment %s -n %s%s", name, namespace, ContextFlag(contextId)))
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		logger.Log.Errorf("Failed to execute command (%s): %v", cmd.String(), err)
-		logger.Log.Errorf("Error: %s", string(output))
-		return WorkloadResult(nil, string(output))
-	}
-	return WorkloadResult(string(output), nil)
+	return describeK8s("Deployment", namespace, name, contextId)
 }
 
 func CreateK8sDeployment(data v1.Deployment, contextId *string) utils.K8sWorkloadResult {
@@ -0,0 +1,102 @@
+package kubernetes
+
+import (
+	"fmt"
+
+	"github.com/mogenius/punq/logger"
+	"github.com/mogenius/punq/utils"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/kubectl/pkg/describe"
+)
+
+type describeKind struct {
+	Gvr        schema.GroupVersionResource
+	Namespaced bool
+}
+
+// kinds of the Describe* functions. Kinds without a kubectl describer (e.g. cert-manager resources and CRDs) are
+// described from their unstructured content.
+var describeKinds = map[string]describeKind{
+	"Pod":                      {schema.GroupVersionResource{Group: "", Version: "v1", Resource: "pods"}, true},
+	"Service":                  {schema.GroupVersionResource{Group: "", Version: "v1", Resource: "services"}, true},
+	"ServiceAccount":           {schema.GroupVersionResource{Group: "", Version: "v1", Resource: "serviceaccounts"}, true},
+	"Secret":                   {schema.GroupVersionResource{Group: "", Version: "v1", Resource: "secrets"}, true},
+	"ConfigMap":                {schema.GroupVersionResource{Group: "", Version: "v1", Resource: "configmaps"}, true},
+	"Endpoints":                {schema.GroupVersionResource{Group: "", Version: "v1", Resource: "endpoints"}, true},
+	"Event":                    {schema.GroupVersionResource{Group: "", Version: "v1", Resource: "events"}, true},
+	"PersistentVolumeClaim":    {schema.GroupVersionResource{Group: "", Version: "v1", Resource: "persistentvolumeclaims"}, true},
+	"ResourceQuota":            {schema.GroupVersionResource{Group: "", Version: "v1", Resource: "resourcequotas"}, true},
+	"Namespace":                {schema.GroupVersionResource{Group: "", Version: "v1", Resource: "namespaces"}, false},
+	"Node":                     {schema.GroupVersionResource{Group: "", Version: "v1", Resource: "nodes"}, false},
+	"PersistentVolume":         {schema.GroupVersionResource{Group: "", Version: "v1", Resource: "persistentvolumes"}, false},
+	"Deployment":               {schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, true},
+	"ReplicaSet":               {schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"}, true},
+	"StatefulSet":              {schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"}, true},
+	"DaemonSet":                {schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "daemonsets"}, true},
+	"Job":                      {schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}, true},
+	"CronJob":                  {schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "cronjobs"}, true},
+	"HorizontalPodAutoscaler":  {schema.GroupVersionResource{Group: "autoscaling", Version: "v2", Resource: "horizontalpodautoscalers"}, true},
+	"Ingress":                  {schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}, true},
+	"IngressClass":             {schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingressclasses"}, false},
+	"NetworkPolicy":            {schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"}, true},
+	"Role":                     {schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "roles"}, true},
+	"RoleBinding":              {schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "rolebindings"}, true},
+	"ClusterRole":              {schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterroles"}, false},
+	"ClusterRoleBinding":       {schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterrolebindings"}, false},
+	"Lease":                    {schema.GroupVersionResource{Group: "coordination.k8s.io", Version: "v1", Resource: "leases"}, true},
+	"PriorityClass":            {schema.GroupVersionResource{Group: "scheduling.k8s.io", Version: "v1", Resource: "priorityclasses"}, false},
+	"StorageClass":             {schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1", Resource: "storageclasses"}, false},
+	"VolumeAttachment":         {schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1", Resource: "volumeattachments"}, false},
+	"VolumeSnapshot":           {schema.GroupVersionResource{Group: "snapshot.storage.k8s.io", Version: "v1", Resource: "volumesnapshots"}, true},
+	"CustomResourceDefinition": {schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}, false},
+	"Certificate":              {schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}, true},
+	"CertificateRequest":       {schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificaterequests"}, true},
+	"Issuer":                   {schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "issuers"}, true},
+	"ClusterIssuer":            {schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "clusterissuers"}, false},
+	"Order":                    {schema.GroupVersionResource{Group: "acme.cert-manager.io", Version: "v1", Resource: "orders"}, true},
+}
+
+// describeK8s renders the output of 'kubectl describe' for an object of a kind of describeKinds.
+func describeK8s(kind string, namespace string, name string, contextId *string) utils.K8sWorkloadResult {
+	describeKind, exists := describeKinds[kind]
+	if !exists {
+		return WorkloadResult(nil, fmt.Errorf("describe is not supported for kind '%s'", kind))
+	}
+	return DescribeK8sResource(describeKind.Gvr.GroupVersion().WithKind(kind), describeKind.Gvr.Resource, describeKind.Namespaced, namespace, name, contextId)
+}
+
+// DescribeK8sResource describes any object (e.g. of a CRD) with the identity of the context. The typed kubectl
+// describer of the kind is used if one exists, otherwise the generic one.
+func DescribeK8sResource(gvk schema.GroupVersionKind, resource string, namespaced bool, namespace string, name string, contextId *string) utils.K8sWorkloadResult {
+	provider, err := NewKubeProvider(contextId)
+	if err != nil {
+		return WorkloadResult(nil, err)
+	}
+	if !namespaced {
+		namespace = ""
+	}
+
+	describer, exists := describe.DescriberFor(gvk.GroupKind(), &provider.ClientConfig)
+	if !exists {
+		scope := meta.RESTScopeRoot
+		if namespaced {
+			scope = meta.RESTScopeNamespace
+		}
+		describer, exists = describe.GenericDescriberFor(&meta.RESTMapping{
+			Resource:         gvk.GroupVersion().WithResource(resource),
+			GroupVersionKind: gvk,
+			Scope:            scope,
+		}, &provider.ClientConfig)
+		if !exists {
+			return WorkloadResult(nil, fmt.Errorf("failed to create describer for %s", gvk.String()))
+		}
+	}
+
+	output, err := describer.Describe(namespace, name, describe.DescriberSettings{ShowEvents: true, ChunkSize: 500})
+	if err != nil {
+		logger.Log.Errorf("Failed to describe %s %s/%s: %s", gvk.Kind, namespace, name, err.Error())
+		return WorkloadResult(nil, err)
+	}
+	return WorkloadResult(output, nil)
+}
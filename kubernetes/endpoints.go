@@ -2,7 +2,6 @@ package kubernetes
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/mogenius/punq/logger"
 	"github.com/mogenius/punq/utils"
@@ -76,15 +75,7 @@ func DeleteK8sEndpointBy(namespace string, name string, contextId *string) error
 }
 
 func DescribeK8sEndpoint(namespace string, name string, contextId *string) utils.K8sWorkloadResult {
-	cmd := utils.RunOnLocalShell(fmt.Sprintf("kubectl describe endpoint %s -n %s%s", name, namespace, ContextFlag(contextId)))
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		logger.Log.Errorf("Failed to execute command (%s): %v", cmd.String(), err)
-		logger.Log.Errorf("Error: %s", string(output))
-		return WorkloadResult(nil, string(output))
-	}
-	return WorkloadResult(string(output), nil)
+	return describeK8s("Endpoints", namespace, name, contextId)
 }
 
 func CreateK8sEndpoint(data corev1.Endpoints, contextId *string) utils.K8sWorkloadResult {
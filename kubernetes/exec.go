@@ -1,17 +1,110 @@
 package kubernetes
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/mogenius/punq/utils"
-	// corev1 "k8s.io/api/core/v1"
-	//"k8s.io/client-go/tools/remotecommand"
-	//"k8s.io/kubectl/pkg/scheme"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
 )
 
+const shellProbeTimeout = 10 * time.Second
+
+// shells tried by FindValidShell (in this order)
+var availableShells = []string{"bash", "ash", "zsh", "sh", "ksh", "csh"}
+
+type ExecStreams struct {
+	Stdin     io.Reader
+	Stdout    io.Writer
+	Stderr    io.Writer // ignored if Tty is set (the terminal merges stderr into stdout)
+	Tty       bool
+	SizeQueue remotecommand.TerminalSizeQueue
+}
+
+// ExecInPod runs command in a container with the identity of the context (including impersonation). The WebSocket
+// protocol is used if the api server supports it, SPDY otherwise. A non-zero exit code of the command is returned as
+// error (see ExecExitCode).
+func ExecInPod(ctx context.Context, namespace string, podName string, container string, command []string, streams ExecStreams, contextId *string) error {
+	provider, err := NewKubeProvider(contextId)
+	if err != nil {
+		return err
+	}
+
+	req := provider.ClientSet.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(podName).
+		SubResource("exec").
+		VersionedParams(&v1.PodExecOptions{
+			Container: container,
+			Command:   command,
+			Stdin:     streams.Stdin != nil,
+			Stdout:    streams.Stdout != nil,
+			Stderr:    streams.Stderr != nil && !streams.Tty,
+			TTY:       streams.Tty,
+		}, scheme.ParameterCodec)
+
+	spdyExecutor, err := remotecommand.NewSPDYExecutor(&provider.ClientConfig, "POST", req.URL())
+	if err != nil {
+		return err
+	}
+	websocketExecutor, err := remotecommand.NewWebSocketExecutor(&provider.ClientConfig, "GET", req.URL().String())
+	if err != nil {
+		return err
+	}
+	executor, err := remotecommand.NewFallbackExecutor(websocketExecutor, spdyExecutor, httpstream.IsUpgradeFailure)
+	if err != nil {
+		return err
+	}
+
+	options := remotecommand.StreamOptions{
+		Stdin:             streams.Stdin,
+		Stdout:            streams.Stdout,
+		Tty:               streams.Tty,
+		TerminalSizeQueue: streams.SizeQueue,
+	}
+	if !streams.Tty {
+		options.Stderr = streams.Stderr
+	}
+	return executor.StreamWithContext(ctx, options)
+}
+
+// ExecExitCode returns the exit code of a command run by ExecInPod (0 if err is nil). ok is false if the command
+// did not run to completion (e.g. connection errors).
+func ExecExitCode(err error) (code int, ok bool) {
+	if err == nil {
+		return 0, true
+	}
+	var exitErr utilexec.ExitError
+	if errors.As(err, &exitErr) && exitErr.Exited() {
+		return exitErr.ExitStatus(), true
+	}
+	return 0, false
+}
+
+// FindValidShell returns the first shell of availableShells which exists in the container ("sh" if none could be
+// found).
+func FindValidShell(namespace string, podName string, container string, contextId *string) string {
+	for _, shell := range availableShells {
+		ctx, cancel := context.WithTimeout(context.Background(), shellProbeTimeout)
+		err := ExecInPod(ctx, namespace, podName, container, []string{shell, "-c", "exit 0"}, ExecStreams{Stdout: io.Discard}, contextId)
+		cancel()
+		if err == nil {
+			return shell
+		}
+	}
+	return "sh"
+}
+
 func SendData(cmdStdin io.WriteCloser, cmdStdout io.ReadCloser) {
 	// Create a dialer
 	dialer := websocket.DefaultDialer
@@ -0,0 +1,295 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/mogenius/punq/dtos"
+	"github.com/mogenius/punq/utils"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+)
+
+// groups of the kinds in ALL_RESOURCES_USER (so a CRD cannot gain USER access by reusing a built-in kind name)
+var genericBuiltinGroups = []string{"", "apps", "batch", "networking.k8s.io", "events.k8s.io"}
+
+func genericClient(resource dtos.GenericResource, namespace string, contextId *string) (dynamic.ResourceInterface, error) {
+	client, _, err := pooledClientset(contextId, "dynamic", dynamic.NewForConfigAndClient)
+	if err != nil {
+		return nil, err
+	}
+	gvr := schema.GroupVersionResource{Group: resource.Group, Version: resource.Version, Resource: resource.Resource}
+	if !resource.Namespaced {
+		return client.Resource(gvr), nil
+	}
+	return client.Resource(gvr).Namespace(namespace), nil
+}
+
+func genericResourcesFrom(gv schema.GroupVersion, list *metav1.APIResourceList) []dtos.GenericResource {
+	result := []dtos.GenericResource{}
+	for _, apiResource := range list.APIResources {
+		// subresources like pods/log
+		if strings.Contains(apiResource.Name, "/") {
+			continue
+		}
+		result = append(result, dtos.GenericResource{
+			Group:      gv.Group,
+			Version:    gv.Version,
+			Resource:   apiResource.Name,
+			Kind:       apiResource.Kind,
+			Namespaced: apiResource.Namespaced,
+			ShortNames: apiResource.ShortNames,
+			Verbs:      apiResource.Verbs,
+		})
+	}
+	return result
+}
+
+// ListGenericResources returns the preferred version of every resource of the cluster (including CRDs).
+func ListGenericResources(contextId *string) ([]dtos.GenericResource, error) {
+	provider, err := NewKubeProvider(contextId)
+	if err != nil {
+		return nil, err
+	}
+
+	resourceLists, err := provider.ClientSet.Discovery().ServerPreferredResources()
+	// unavailable aggregated apis (e.g. a broken metrics-server) must not hide all other resources
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, err
+	}
+
+	result := []dtos.GenericResource{}
+	for _, resourceList := range resourceLists {
+		gv, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			continue
+		}
+		result = append(result, genericResourcesFrom(gv, resourceList)...)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].FullName() < result[j].FullName()
+	})
+	return result, nil
+}
+
+// FindGenericResource looks up a resource of the given group ("" for core) and version.
+func FindGenericResource(group string, version string, resource string, contextId *string) (*dtos.GenericResource, error) {
+	provider, err := NewKubeProvider(contextId)
+	if err != nil {
+		return nil, err
+	}
+
+	gv := schema.GroupVersion{Group: group, Version: version}
+	resourceList, err := provider.ClientSet.Discovery().ServerResourcesForGroupVersion(gv.String())
+	if err != nil {
+		return nil, err
+	}
+	for _, genericResource := range genericResourcesFrom(gv, resourceList) {
+		if genericResource.Resource == resource {
+			return &genericResource, nil
+		}
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Group: group, Resource: resource}, "")
+}
+
+// ResolveGenericResource finds a resource like kubectl does: by plural, singular (kind) or short name, optionally
+// qualified by its group (e.g. "deploy", "Rollout" or "rollouts.argoproj.io").
+func ResolveGenericResource(name string, contextId *string) (*dtos.GenericResource, error) {
+	resources, err := ListGenericResources(contextId)
+	if err != nil {
+		return nil, err
+	}
+
+	name = strings.ToLower(name)
+	group := ""
+	qualified := false
+	if index := strings.Index(name, "."); index >= 0 {
+		name, group = name[:index], name[index+1:]
+		qualified = true
+	}
+	for _, resource := range resources {
+		if qualified && resource.Group != group {
+			continue
+		}
+		if resource.Resource == name || strings.ToLower(resource.Kind) == name || utils.ContainsEqual(resource.ShortNames, name) {
+			return &resource, nil
+		}
+	}
+	return nil, fmt.Errorf("the server doesn't have a resource type '%s'", name)
+}
+
+// GenericResourceAccessLevel mirrors the access levels of the typed workload routes: USER for the kinds of
+// ALL_RESOURCES_USER (except deleting namespaces), ADMIN for everything else (secrets, rbac, custom resources, ...).
+func GenericResourceAccessLevel(resource dtos.GenericResource, verb string) dtos.AccessLevel {
+	kind := resource.Kind
+	if kind == "Endpoints" {
+		kind = RES_ENDPOINT
+	}
+	if !utils.ContainsEqual(ALL_RESOURCES_USER, kind) || !utils.ContainsEqual(genericBuiltinGroups, resource.Group) {
+		return dtos.ADMIN
+	}
+	if kind == RES_NAMESPACE && verb == "delete" {
+		return dtos.ADMIN
+	}
+	return dtos.USER
+}
+
+func ListGenericWorkloads(resource dtos.GenericResource, namespace string, labelSelector string, contextId *string) ([]unstructured.Unstructured, error) {
+	client, err := genericClient(resource, namespace, contextId)
+	if err != nil {
+		return nil, err
+	}
+	list, err := client.List(context.TODO(), metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return nil, err
+	}
+
+	result := []unstructured.Unstructured{}
+	for _, item := range list.Items {
+		if resource.Namespaced && utils.Contains(utils.CONFIG.Misc.IgnoreNamespaces, item.GetNamespace()) {
+			continue
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+func AllK8sGenericWorkloads(resource dtos.GenericResource, namespace string, labelSelector string, contextId *string) utils.K8sWorkloadResult {
+	result, err := ListGenericWorkloads(resource, namespace, labelSelector, contextId)
+	if err != nil {
+		return WorkloadResult(nil, err)
+	}
+	return WorkloadResult(result, nil)
+}
+
+func GetGenericWorkload(resource dtos.GenericResource, namespace string, name string, contextId *string) (*unstructured.Unstructured, error) {
+	if err := requireGenericNamespace(resource, namespace); err != nil {
+		return nil, err
+	}
+	client, err := genericClient(resource, namespace, contextId)
+	if err != nil {
+		return nil, err
+	}
+	return client.Get(context.TODO(), name, metav1.GetOptions{})
+}
+
+func GetK8sGenericWorkload(resource dtos.GenericResource, namespace string, name string, contextId *string) utils.K8sWorkloadResult {
+	res, err := GetGenericWorkload(resource, namespace, name, contextId)
+	if err != nil {
+		return WorkloadResult(nil, err)
+	}
+	return WorkloadResult(res, nil)
+}
+
+// CreateK8sGenericWorkload creates an object from its yaml or json manifest. apiVersion and kind are set if
+// missing and must match the resource otherwise. The object is created in namespace (the namespace the caller
+// authorized), a manifest of another namespace is rejected.
+func CreateK8sGenericWorkload(resource dtos.GenericResource, namespace string, manifest []byte, contextId *string) utils.K8sWorkloadResult {
+	object := unstructured.Unstructured{}
+	err := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(manifest), 4096).Decode(&object.Object)
+	if err != nil {
+		return WorkloadResult(nil, err)
+	}
+	if object.GetAPIVersion() == "" {
+		object.SetAPIVersion(resource.GroupVersion())
+	}
+	if object.GetKind() == "" {
+		object.SetKind(resource.Kind)
+	}
+	if object.GetAPIVersion() != resource.GroupVersion() || object.GetKind() != resource.Kind {
+		return WorkloadResult(nil, fmt.Errorf("manifest is a %s %s, expected %s %s", object.GetAPIVersion(), object.GetKind(), resource.GroupVersion(), resource.Kind))
+	}
+	if resource.Namespaced && object.GetNamespace() != namespace {
+		return WorkloadResult(nil, fmt.Errorf("manifest is in namespace '%s', expected '%s'", object.GetNamespace(), namespace))
+	}
+	if err := requireGenericNamespace(resource, object.GetNamespace()); err != nil {
+		return WorkloadResult(nil, err)
+	}
+
+	client, err := genericClient(resource, object.GetNamespace(), contextId)
+	if err != nil {
+		return WorkloadResult(nil, err)
+	}
+	res, err := client.Create(context.TODO(), &object, metav1.CreateOptions{})
+	if err != nil {
+		return WorkloadResult(nil, err)
+	}
+	return WorkloadResult(res, nil)
+}
+
+// PatchK8sGenericWorkload applies a JSON merge patch (RFC 7386).
+func PatchK8sGenericWorkload(resource dtos.GenericResource, namespace string, name string, patch []byte, contextId *string) utils.K8sWorkloadResult {
+	if err := requireGenericNamespace(resource, namespace); err != nil {
+		return WorkloadResult(nil, err)
+	}
+	client, err := genericClient(resource, namespace, contextId)
+	if err != nil {
+		return WorkloadResult(nil, err)
+	}
+	res, err := client.Patch(context.TODO(), name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return WorkloadResult(nil, err)
+	}
+	return WorkloadResult(res, nil)
+}
+
+func DeleteK8sGenericWorkloadBy(resource dtos.GenericResource, namespace string, name string, contextId *string) error {
+	if err := requireGenericNamespace(resource, namespace); err != nil {
+		return err
+	}
+	client, err := genericClient(resource, namespace, contextId)
+	if err != nil {
+		return err
+	}
+	return client.Delete(context.TODO(), name, metav1.DeleteOptions{})
+}
+
+func requireGenericNamespace(resource dtos.GenericResource, namespace string) error {
+	if resource.Namespaced && namespace == "" {
+		return fmt.Errorf("%s is namespaced, a namespace is required", resource.FullName())
+	}
+	return nil
+}
+
+func ListGenericResourcesTerminal(resources []dtos.GenericResource) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"#", "Name", "Short Names", "ApiVersion", "Namespaced", "Kind"})
+	for index, resource := range resources {
+		t.AppendRow(
+			table.Row{index + 1, resource.FullName(), strings.Join(resource.ShortNames, ","), resource.GroupVersion(), resource.Namespaced, resource.Kind},
+		)
+	}
+	t.Render()
+}
+
+func ListGenericWorkloadsTerminal(resource dtos.GenericResource, items []unstructured.Unstructured) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	if resource.Namespaced {
+		t.AppendHeader(table.Row{"#", "Namespace", "Name", "Age"})
+	} else {
+		t.AppendHeader(table.Row{"#", "Name", "Age"})
+	}
+	for index, item := range items {
+		age := utils.JsonStringToHumanDuration(item.GetCreationTimestamp().Format(time.RFC3339))
+		if resource.Namespaced {
+			t.AppendRow(table.Row{index + 1, item.GetNamespace(), item.GetName(), age})
+		} else {
+			t.AppendRow(table.Row{index + 1, item.GetName(), age})
+		}
+	}
+	t.Render()
+}
@@ -0,0 +1,34 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/mogenius/punq/dtos"
+)
+
+func TestGenericResourceAccessLevel(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource dtos.GenericResource
+		verb     string
+		want     dtos.AccessLevel
+	}{
+		{"pods", dtos.GenericResource{Group: "", Kind: "Pod"}, "delete", dtos.USER},
+		{"deployments", dtos.GenericResource{Group: "apps", Kind: "Deployment"}, "patch", dtos.USER},
+		{"endpoints kind name", dtos.GenericResource{Group: "", Kind: "Endpoints"}, "get", dtos.USER},
+		{"list namespaces", dtos.GenericResource{Group: "", Kind: "Namespace"}, "list", dtos.USER},
+		{"delete namespaces", dtos.GenericResource{Group: "", Kind: "Namespace"}, "delete", dtos.ADMIN},
+		{"secrets", dtos.GenericResource{Group: "", Kind: "Secret"}, "get", dtos.ADMIN},
+		{"cluster roles", dtos.GenericResource{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"}, "list", dtos.ADMIN},
+		{"custom resources", dtos.GenericResource{Group: "argoproj.io", Kind: "Rollout"}, "get", dtos.ADMIN},
+		{"crd reusing a built-in kind", dtos.GenericResource{Group: "evil.example.com", Kind: "Pod"}, "create", dtos.ADMIN},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := GenericResourceAccessLevel(test.resource, test.verb); got != test.want {
+				t.Errorf("GenericResourceAccessLevel(%s, %s) = %s, want %s", test.resource.Kind, test.verb, got.String(), test.want.String())
+			}
+		})
+	}
+}
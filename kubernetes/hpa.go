@@ -2,7 +2,6 @@ package kubernetes
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/mogenius/punq/utils"
 
@@ -77,15 +76,7 @@ func DeleteK8sHpaBy(namespace string, name string, contextId *string) error {
 }
 
 func DescribeK8sHpa(namespace string, name string, contextId *string) utils.K8sWorkloadResult {
-	cmd := utils.RunOnLocalShell(fmt.Sprintf("kubectl describe hpa %s -n %s%s", name, namespace, ContextFlag(contextId)))
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		logger.Log.Errorf("Failed to execute command (%s): %v", cmd.String(), err)
-		logger.Log.Errorf("Error: %s", string(output))
-		return WorkloadResult(nil, string(output))
-	}
-	return WorkloadResult(string(output), nil)
+	return describeK8s("HorizontalPodAutoscaler", namespace, name, contextId)
 }
 
 func CreateK8sHpa(data v2.HorizontalPodAutoscaler, contextId *string) utils.K8sWorkloadResult {
@@ -0,0 +1,63 @@
+package kubernetes
+
+import (
+	"fmt"
+	"sync"
+
+	"k8s.io/client-go/rest"
+)
+
+// Handlers pass the *string of the X-Context-Id header down to all providers and kubectl calls. The identity for
+// cluster requests is attached to exactly that pointer while the request is running:
+//   - RegisterImpersonation: the punq user of the request (contexts with PunqContext.Impersonate)
+//   - RegisterSystemIdentity: punq itself, i.e. the identity of the kubeconfig (cli, informer caches)
+//
+// Contexts with Impersonate fail closed: a pointer without an attached identity (e.g. a copied context id) gets
+// an error instead of the privileged identity of the kubeconfig.
+type contextIdentity struct {
+	system        bool
+	impersonation rest.ImpersonationConfig
+}
+
+var contextIdentities sync.Map // *string -> contextIdentity
+
+func RegisterImpersonation(contextId *string, config rest.ImpersonationConfig) {
+	if contextId == nil {
+		return
+	}
+	contextIdentities.Store(contextId, contextIdentity{impersonation: config})
+}
+
+// RegisterSystemIdentity lets calls with contextId use the identity of the kubeconfig even if the context
+// impersonates its users. Only for work punq does on its own behalf, never for requests of a user.
+func RegisterSystemIdentity(contextId *string) {
+	if contextId == nil {
+		return
+	}
+	contextIdentities.Store(contextId, contextIdentity{system: true})
+}
+
+func ReleaseIdentity(contextId *string) {
+	if contextId == nil {
+		return
+	}
+	contextIdentities.Delete(contextId)
+}
+
+// impersonationFor returns the user to impersonate for calls with contextId (nil: identity of the kubeconfig).
+func impersonationFor(contextId *string) (*rest.ImpersonationConfig, error) {
+	if contextId == nil {
+		return nil, nil
+	}
+	if value, exists := contextIdentities.Load(contextId); exists {
+		identity := value.(contextIdentity)
+		if identity.system {
+			return nil, nil
+		}
+		return &identity.impersonation, nil
+	}
+	if ctx := ContextForId(*contextId); ctx != nil && ctx.Impersonate {
+		return nil, fmt.Errorf("context '%s' impersonates its users, but no user identity is attached to the request", ctx.Name)
+	}
+	return nil, nil
+}
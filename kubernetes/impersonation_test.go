@@ -0,0 +1,53 @@
+package kubernetes
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mogenius/punq/dtos"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+func TestImpersonationFailsClosed(t *testing.T) {
+	Contexts.Upsert(dtos.PunqContext{Id: "ctx-impersonate", Name: "impersonate", Impersonate: true, InformerCache: true})
+	Contexts.Upsert(dtos.PunqContext{Id: "ctx-plain", Name: "plain"})
+	t.Cleanup(func() {
+		Contexts.Remove("ctx-impersonate")
+		Contexts.Remove("ctx-plain")
+	})
+
+	requestId := "ctx-impersonate"
+	RegisterImpersonation(&requestId, rest.ImpersonationConfig{UserName: "jane@punq.dev", Groups: []string{"punq:authenticated"}})
+	defer ReleaseIdentity(&requestId)
+
+	config, err := impersonationFor(&requestId)
+	if err != nil || config == nil || config.UserName != "jane@punq.dev" {
+		t.Errorf("registered pointer: got %v, %v", config, err)
+	}
+
+	// a copy of the id carries no identity and must not fall back to the kubeconfig identity
+	copiedId := requestId
+	if config, err := impersonationFor(&copiedId); err == nil {
+		t.Errorf("copied id: got %v, want error", config)
+	}
+	if _, _, err := pooledClientset(&copiedId, "test", func(*rest.Config, *http.Client) (interface{}, error) { return nil, nil }); err == nil {
+		t.Error("copied id: pooledClientset succeeded")
+	}
+
+	systemId := "ctx-impersonate"
+	RegisterSystemIdentity(&systemId)
+	defer ReleaseIdentity(&systemId)
+	if config, err := impersonationFor(&systemId); err != nil || config != nil {
+		t.Errorf("system identity: got %v, %v", config, err)
+	}
+
+	plainId := "ctx-plain"
+	if config, err := impersonationFor(&plainId); err != nil || config != nil {
+		t.Errorf("context without impersonation: got %v, %v", config, err)
+	}
+
+	if _, cached := informerCacheList(&systemId, "Pod", "", metav1.ListOptions{}); cached {
+		t.Error("informer cache served a context with impersonation")
+	}
+}
@@ -0,0 +1,312 @@
+package kubernetes
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mogenius/punq/dtos"
+	"github.com/mogenius/punq/logger"
+	"github.com/mogenius/punq/utils"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const informerCacheCheckInterval = time.Minute
+
+const (
+	informerNamespaces             = "namespaces"
+	informerNodes                  = "nodes"
+	informerPods                   = "pods"
+	informerServices               = "services"
+	informerConfigMaps             = "configmaps"
+	informerPersistentVolumeClaims = "persistentvolumeclaims"
+	informerDeployments            = "deployments"
+	informerReplicaSets            = "replicasets"
+	informerStatefulSets           = "statefulsets"
+	informerDaemonSets             = "daemonsets"
+	informerJobs                   = "jobs"
+	informerCronJobs               = "cronjobs"
+	informerIngresses              = "ingresses"
+)
+
+// kinds which are served from the informer cache (secrets are never cached)
+var informerKinds = map[string]func(informers.SharedInformerFactory) cache.SharedIndexInformer{
+	informerNamespaces: func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Core().V1().Namespaces().Informer()
+	},
+	informerNodes: func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Core().V1().Nodes().Informer()
+	},
+	informerPods: func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Core().V1().Pods().Informer()
+	},
+	informerServices: func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Core().V1().Services().Informer()
+	},
+	informerConfigMaps: func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Core().V1().ConfigMaps().Informer()
+	},
+	informerPersistentVolumeClaims: func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Core().V1().PersistentVolumeClaims().Informer()
+	},
+	informerDeployments: func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Apps().V1().Deployments().Informer()
+	},
+	informerReplicaSets: func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Apps().V1().ReplicaSets().Informer()
+	},
+	informerStatefulSets: func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Apps().V1().StatefulSets().Informer()
+	},
+	informerDaemonSets: func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Apps().V1().DaemonSets().Informer()
+	},
+	informerJobs: func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Batch().V1().Jobs().Informer()
+	},
+	informerCronJobs: func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Batch().V1().CronJobs().Informer()
+	},
+	informerIngresses: func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Networking().V1().Ingresses().Informer()
+	},
+}
+
+// contextInformers is the SharedInformerFactory of one context. Informers of a kind are added on first use.
+type contextInformers struct {
+	factory   informers.SharedInformerFactory
+	stop      chan struct{}
+	informers map[string]*cachedInformer
+	started   time.Time
+	lastUsed  time.Time
+}
+
+type cachedInformer struct {
+	informer  cache.SharedIndexInformer
+	started   time.Time
+	lastError string
+}
+
+var informerCaches = map[string]*contextInformers{}
+var informerCachesLock sync.Mutex
+var informerCacheJanitor sync.Once
+
+func init() {
+	Contexts.OnChange(func(change ContextChange) {
+		if change.Type == ContextRemoved || change.KubeconfigChanged || !change.Context.InformerCache {
+			stopInformerCache(change.Context.Id, "context changed")
+		}
+	})
+}
+
+// cachedList serves a LIST from the informer cache of the context if the context opted in (PunqContext.InformerCache),
+// does not impersonate its users (the cluster RBAC must apply) and the informer of the kind has synced. Otherwise (and while
+// the informer is syncing) listLive is called.
+func cachedList[L any, PL interface {
+	*L
+	runtime.Object
+}](contextId *string, kind string, namespace string, opts metav1.ListOptions, listLive func(context.Context, metav1.ListOptions) (PL, error)) (PL, error) {
+	objects, cached := informerCacheList(contextId, kind, namespace, opts)
+	if !cached {
+		return listLive(context.TODO(), opts)
+	}
+	list := PL(new(L))
+	if err := meta.SetList(list, objects); err != nil {
+		logger.Log.Errorf("Failed to serve %s from informer cache: %s", kind, err.Error())
+		return listLive(context.TODO(), opts)
+	}
+	return list, nil
+}
+
+func informerCacheList(contextId *string, kind string, namespace string, opts metav1.ListOptions) ([]runtime.Object, bool) {
+	if contextId == nil {
+		return nil, false
+	}
+	// the informers watch with the identity of the kubeconfig, never serve them to impersonated users
+	ctx := ContextForId(*contextId)
+	if ctx == nil || !ctx.InformerCache || ctx.Impersonate {
+		return nil, false
+	}
+	informer := contextInformer(*contextId, kind)
+	if informer == nil || !informer.HasSynced() {
+		return nil, false
+	}
+
+	labelSelector, err := labels.Parse(opts.LabelSelector)
+	if err != nil {
+		return nil, false
+	}
+	fieldSelector, err := fields.ParseSelector(opts.FieldSelector)
+	if err != nil {
+		return nil, false
+	}
+
+	var items []interface{}
+	if namespace != "" {
+		items, err = informer.GetIndexer().ByIndex(cache.NamespaceIndex, namespace)
+		if err != nil {
+			return nil, false
+		}
+	} else {
+		items = informer.GetIndexer().List()
+	}
+
+	result := []runtime.Object{}
+	for _, item := range items {
+		object, ok := item.(runtime.Object)
+		if !ok {
+			continue
+		}
+		accessor, err := meta.Accessor(object)
+		if err != nil {
+			continue
+		}
+		if !labelSelector.Matches(labels.Set(accessor.GetLabels())) || !fieldSelector.Matches(objectFields(object, accessor)) {
+			continue
+		}
+		// objects of the cache must not be modified by callers
+		result = append(result, object.DeepCopyObject())
+	}
+	return result, true
+}
+
+// objectFields supports the field selectors used for lists in this package.
+func objectFields(object runtime.Object, accessor metav1.Object) fields.Set {
+	set := fields.Set{
+		"metadata.name":      accessor.GetName(),
+		"metadata.namespace": accessor.GetNamespace(),
+	}
+	if pod, ok := object.(*v1.Pod); ok {
+		set["spec.nodeName"] = pod.Spec.NodeName
+		set["status.phase"] = string(pod.Status.Phase)
+	}
+	return set
+}
+
+// contextInformer returns the informer of kind, starting the factory of the context lazily.
+func contextInformer(contextId string, kind string) cache.SharedIndexInformer {
+	informerFor, supported := informerKinds[kind]
+	if !supported {
+		return nil
+	}
+
+	informerCachesLock.Lock()
+	defer informerCachesLock.Unlock()
+
+	caches, exists := informerCaches[contextId]
+	if !exists {
+		// the factory uses the identity of the kubeconfig (own pointer, so no impersonation of a request applies)
+		id := contextId
+		RegisterSystemIdentity(&id)
+		clientset, _, err := pooledClientset(&id, "kubernetes", kubernetes.NewForConfigAndClient)
+		ReleaseIdentity(&id)
+		if err != nil {
+			logger.Log.Errorf("Failed to start informer cache of context '%s': %s", contextId, err.Error())
+			return nil
+		}
+		caches = &contextInformers{
+			factory:   informers.NewSharedInformerFactory(clientset, 0),
+			stop:      make(chan struct{}),
+			informers: map[string]*cachedInformer{},
+			started:   time.Now(),
+		}
+		informerCaches[contextId] = caches
+		logger.Log.Infof("Started informer cache of context '%s'.", contextId)
+		informerCacheJanitor.Do(func() {
+			go stopIdleInformerCaches()
+		})
+	}
+	caches.lastUsed = time.Now()
+
+	cached, exists := caches.informers[kind]
+	if !exists {
+		cached = &cachedInformer{informer: informerFor(caches.factory), started: time.Now()}
+		err := cached.informer.SetWatchErrorHandler(func(r *cache.Reflector, err error) {
+			informerCachesLock.Lock()
+			cached.lastError = err.Error()
+			informerCachesLock.Unlock()
+			cache.DefaultWatchErrorHandler(r, err)
+		})
+		if err != nil {
+			logger.Log.Errorf("Failed to set watch error handler for %s: %s", kind, err.Error())
+		}
+		caches.informers[kind] = cached
+		caches.factory.Start(caches.stop)
+	}
+	return cached.informer
+}
+
+func stopIdleInformerCaches() {
+	for {
+		time.Sleep(informerCacheCheckInterval)
+
+		idleTimeout := time.Duration(utils.CONFIG.Kubernetes.InformerIdleMinutes) * time.Minute
+		informerCachesLock.Lock()
+		idle := []string{}
+		for contextId, caches := range informerCaches {
+			if time.Since(caches.lastUsed) > idleTimeout {
+				idle = append(idle, contextId)
+			}
+		}
+		informerCachesLock.Unlock()
+
+		for _, contextId := range idle {
+			stopInformerCache(contextId, "idle")
+		}
+	}
+}
+
+func stopInformerCache(contextId string, reason string) {
+	informerCachesLock.Lock()
+	defer informerCachesLock.Unlock()
+
+	caches, exists := informerCaches[contextId]
+	if !exists {
+		return
+	}
+	close(caches.stop)
+	delete(informerCaches, contextId)
+	logger.Log.Infof("Stopped informer cache of context '%s' (%s).", contextId, reason)
+}
+
+// InformerCacheStatus reports the running informer caches and whether their kinds have synced.
+func InformerCacheStatus() []dtos.InformerCacheStatus {
+	informerCachesLock.Lock()
+	defer informerCachesLock.Unlock()
+
+	result := []dtos.InformerCacheStatus{}
+	for contextId, caches := range informerCaches {
+		status := dtos.InformerCacheStatus{
+			ContextId: contextId,
+			Started:   caches.started.Format(time.RFC3339),
+			LastUsed:  caches.lastUsed.Format(time.RFC3339),
+			Kinds:     []dtos.InformerCacheKindStatus{},
+		}
+		for kind, cached := range caches.informers {
+			status.Kinds = append(status.Kinds, dtos.InformerCacheKindStatus{
+				Kind:      kind,
+				Synced:    cached.informer.HasSynced(),
+				Items:     len(cached.informer.GetStore().ListKeys()),
+				Started:   cached.started.Format(time.RFC3339),
+				LastError: cached.lastError,
+			})
+		}
+		sort.Slice(status.Kinds, func(i, j int) bool {
+			return status.Kinds[i].Kind < status.Kinds[j].Kind
+		})
+		result = append(result, status)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ContextId < result[j].ContextId
+	})
+	return result
+}
@@ -2,7 +2,6 @@ package kubernetes
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/mogenius/punq/logger"
 	"github.com/mogenius/punq/utils"
@@ -22,7 +21,7 @@ func AllIngresses(namespaceName string, contextId *string) []v1.Ingress {
 	if err != nil {
 		return result
 	}
-	ingressList, err := provider.ClientSet.NetworkingV1().Ingresses(namespaceName).List(context.TODO(), metav1.ListOptions{FieldSelector: "metadata.namespace!=kube-system"})
+	ingressList, err := cachedList(contextId, informerIngresses, namespaceName, metav1.ListOptions{FieldSelector: "metadata.namespace!=kube-system"}, provider.ClientSet.NetworkingV1().Ingresses(namespaceName).List)
 	if err != nil {
 		logger.Log.Errorf("AllIngresses ERROR: %s", err.Error())
 		return result
@@ -43,7 +42,7 @@ func AllK8sIngresses(namespaceName string, contextId *string) utils.K8sWorkloadR
 	if err != nil {
 		return WorkloadResult(nil, err)
 	}
-	ingressList, err := provider.ClientSet.NetworkingV1().Ingresses(namespaceName).List(context.TODO(), metav1.ListOptions{FieldSelector: "metadata.namespace!=kube-system"})
+	ingressList, err := cachedList(contextId, informerIngresses, namespaceName, metav1.ListOptions{FieldSelector: "metadata.namespace!=kube-system"}, provider.ClientSet.NetworkingV1().Ingresses(namespaceName).List)
 	if err != nil {
 		logger.Log.Errorf("AllIngresses ERROR: %s", err.Error())
 		return WorkloadResult(nil, err)
@@ -101,15 +100,7 @@ func DeleteK8sIngressBy(namespace string, name string, contextId *string) error
 }
 
 func DescribeK8sIngress(namespace string, name string, contextId *string) utils.K8sWorkloadResult {
-	cmd := utils.RunOnLocalShell(fmt.Sprintf("kubectl describe ingress %s -n %s%s", name, namespace, ContextFlag(contextId)))
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		logger.Log.Errorf("Failed to execute command (%s): %v", cmd.String(), err)
-		logger.Log.Errorf("Error: %s", string(output))
-		return WorkloadResult(nil, string(output))
-	}
-	return WorkloadResult(string(output), nil)
+	return describeK8s("Ingress", namespace, name, contextId)
 }
 
 func CreateK8sIngress(data v1.Ingress, contextId *string) utils.K8sWorkloadResult {
@@ -6,7 +6,6 @@
 package kubernetes
 
 import (
-	"context"
 	"fmt"
 	"net"
 
@@ -25,7 +24,7 @@ func GetIngressControllerIps(useLocalKubeConfig bool, contextId *string) []net.I
 
 	labelSelector := "app.kubernetes.io/component=controller,app.kubernetes.io/instance=nginx-ingress,app.kubernetes.io/name=ingress-nginx"
 
-	pods, err := provider.ClientSet.CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{LabelSelector: labelSelector})
+	pods, err := cachedList(contextId, informerPods, "", metav1.ListOptions{LabelSelector: labelSelector}, provider.ClientSet.CoreV1().Pods("").List)
 
 	for _, pod := range pods.Items {
 		ip := net.ParseIP(pod.Status.PodIP)
@@ -51,7 +50,7 @@ func GetClusterExternalIps(contextId *string) []string {
 		return result
 	}
 	labelSelector := "app.kubernetes.io/component=controller,app.kubernetes.io/name=ingress-nginx"
-	services, err := provider.ClientSet.CoreV1().Services("").List(context.TODO(), metav1.ListOptions{LabelSelector: labelSelector})
+	services, err := cachedList(contextId, informerServices, "", metav1.ListOptions{LabelSelector: labelSelector}, provider.ClientSet.CoreV1().Services("").List)
 	allServices = append(allServices, services.Items...)
 
 	if err != nil {
@@ -62,7 +61,7 @@ func GetClusterExternalIps(contextId *string) []string {
 	// check if traefik is used
 	if len(result) <= 0 {
 		traefikSelector := "app.kubernetes.io/name=traefik"
-		services, err := provider.ClientSet.CoreV1().Services("").List(context.TODO(), metav1.ListOptions{LabelSelector: traefikSelector})
+		services, err := cachedList(contextId, informerServices, "", metav1.ListOptions{LabelSelector: traefikSelector}, provider.ClientSet.CoreV1().Services("").List)
 		allServices = append(allServices, services.Items...)
 
 		if err != nil {
@@ -2,7 +2,6 @@ package kubernetes
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/mogenius/punq/utils"
 
@@ -19,7 +18,7 @@ func AllJobs(namespaceName string, contextId *string) utils.K8sWorkloadResult {
 	if err != nil {
 		return WorkloadResult(nil, err)
 	}
-	jobList, err := provider.ClientSet.BatchV1().Jobs(namespaceName).List(context.TODO(), metav1.ListOptions{FieldSelector: "metadata.namespace!=kube-system"})
+	jobList, err := cachedList(contextId, informerJobs, namespaceName, metav1.ListOptions{FieldSelector: "metadata.namespace!=kube-system"}, provider.ClientSet.BatchV1().Jobs(namespaceName).List)
 	if err != nil {
 		logger.Log.Errorf("AllJobs ERROR: %s", err.Error())
 		return WorkloadResult(nil, err)
@@ -77,15 +76,7 @@ func DeleteK8sJobBy(namespace string, name string, contextId *string) error {
 }
 
 func DescribeK8sJob(namespace string, name string, contextId *string) utils.K8sWorkloadResult {
-	cmd := utils.RunOnLocalShell(fmt.Sprintf("kubectl describe job %s -n %s%s", name, namespace, ContextFlag(contextId)))
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		logger.Log.Errorf("Failed to execute command (%s): %v", cmd.String(), err)
-		logger.Log.Errorf("Error: %s", string(output))
-		return WorkloadResult(nil, string(output))
-	}
-	return WorkloadResult(string(output), nil)
+	return describeK8s("Job", namespace, name, contextId)
 }
 
 func CreateK8sJob(data v1job.Job, contextId *string) utils.K8sWorkloadResult {
@@ -2,6 +2,7 @@ package kubernetes
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/mogenius/punq/logger"
 	"github.com/mogenius/punq/utils"
@@ -16,66 +17,61 @@ type KubeProvider struct {
 	ClientConfig rest.Config
 }
 
+// NewKubeProvider returns the pooled clientset of the context (see providerPool).
 func NewKubeProvider(contextId *string) (*KubeProvider, error) {
-	var provider *KubeProvider
-	var err error
-	if RunsInCluster {
-		provider, err = newKubeProviderInCluster(contextId)
-	} else {
-		provider, err = newKubeProviderLocal(contextId)
-	}
-
+	clientSet, config, err := pooledClientset(contextId, "kubernetes", kubernetes.NewForConfigAndClient)
 	if err != nil {
 		logger.Log.Errorf("ERROR: %s", err.Error())
-	}
-	return provider, err
-}
-
-func newKubeProviderLocal(contextId *string) (*KubeProvider, error) {
-	config, err := ContextSwitcher(contextId)
-	if err != nil {
 		return nil, err
 	}
-
-	clientSet, errClientSet := kubernetes.NewForConfig(config)
-	if errClientSet != nil {
-		return nil, errClientSet
-	}
-
 	return &KubeProvider{
 		ClientSet:    clientSet,
 		ClientConfig: *config,
 	}, nil
 }
 
-func newKubeProviderInCluster(contextId *string) (*KubeProvider, error) {
-	config, err := rest.InClusterConfig()
+// parsed kubeconfigs per context id, dropped when the kubeconfig of the context changes
+var contextRestConfigs = map[string]*rest.Config{}
+var contextRestConfigsLock sync.Mutex
+
+func init() {
+	Contexts.OnChange(func(change ContextChange) {
+		if change.KubeconfigChanged {
+			contextRestConfigsLock.Lock()
+			delete(contextRestConfigs, change.Context.Id)
+			contextRestConfigsLock.Unlock()
+			kubeProviderPool.invalidate(change.Context.Id)
+		}
+	})
+}
+
+func ContextConfigLoader(contextId *string) (*rest.Config, error) {
+	config, err := contextRestConfig(*contextId)
 	if err != nil {
 		return nil, err
 	}
-
-	if contextId != nil {
-		config, err = ContextSwitcher(contextId)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	clientset, err := kubernetes.NewForConfig(config)
+	impersonation, err := impersonationFor(contextId)
 	if err != nil {
 		return nil, err
 	}
-
-	return &KubeProvider{
-		ClientSet:    clientset,
-		ClientConfig: *config,
-	}, nil
+	if impersonation != nil {
+		config.Impersonate = *impersonation
+	}
+	return config, nil
 }
 
-func ContextConfigLoader(contextId *string) (*rest.Config, error) {
-	ctx := ContextForId(*contextId)
+// contextRestConfig returns a copy of the (cached) rest config of the context.
+func contextRestConfig(id string) (*rest.Config, error) {
+	contextRestConfigsLock.Lock()
+	defer contextRestConfigsLock.Unlock()
+
+	if config, exists := contextRestConfigs[id]; exists {
+		return rest.CopyConfig(config), nil
+	}
+
+	ctx := ContextForId(id)
 	if ctx == nil {
-		return nil, fmt.Errorf("context not found for id: %s", *contextId)
+		return nil, fmt.Errorf("context not found for id: %s", id)
 	}
 
 	configFromString, err := clientcmd.NewClientConfigFromBytes([]byte(ctx.Context))
@@ -85,7 +81,11 @@ func ContextConfigLoader(contextId *string) (*rest.Config, error) {
 	}
 
 	config, err := configFromString.ClientConfig()
-	return config, err
+	if err != nil {
+		return nil, err
+	}
+	contextRestConfigs[id] = config
+	return rest.CopyConfig(config), nil
 }
 
 func ContextSwitcher(contextId *string) (*rest.Config, error) {
@@ -2,7 +2,6 @@ package kubernetes
 
 import (
 	"context"
-	"fmt"
 	"strings"
 
 	"github.com/mogenius/punq/utils"
@@ -22,7 +21,7 @@ func ListAllNamespaceNames(contextId *string) []string {
 	}
 	namespaceClient := provider.ClientSet.CoreV1().Namespaces()
 
-	namespaceList, err := namespaceClient.List(context.TODO(), metav1.ListOptions{})
+	namespaceList, err := cachedList(contextId, informerNamespaces, "", metav1.ListOptions{}, namespaceClient.List)
 	if err != nil {
 		logger.Log.Errorf("ListAll ERROR: %s", err.Error())
 		return result
@@ -44,7 +43,7 @@ func ListAllNamespace(contextId *string) []v1.Namespace {
 	}
 	namespaceClient := provider.ClientSet.CoreV1().Namespaces()
 
-	namespaceList, err := namespaceClient.List(context.TODO(), metav1.ListOptions{})
+	namespaceList, err := cachedList(contextId, informerNamespaces, "", metav1.ListOptions{}, namespaceClient.List)
 	if err != nil {
 		logger.Log.Errorf("ListAllNamespace ERROR: %s", err.Error())
 		return result
@@ -73,7 +72,7 @@ func ListK8sNamespaces(namespaceName string, contextId *string) utils.K8sWorkloa
 	}
 	namespaceClient := provider.ClientSet.CoreV1().Namespaces()
 
-	namespaceList, err := namespaceClient.List(context.TODO(), metav1.ListOptions{})
+	namespaceList, err := cachedList(contextId, informerNamespaces, "", metav1.ListOptions{}, namespaceClient.List)
 	if err != nil {
 		logger.Log.Errorf("ListAllNamespace ERROR: %s", err.Error())
 		return WorkloadResult(nil, err)
@@ -115,15 +114,7 @@ func DeleteK8sNamespaceBy(name string, contextId *string) error {
 }
 
 func DescribeK8sNamespace(name string, contextId *string) utils.K8sWorkloadResult {
-	cmd := utils.RunOnLocalShell(fmt.Sprintf("kubectl describe namespace %s%s", name, ContextFlag(contextId)))
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		logger.Log.Errorf("Failed to execute command (%s): %v", cmd.String(), err)
-		logger.Log.Errorf("Error: %s", string(output))
-		return WorkloadResult(nil, string(output))
-	}
-	return WorkloadResult(string(output), nil)
+	return describeK8s("Namespace", "", name, contextId)
 }
 
 func NamespaceExists(namespaceName string, contextId *string) (bool, error) {
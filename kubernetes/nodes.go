@@ -19,9 +19,15 @@ func GetNodeStats(contextId *string) []dtos.NodeStat {
 	nodes := ListNodes(contextId)
 	nodeMetrics := ListNodeMetricss(contextId)
 
+	// one list for all nodes instead of one per node
+	podsOnNode := map[string][]v1.Pod{}
+	for _, pod := range allScheduledPods(contextId) {
+		podsOnNode[pod.Spec.NodeName] = append(podsOnNode[pod.Spec.NodeName], pod)
+	}
+
 	for index, node := range nodes {
 
-		allPods := AllPodsOnNode(node.Name, contextId)
+		allPods := podsOnNode[node.Name]
 		requestCpuCores, limitCpuCores := SumCpuResources(allPods)
 		requestMemoryBytes, limitMemoryBytes := SumMemoryResources(allPods)
 
@@ -132,7 +138,7 @@ func ListK8sNodes(contextId *string) utils.K8sWorkloadResult {
 		return WorkloadResult(nil, err)
 	}
 
-	nodeMetricsList, err := provider.ClientSet.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	nodeMetricsList, err := cachedList(contextId, informerNodes, "", metav1.ListOptions{}, provider.ClientSet.CoreV1().Nodes().List)
 	if err != nil {
 		logger.Log.Errorf("ListNodeMetrics ERROR: %s", err.Error())
 		return WorkloadResult(nil, err)
@@ -157,13 +163,5 @@ func DeleteK8sNode(name string, contextId *string) error {
 }
 
 func DescribeK8sNode(name string, contextId *string) utils.K8sWorkloadResult {
-	cmd := utils.RunOnLocalShell(fmt.Sprintf("kubectl describe node %s%s", name, ContextFlag(contextId)))
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		logger.Log.Errorf("Failed to execute command (%s): %v", cmd.String(), err)
-		logger.Log.Errorf("Error: %s", string(output))
-		return WorkloadResult(nil, string(output))
-	}
-	return WorkloadResult(string(output), nil)
+	return describeK8s("Node", "", name, contextId)
 }
@@ -2,7 +2,6 @@ package kubernetes
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/mogenius/punq/utils"
 
@@ -19,7 +18,7 @@ func AllPersistentVolumeClaims(namespaceName string, contextId *string) []core.P
 	if err != nil {
 		return result
 	}
-	pvList, err := provider.ClientSet.CoreV1().PersistentVolumeClaims(namespaceName).List(context.TODO(), metav1.ListOptions{})
+	pvList, err := cachedList(contextId, informerPersistentVolumeClaims, namespaceName, metav1.ListOptions{}, provider.ClientSet.CoreV1().PersistentVolumeClaims(namespaceName).List)
 	if err != nil {
 		logger.Log.Errorf("AllPersistentVolumeClaims ERROR: %s", err.Error())
 		return result
@@ -44,7 +43,7 @@ func AllK8sPersistentVolumeClaims(namespaceName string, contextId *string) utils
 	if err != nil {
 		return WorkloadResult(nil, err)
 	}
-	pvList, err := provider.ClientSet.CoreV1().PersistentVolumeClaims(namespaceName).List(context.TODO(), metav1.ListOptions{})
+	pvList, err := cachedList(contextId, informerPersistentVolumeClaims, namespaceName, metav1.ListOptions{}, provider.ClientSet.CoreV1().PersistentVolumeClaims(namespaceName).List)
 	if err != nil {
 		logger.Log.Errorf("AllPersistentVolumeClaims ERROR: %s", err.Error())
 		return WorkloadResult(nil, err)
@@ -90,15 +89,7 @@ func DeleteK8sPersistentVolumeClaimBy(namespace string, name string, contextId *
 }
 
 func DescribeK8sPersistentVolumeClaim(namespace string, name string, contextId *string) utils.K8sWorkloadResult {
-	cmd := utils.RunOnLocalShell(fmt.Sprintf("kubectl describe persistentvolumeclaim %s -n %s%s", name, namespace, ContextFlag(contextId)))
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		logger.Log.Errorf("Failed to execute command (%s): %v", cmd.String(), err)
-		logger.Log.Errorf("Error: %s", string(output))
-		return WorkloadResult(nil, string(output))
-	}
-	return WorkloadResult(string(output), nil)
+	return describeK8s("PersistentVolumeClaim", namespace, name, contextId)
 }
 
 func CreateK8sPersistentVolumeClaim(data core.PersistentVolumeClaim, contextId *string) utils.K8sWorkloadResult {
@@ -2,7 +2,6 @@ package kubernetes
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/mogenius/punq/utils"
 
@@ -90,15 +89,7 @@ func DeleteK8sPersistentVolumeBy(name string, contextId *string) error {
 }
 
 func DescribeK8sPersistentVolume(name string, contextId *string) utils.K8sWorkloadResult {
-	cmd := utils.RunOnLocalShell(fmt.Sprintf("kubectl describe persistentvolume %s%s", name, ContextFlag(contextId)))
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		logger.Log.Errorf("Failed to execute command (%s): %v", cmd.String(), err)
-		logger.Log.Errorf("Error: %s", string(output))
-		return WorkloadResult(nil, string(output))
-	}
-	return WorkloadResult(string(output), nil)
+	return describeK8s("PersistentVolume", "", name, contextId)
 }
 
 func CreateK8sPersistentVolume(data core.PersistentVolume, contextId *string) utils.K8sWorkloadResult {
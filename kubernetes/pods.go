@@ -3,7 +3,6 @@ package kubernetes
 import (
 	"bytes"
 	"context"
-	"fmt"
 	"os"
 	"sort"
 	"strings"
@@ -95,7 +94,7 @@ func ServicePodStatus(namespace string, serviceName string, contextId *string) [
 
 	podClient := provider.ClientSet.CoreV1().Pods(namespace)
 
-	pods, err := podClient.List(context.TODO(), metav1.ListOptions{})
+	pods, err := cachedList(contextId, informerPods, namespace, metav1.ListOptions{}, podClient.List)
 	if err != nil {
 		logger.Log.Errorf("ServicePodStatus Error: %s", err.Error())
 		return result
@@ -119,7 +118,7 @@ func GetFirstPodForLabelName(namespace string, labelName string, contextId *stri
 		return nil
 	}
 
-	pods, err := provider.ClientSet.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: labelName})
+	pods, err := cachedList(contextId, informerPods, namespace, metav1.ListOptions{LabelSelector: labelName}, provider.ClientSet.CoreV1().Pods(namespace).List)
 
 	for _, pod := range pods.Items {
 		return &pod
@@ -184,9 +183,9 @@ func AllPodsOnNode(nodeName string, contextId *string) []v1.Pod {
 		return result
 	}
 
-	podsList, err := provider.ClientSet.CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{
+	podsList, err := cachedList(contextId, informerPods, "", metav1.ListOptions{
 		FieldSelector: "spec.nodeName=" + nodeName,
-	})
+	}, provider.ClientSet.CoreV1().Pods("").List)
 	if err != nil {
 		logger.Log.Errorf("AllPodsOnNode ERROR: %s", err.Error())
 		return result
@@ -196,6 +195,23 @@ func AllPodsOnNode(nodeName string, contextId *string) []v1.Pod {
 	return result
 }
 
+// allScheduledPods lists the pods of all nodes at once.
+func allScheduledPods(contextId *string) []v1.Pod {
+	provider, err := NewKubeProvider(contextId)
+	if err != nil {
+		return []v1.Pod{}
+	}
+
+	podsList, err := cachedList(contextId, informerPods, "", metav1.ListOptions{
+		FieldSelector: "spec.nodeName!=",
+	}, provider.ClientSet.CoreV1().Pods("").List)
+	if err != nil {
+		logger.Log.Errorf("allScheduledPods ERROR: %s", err.Error())
+		return []v1.Pod{}
+	}
+	return podsList.Items
+}
+
 func AllPods(namespaceName string, contextId *string) []v1.Pod {
 	result := []v1.Pod{}
 
@@ -203,7 +219,7 @@ func AllPods(namespaceName string, contextId *string) []v1.Pod {
 	if err != nil {
 		return result
 	}
-	podsList, err := provider.ClientSet.CoreV1().Pods(namespaceName).List(context.TODO(), metav1.ListOptions{FieldSelector: "metadata.namespace!=kube-system"})
+	podsList, err := cachedList(contextId, informerPods, namespaceName, metav1.ListOptions{FieldSelector: "metadata.namespace!=kube-system"}, provider.ClientSet.CoreV1().Pods(namespaceName).List)
 	if err != nil {
 		logger.Log.Errorf("AllPods podMetricsList ERROR: %s", err.Error())
 		return result
@@ -308,15 +324,7 @@ func DeleteK8sPodBy(namespace string, name string, contextId *string) error {
 }
 
 func DescribeK8sPod(namespace string, name string, contextId *string) utils.K8sWorkloadResult {
-	cmd := utils.RunOnLocalShell(fmt.Sprintf("kubectl describe pod %s -n %s%s", name, namespace, ContextFlag(contextId)))
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		logger.Log.Errorf("Failed to execute command (%s): %v", cmd.String(), err)
-		logger.Log.Errorf("Error: %s", string(output))
-		return WorkloadResult(nil, string(output))
-	}
-	return WorkloadResult(string(output), nil)
+	return describeK8s("Pod", namespace, name, contextId)
 }
 
 func CreateK8sPod(data v1.Pod, contextId *string) utils.K8sWorkloadResult {
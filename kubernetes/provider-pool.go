@@ -0,0 +1,269 @@
+package kubernetes
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mogenius/punq/dtos"
+	"github.com/mogenius/punq/utils"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/transport"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+const (
+	ProviderPoolIdleTimeout   = 15 * time.Minute
+	providerPoolSweepInterval = time.Minute
+)
+
+// providerPool keeps one http client (TLS connections) and one rate limiter per context. Impersonated users get
+// their own clientsets on top of the shared transport, so all requests of a context share connections and QPS.
+type providerPool struct {
+	lock          sync.Mutex
+	contexts      map[string]*providerPoolContext // context id ("" = cluster punq runs in / default kubeconfig)
+	hits          uint64
+	misses        uint64
+	invalidations uint64
+	evictions     uint64
+	lastSweep     time.Time
+}
+
+type providerPoolContext struct {
+	baseConfig *rest.Config
+	httpClient *http.Client
+	identities map[string]*providerPoolEntry // "" = identity of the kubeconfig
+	hits       uint64
+	created    time.Time
+	lastUsed   time.Time
+}
+
+type providerPoolEntry struct {
+	config     *rest.Config
+	httpClient *http.Client
+	clientsets map[string]interface{} // client name -> typed clientset
+	lastUsed   time.Time
+}
+
+var kubeProviderPool = &providerPool{contexts: map[string]*providerPoolContext{}}
+
+// pooledClientset returns the clientset called name for the context (and the impersonated user of the request),
+// creating it with create on first use.
+func pooledClientset[T any](contextId *string, name string, create func(*rest.Config, *http.Client) (T, error)) (T, *rest.Config, error) {
+	var empty T
+
+	kubeProviderPool.lock.Lock()
+	defer kubeProviderPool.lock.Unlock()
+
+	entry, err := kubeProviderPool.entry(contextId)
+	if err != nil {
+		return empty, nil, err
+	}
+	if clientset, exists := entry.clientsets[name]; exists {
+		return clientset.(T), rest.CopyConfig(entry.config), nil
+	}
+	clientset, err := create(entry.config, entry.httpClient)
+	if err != nil {
+		return empty, nil, err
+	}
+	entry.clientsets[name] = clientset
+	return clientset, rest.CopyConfig(entry.config), nil
+}
+
+func providerPoolKey(contextId *string) string {
+	if contextId == nil {
+		return ""
+	}
+	return *contextId
+}
+
+func impersonationIdentity(impersonation *rest.ImpersonationConfig) string {
+	if impersonation == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s|%s|%v|%v", impersonation.UserName, impersonation.UID, impersonation.Groups, impersonation.Extra)
+}
+
+// entry must be called with p.lock held.
+func (p *providerPool) entry(contextId *string) (*providerPoolEntry, error) {
+	impersonation, err := impersonationFor(contextId)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	p.sweep(now)
+
+	key := providerPoolKey(contextId)
+	poolContext, exists := p.contexts[key]
+	if !exists {
+		var err error
+		poolContext, err = newProviderPoolContext(key)
+		if err != nil {
+			return nil, err
+		}
+		p.contexts[key] = poolContext
+	}
+	poolContext.lastUsed = now
+
+	identity := impersonationIdentity(impersonation)
+	entry, exists := poolContext.identities[identity]
+	if exists {
+		p.hits++
+		poolContext.hits++
+	} else {
+		p.misses++
+		entry = poolContext.newEntry(impersonation)
+		poolContext.identities[identity] = entry
+	}
+	entry.lastUsed = now
+	return entry, nil
+}
+
+func newProviderPoolContext(key string) (*providerPoolContext, error) {
+	var config *rest.Config
+	var err error
+	switch {
+	case key != "":
+		config, err = contextRestConfig(key)
+	case RunsInCluster:
+		config, err = rest.InClusterConfig()
+	default:
+		config, err = clientcmd.BuildConfigFromFlags("", utils.GetDefaultKubeConfig())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	config.QPS = utils.CONFIG.Kubernetes.ClientQps
+	config.Burst = utils.CONFIG.Kubernetes.ClientBurst
+	if config.QPS > 0 {
+		config.RateLimiter = flowcontrol.NewTokenBucketRateLimiter(config.QPS, config.Burst)
+	}
+
+	httpClient, err := rest.HTTPClientFor(config)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &providerPoolContext{
+		baseConfig: config,
+		httpClient: httpClient,
+		identities: map[string]*providerPoolEntry{},
+		created:    now,
+		lastUsed:   now,
+	}, nil
+}
+
+func (c *providerPoolContext) newEntry(impersonation *rest.ImpersonationConfig) *providerPoolEntry {
+	if impersonation == nil {
+		return &providerPoolEntry{config: c.baseConfig, httpClient: c.httpClient, clientsets: map[string]interface{}{}}
+	}
+
+	config := rest.CopyConfig(c.baseConfig)
+	config.Impersonate = *impersonation
+	baseTransport := c.httpClient.Transport
+	if baseTransport == nil {
+		// plain http without auth uses http.DefaultClient
+		baseTransport = http.DefaultTransport
+	}
+	impersonatingTransport := transport.NewImpersonatingRoundTripper(transport.ImpersonationConfig{
+		UserName: impersonation.UserName,
+		UID:      impersonation.UID,
+		Groups:   impersonation.Groups,
+		Extra:    impersonation.Extra,
+	}, baseTransport)
+
+	return &providerPoolEntry{
+		config:     config,
+		httpClient: &http.Client{Transport: impersonatingTransport, Timeout: c.httpClient.Timeout},
+		clientsets: map[string]interface{}{},
+	}
+}
+
+func (c *providerPoolContext) close() {
+	if c.httpClient.Transport != nil && c.httpClient.Transport != http.DefaultTransport {
+		utilnet.CloseIdleConnectionsFor(c.httpClient.Transport)
+	}
+}
+
+// sweep drops idle impersonated users and idle contexts. It must be called with p.lock held.
+func (p *providerPool) sweep(now time.Time) {
+	if now.Sub(p.lastSweep) < providerPoolSweepInterval {
+		return
+	}
+	p.lastSweep = now
+
+	for key, poolContext := range p.contexts {
+		if now.Sub(poolContext.lastUsed) > ProviderPoolIdleTimeout {
+			poolContext.close()
+			delete(p.contexts, key)
+			p.evictions++
+			continue
+		}
+		for identity, entry := range poolContext.identities {
+			if now.Sub(entry.lastUsed) > ProviderPoolIdleTimeout {
+				delete(poolContext.identities, identity)
+				p.evictions++
+			}
+		}
+	}
+}
+
+// invalidate drops all clients of a context (e.g. because its kubeconfig changed).
+func (p *providerPool) invalidate(contextId string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if poolContext, exists := p.contexts[contextId]; exists {
+		poolContext.close()
+		delete(p.contexts, contextId)
+		p.invalidations++
+	}
+}
+
+// ProviderPoolStats describes the pooled clients of this process.
+func ProviderPoolStats() dtos.KubeProviderPoolStats {
+	kubeProviderPool.lock.Lock()
+	defer kubeProviderPool.lock.Unlock()
+
+	stats := dtos.KubeProviderPoolStats{
+		Qps:           utils.CONFIG.Kubernetes.ClientQps,
+		Burst:         utils.CONFIG.Kubernetes.ClientBurst,
+		Hits:          kubeProviderPool.hits,
+		Misses:        kubeProviderPool.misses,
+		Invalidations: kubeProviderPool.invalidations,
+		Evictions:     kubeProviderPool.evictions,
+		Contexts:      []dtos.KubeProviderPoolContextStats{},
+	}
+	for key, poolContext := range kubeProviderPool.contexts {
+		clients := map[string]bool{}
+		for _, entry := range poolContext.identities {
+			for name := range entry.clientsets {
+				clients[name] = true
+			}
+		}
+		contextStats := dtos.KubeProviderPoolContextStats{
+			ContextId:  key,
+			Identities: len(poolContext.identities),
+			Clients:    []string{},
+			Hits:       poolContext.hits,
+			Created:    poolContext.created.Format(time.RFC3339),
+			LastUsed:   poolContext.lastUsed.Format(time.RFC3339),
+		}
+		for name := range clients {
+			contextStats.Clients = append(contextStats.Clients, name)
+		}
+		sort.Strings(contextStats.Clients)
+		stats.Contexts = append(stats.Contexts, contextStats)
+	}
+	sort.Slice(stats.Contexts, func(i, j int) bool {
+		return stats.Contexts[i].ContextId < stats.Contexts[j].ContextId
+	})
+	return stats
+}
@@ -0,0 +1,243 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mogenius/punq/dtos"
+	"github.com/mogenius/punq/utils"
+	core "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+const (
+	RemoteServiceAccountPrefix = "punq-remote"
+	RemoteServiceAccountLabel  = "punq.dev/remote-serviceaccount"
+	remoteTokenWait            = 30 * time.Second
+)
+
+func clientsetForKubeconfig(kubeconfig string) (*kubernetes.Clientset, *api.Config, error) {
+	config, err := clientcmd.Load([]byte(kubeconfig))
+	if err != nil {
+		return nil, nil, err
+	}
+	restConfig, err := clientcmd.NewDefaultClientConfig(*config, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	return clientset, config, nil
+}
+
+// RemoteServiceAccountName is the name of the ServiceAccount, its ClusterRoleBinding and its token secret. It is
+// unique per punq context, so several imports of the same cluster never share credentials or permissions.
+func RemoteServiceAccountName(contextId string) string {
+	return fmt.Sprintf("%s-%s", RemoteServiceAccountPrefix, strings.ToLower(contextId))
+}
+
+// MintServiceAccountContext uses the (admin) kubeconfig of ctx once to create a ServiceAccount of this punq context
+// bound to clusterRole plus a long-lived token in the target cluster. The returned context authenticates with this
+// token only.
+func MintServiceAccountContext(ctx dtos.PunqContext, clusterRole string) (*dtos.PunqContext, error) {
+	clientset, sourceConfig, err := clientsetForKubeconfig(ctx.Context)
+	if err != nil {
+		return nil, err
+	}
+	namespace := utils.CONFIG.Kubernetes.OwnNamespace
+	name := RemoteServiceAccountName(ctx.Id)
+	labels := map[string]string{RemoteServiceAccountLabel: "true"}
+
+	fmt.Printf("Creating %s/%s serviceaccount in '%s' ...\n", namespace, name, ctx.Name)
+	_, err = clientset.CoreV1().Namespaces().Create(context.TODO(), &core.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}, MoCreateOptions())
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return nil, err
+	}
+	// a failed mint must not leave a bound serviceaccount behind
+	defer func() {
+		if err != nil {
+			if cleanupErr := deleteRemoteServiceAccount(clientset, namespace, name); cleanupErr != nil {
+				fmt.Printf("Cleanup of %s/%s failed: %s\n", namespace, name, cleanupErr.Error())
+			}
+		}
+	}()
+
+	serviceAccount := &core.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels}}
+	_, err = clientset.CoreV1().ServiceAccounts(namespace).Create(context.TODO(), serviceAccount, MoCreateOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	binding := &rbac.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		RoleRef:    rbac.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: clusterRole},
+		Subjects:   []rbac.Subject{{Kind: rbac.ServiceAccountKind, Name: name, Namespace: namespace}},
+	}
+	_, err = clientset.RbacV1().ClusterRoleBindings().Create(context.TODO(), binding, MoCreateOptions())
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("Bound %s to clusterrole '%s'. ✅\n", name, clusterRole)
+
+	tokenSecret := &core.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Labels:      labels,
+			Annotations: map[string]string{core.ServiceAccountNameKey: name},
+		},
+		Type: core.SecretTypeServiceAccountToken,
+	}
+	_, err = clientset.CoreV1().Secrets(namespace).Create(context.TODO(), tokenSecret, MoCreateOptions())
+	if err != nil {
+		return nil, err
+	}
+	token, caData, err := waitForServiceAccountToken(clientset, namespace, tokenSecret.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	kubeconfig, err := serviceAccountKubeconfig(sourceConfig, ctx.Name, name, token, caData)
+	if err != nil {
+		return nil, err
+	}
+
+	minted := dtos.CreateContext(ctx.Id, ctx.Name, kubeconfig, ctx.Provider, ctx.AccessLevel)
+	minted.Reachable = ctx.Reachable
+	minted.RemoteServiceAccount = fmt.Sprintf("%s/%s", namespace, name)
+	fmt.Printf("Created token for %s in '%s'. ✅\n", name, ctx.Name)
+	return &minted, nil
+}
+
+func waitForServiceAccountToken(clientset *kubernetes.Clientset, namespace string, name string) (string, []byte, error) {
+	deadline := time.Now().Add(remoteTokenWait)
+	for time.Now().Before(deadline) {
+		secret, err := clientset.CoreV1().Secrets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err == nil && len(secret.Data[core.ServiceAccountTokenKey]) > 0 {
+			return string(secret.Data[core.ServiceAccountTokenKey]), secret.Data[core.ServiceAccountRootCAKey], nil
+		}
+		time.Sleep(time.Second)
+	}
+	return "", nil, fmt.Errorf("token of secret '%s/%s' has not been populated within %s", namespace, name, remoteTokenWait)
+}
+
+// serviceAccountKubeconfig builds a single-context kubeconfig with the server of the source context and the token.
+func serviceAccountKubeconfig(sourceConfig *api.Config, contextName string, userName string, token string, caData []byte) (string, error) {
+	sourceContext, exists := sourceConfig.Contexts[sourceConfig.CurrentContext]
+	if !exists {
+		return "", fmt.Errorf("current context not found in source kubeconfig")
+	}
+	sourceCluster, exists := sourceConfig.Clusters[sourceContext.Cluster]
+	if !exists {
+		return "", fmt.Errorf("cluster '%s' not found in source kubeconfig", sourceContext.Cluster)
+	}
+
+	cluster := api.NewCluster()
+	cluster.Server = sourceCluster.Server
+	cluster.TLSServerName = sourceCluster.TLSServerName
+	cluster.InsecureSkipTLSVerify = sourceCluster.InsecureSkipTLSVerify
+	cluster.CertificateAuthorityData = sourceCluster.CertificateAuthorityData
+	if len(cluster.CertificateAuthorityData) == 0 && !cluster.InsecureSkipTLSVerify {
+		cluster.CertificateAuthorityData = caData
+	}
+
+	authInfo := api.NewAuthInfo()
+	authInfo.Token = token
+
+	kubeContext := api.NewContext()
+	kubeContext.Cluster = contextName
+	kubeContext.AuthInfo = userName
+
+	config := api.NewConfig()
+	config.CurrentContext = contextName
+	config.Clusters[contextName] = cluster
+	config.AuthInfos[userName] = authInfo
+	config.Contexts[contextName] = kubeContext
+
+	data, err := clientcmd.Write(*config)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// RevokeServiceAccountContext deletes the ClusterRoleBinding, the ServiceAccount and the token of a minted context (in
+// this order, so the permissions are gone even if a later step fails). The minted token cannot delete its own
+// binding, so this needs the kubeconfig of a cluster admin for the same cluster.
+func RevokeServiceAccountContext(ctx dtos.PunqContext, adminKubeconfig string) error {
+	namespace, name, found := strings.Cut(ctx.RemoteServiceAccount, "/")
+	if !found || name != RemoteServiceAccountName(ctx.Id) {
+		return fmt.Errorf("malformed remote serviceaccount '%s'", ctx.RemoteServiceAccount)
+	}
+	clientset, adminConfig, err := clientsetForKubeconfig(adminKubeconfig)
+	if err != nil {
+		return err
+	}
+	if err := requireSameCluster(ctx.Context, adminConfig); err != nil {
+		return err
+	}
+
+	if err := deleteRemoteServiceAccount(clientset, namespace, name); err != nil {
+		return err
+	}
+	fmt.Printf("Revoked %s in '%s'. ✅\n", ctx.RemoteServiceAccount, ctx.Name)
+	return nil
+}
+
+func deleteRemoteServiceAccount(clientset kubernetes.Interface, namespace string, name string) error {
+	err := clientset.RbacV1().ClusterRoleBindings().Delete(context.TODO(), name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete clusterrolebinding '%s': %s", name, err.Error())
+	}
+	err = clientset.CoreV1().ServiceAccounts(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete serviceaccount '%s/%s': %s", namespace, name, err.Error())
+	}
+	err = clientset.CoreV1().Secrets(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete token secret '%s/%s': %s", namespace, name, err.Error())
+	}
+	return nil
+}
+
+// requireSameCluster compares the api servers, a kubeconfig of another cluster would delete nothing (or the wrong
+// objects) and report success.
+func requireSameCluster(mintedKubeconfig string, adminConfig *api.Config) error {
+	mintedConfig, err := clientcmd.Load([]byte(mintedKubeconfig))
+	if err != nil {
+		return err
+	}
+	mintedServer, err := currentServer(mintedConfig)
+	if err != nil {
+		return err
+	}
+	adminServer, err := currentServer(adminConfig)
+	if err != nil {
+		return err
+	}
+	if mintedServer != adminServer {
+		return fmt.Errorf("the admin kubeconfig points to '%s', the context to '%s'", adminServer, mintedServer)
+	}
+	return nil
+}
+
+func currentServer(config *api.Config) (string, error) {
+	kubeContext, exists := config.Contexts[config.CurrentContext]
+	if !exists {
+		return "", fmt.Errorf("current context not found in kubeconfig")
+	}
+	cluster, exists := config.Clusters[kubeContext.Cluster]
+	if !exists {
+		return "", fmt.Errorf("cluster '%s' not found in kubeconfig", kubeContext.Cluster)
+	}
+	return strings.TrimSuffix(cluster.Server, "/"), nil
+}
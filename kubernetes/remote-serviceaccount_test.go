@@ -0,0 +1,86 @@
+package kubernetes
+
+import (
+	"testing"
+
+	core "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestDeleteRemoteServiceAccountRevokesPermissionsFirst(t *testing.T) {
+	name := RemoteServiceAccountName("AbC")
+	clientset := fake.NewSimpleClientset(
+		&rbac.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: name}},
+		&core.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "punq"}},
+		&core.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "punq"}},
+	)
+	deleted := []string{}
+	clientset.PrependReactor("delete", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		deleted = append(deleted, action.GetResource().Resource)
+		return false, nil, nil
+	})
+
+	if err := deleteRemoteServiceAccount(clientset, "punq", name); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"clusterrolebindings", "serviceaccounts", "secrets"}
+	if len(deleted) != len(want) {
+		t.Fatalf("deleted %v, want %v", deleted, want)
+	}
+	for i := range want {
+		if deleted[i] != want[i] {
+			t.Fatalf("deleted %v, want %v", deleted, want)
+		}
+	}
+
+	// a second revocation finds nothing and succeeds
+	if err := deleteRemoteServiceAccount(clientset, "punq", name); err != nil {
+		t.Errorf("revoking twice failed: %s", err.Error())
+	}
+}
+
+func TestDeleteRemoteServiceAccountReportsFailure(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	clientset.PrependReactor("delete", "clusterrolebindings", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(rbac.Resource("clusterrolebindings"), "punq-remote-abc", nil)
+	})
+	if err := deleteRemoteServiceAccount(clientset, "punq", RemoteServiceAccountName("abc")); err == nil {
+		t.Error("a forbidden delete has been reported as success")
+	}
+}
+
+func TestRequireSameCluster(t *testing.T) {
+	kubeconfig := func(server string) *api.Config {
+		config := api.NewConfig()
+		config.Clusters["c"] = &api.Cluster{Server: server}
+		config.AuthInfos["u"] = &api.AuthInfo{Token: "t"}
+		config.Contexts["ctx"] = &api.Context{Cluster: "c", AuthInfo: "u"}
+		config.CurrentContext = "ctx"
+		return config
+	}
+	minted, err := serviceAccountKubeconfig(kubeconfig("https://a.example:6443"), "ctx", RemoteServiceAccountName("abc"), "token", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		server  string
+		wantErr bool
+	}{
+		{"https://a.example:6443", false},
+		{"https://a.example:6443/", false},
+		{"https://b.example:6443", true},
+	}
+	for _, test := range tests {
+		err := requireSameCluster(minted, kubeconfig(test.server))
+		if (err != nil) != test.wantErr {
+			t.Errorf("requireSameCluster(%s) = %v, wantErr %v", test.server, err, test.wantErr)
+		}
+	}
+}
@@ -2,7 +2,6 @@ package kubernetes
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/mogenius/punq/utils"
 
@@ -19,7 +18,7 @@ func AllReplicasets(namespaceName string, contextId *string) []v1.ReplicaSet {
 	if err != nil {
 		return result
 	}
-	replicaSetList, err := provider.ClientSet.AppsV1().ReplicaSets(namespaceName).List(context.TODO(), metav1.ListOptions{FieldSelector: "metadata.namespace!=kube-system"})
+	replicaSetList, err := cachedList(contextId, informerReplicaSets, namespaceName, metav1.ListOptions{FieldSelector: "metadata.namespace!=kube-system"}, provider.ClientSet.AppsV1().ReplicaSets(namespaceName).List)
 	if err != nil {
 		logger.Log.Errorf("AllReplicasets ERROR: %s", err.Error())
 		return result
@@ -48,7 +47,7 @@ func AllK8sReplicasets(namespaceName string, contextId *string) utils.K8sWorkloa
 	if err != nil {
 		return WorkloadResult(nil, err)
 	}
-	replicaSetList, err := provider.ClientSet.AppsV1().ReplicaSets(namespaceName).List(context.TODO(), metav1.ListOptions{FieldSelector: "metadata.namespace!=kube-system"})
+	replicaSetList, err := cachedList(contextId, informerReplicaSets, namespaceName, metav1.ListOptions{FieldSelector: "metadata.namespace!=kube-system"}, provider.ClientSet.AppsV1().ReplicaSets(namespaceName).List)
 	if err != nil {
 		logger.Log.Errorf("AllReplicasets ERROR: %s", err.Error())
 		return WorkloadResult(nil, err)
@@ -98,15 +97,7 @@ func DeleteK8sReplicasetBy(namespace string, name string, contextId *string) err
 }
 
 func DescribeK8sReplicaset(namespace string, name string, contextId *string) utils.K8sWorkloadResult {
-	cmd := utils.RunOnLocalShell(fmt.Sprintf("kubectl describe replicaset %s -n %s%s", name, namespace, ContextFlag(contextId)))
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		logger.Log.Errorf("Failed to execute command (%s): %v", cmd.String(), err)
-		logger.Log.Errorf("Error: %s", string(output))
-		return WorkloadResult(nil, string(output))
-	}
-	return WorkloadResult(string(output), nil)
+	return describeK8s("ReplicaSet", namespace, name, contextId)
 }
 
 func CreateK8sReplicaSet(data v1.ReplicaSet, contextId *string) utils.K8sWorkloadResult {
@@ -2,7 +2,6 @@ package kubernetes
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/mogenius/punq/logger"
 	"github.com/mogenius/punq/utils"
@@ -77,15 +76,7 @@ func DeleteK8sRoleBindingBy(namespace string, name string, contextId *string) er
 }
 
 func DescribeK8sRoleBinding(namespace string, name string, contextId *string) utils.K8sWorkloadResult {
-	cmd := utils.RunOnLocalShell(fmt.Sprintf("kubectl describe rolebinding %s -n %s%s", name, namespace, ContextFlag(contextId)))
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		logger.Log.Errorf("Failed to execute command (%s): %v", cmd.String(), err)
-		logger.Log.Errorf("Error: %s", string(output))
-		return WorkloadResult(nil, string(output))
-	}
-	return WorkloadResult(string(output), nil)
+	return describeK8s("RoleBinding", namespace, name, contextId)
 }
 
 func CreateK8sRoleBinding(data v1.RoleBinding, contextId *string) utils.K8sWorkloadResult {
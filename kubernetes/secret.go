@@ -2,11 +2,7 @@ package kubernetes
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"sort"
 
-	"github.com/mogenius/punq/dtos"
 	"github.com/mogenius/punq/logger"
 	"github.com/mogenius/punq/utils"
 
@@ -57,30 +53,6 @@ func GetSecret(namespace string, name string, contextId *string) (*v1.Secret, er
 	return secretClient.Get(context.TODO(), name, metav1.GetOptions{})
 }
 
-func ListAllContexts() []dtos.PunqContext {
-	contexts := []dtos.PunqContext{}
-
-	secret := SecretFor(utils.CONFIG.Kubernetes.OwnNamespace, utils.CONTEXTSSECRET, nil)
-	if secret == nil {
-		logger.Log.Errorf("Failed to get '%s/%s' secret.", utils.CONFIG.Kubernetes.OwnNamespace, utils.CONTEXTSSECRET)
-		return contexts
-	}
-
-	for ctxId, contextRaw := range secret.Data {
-		ctx := dtos.PunqContext{}
-		err := json.Unmarshal(contextRaw, &ctx)
-		if err != nil {
-			logger.Log.Error("Failed to Unmarshal context '%s'.", ctxId)
-		}
-		contexts = append(contexts, ctx)
-	}
-
-	sort.Slice(contexts, func(i, j int) bool {
-		return contexts[i].Name < contexts[j].Name
-	})
-	return contexts
-}
-
 func AllK8sSecrets(namespaceName string, contextId *string) utils.K8sWorkloadResult {
 	result := []v1.Secret{}
 
@@ -138,15 +110,7 @@ func DeleteK8sSecretBy(namespace string, name string, contextId *string) error {
 }
 
 func DescribeK8sSecret(namespace string, name string, contextId *string) utils.K8sWorkloadResult {
-	cmd := utils.RunOnLocalShell(fmt.Sprintf("kubectl describe secret %s -n %s%s", name, namespace, ContextFlag(contextId)))
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		logger.Log.Errorf("Failed to execute command (%s): %v", cmd.String(), err)
-		logger.Log.Errorf("Error: %s", string(output))
-		return WorkloadResult(nil, string(output))
-	}
-	return WorkloadResult(string(output), nil)
+	return describeK8s("Secret", namespace, name, contextId)
 }
 
 func CreateK8sSecret(data v1.Secret, contextId *string) utils.K8sWorkloadResult {
@@ -2,7 +2,6 @@ package kubernetes
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/mogenius/punq/utils"
 
@@ -78,15 +77,7 @@ func DeleteK8sServiceAccountBy(namespace string, name string, contextId *string)
 }
 
 func DescribeK8sServiceAccount(namespace string, name string, contextId *string) utils.K8sWorkloadResult {
-	cmd := utils.RunOnLocalShell(fmt.Sprintf("kubectl describe serviceaccount %s -n %s%s", name, namespace, ContextFlag(contextId)))
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		logger.Log.Errorf("Failed to execute command (%s): %v", cmd.String(), err)
-		logger.Log.Errorf("Error: %s", string(output))
-		return WorkloadResult(nil, string(output))
-	}
-	return WorkloadResult(string(output), nil)
+	return describeK8s("ServiceAccount", namespace, name, contextId)
 }
 
 func CreateK8sServiceAccount(data v1.ServiceAccount, contextId *string) utils.K8sWorkloadResult {
@@ -2,7 +2,6 @@ package kubernetes
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/mogenius/punq/utils"
 
@@ -54,7 +53,7 @@ func AllServices(namespaceName string, contextId *string) []v1.Service {
 	if err != nil {
 		return result
 	}
-	serviceList, err := provider.ClientSet.CoreV1().Services(namespaceName).List(context.TODO(), metav1.ListOptions{FieldSelector: "metadata.namespace!=kube-system"})
+	serviceList, err := cachedList(contextId, informerServices, namespaceName, metav1.ListOptions{FieldSelector: "metadata.namespace!=kube-system"}, provider.ClientSet.CoreV1().Services(namespaceName).List)
 	if err != nil {
 		logger.Log.Errorf("AllServices ERROR: %s", err.Error())
 		return result
@@ -109,15 +108,7 @@ func DeleteK8sServiceBy(namespace string, name string, contextId *string) error
 }
 
 func DescribeK8sService(namespace string, name string, contextId *string) utils.K8sWorkloadResult {
-	cmd := utils.RunOnLocalShell(fmt.Sprintf("kubectl describe service %s -n %s%s", name, namespace, ContextFlag(contextId)))
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		logger.Log.Errorf("Failed to execute command (%s): %v", cmd.String(), err)
-		logger.Log.Errorf("Error: %s", string(output))
-		return WorkloadResult(nil, string(output))
-	}
-	return WorkloadResult(string(output), nil)
+	return describeK8s("Service", namespace, name, contextId)
 }
 
 func CreateK8sService(data v1.Service, contextId *string) utils.K8sWorkloadResult {
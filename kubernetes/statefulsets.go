@@ -2,7 +2,6 @@ package kubernetes
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/mogenius/punq/utils"
 
@@ -19,7 +18,7 @@ func AllStatefulSets(namespaceName string, contextId *string) utils.K8sWorkloadR
 	if err != nil {
 		return WorkloadResult(nil, err)
 	}
-	statefulSetList, err := provider.ClientSet.AppsV1().StatefulSets(namespaceName).List(context.TODO(), metav1.ListOptions{FieldSelector: "metadata.namespace!=kube-system"})
+	statefulSetList, err := cachedList(contextId, informerStatefulSets, namespaceName, metav1.ListOptions{FieldSelector: "metadata.namespace!=kube-system"}, provider.ClientSet.AppsV1().StatefulSets(namespaceName).List)
 	if err != nil {
 		logger.Log.Errorf("AllStatefulSets ERROR: %s", err.Error())
 		return WorkloadResult(nil, err)
@@ -77,15 +76,7 @@ func DeleteK8sStatefulsetBy(namespace string, name string, contextId *string) er
 }
 
 func DescribeK8sStatefulset(namespace string, name string, contextId *string) utils.K8sWorkloadResult {
-	cmd := utils.RunOnLocalShell(fmt.Sprintf("kubectl describe statefulset %s -n %s%s", name, namespace, ContextFlag(contextId)))
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		logger.Log.Errorf("Failed to execute command (%s): %v", cmd.String(), err)
-		logger.Log.Errorf("Error: %s", string(output))
-		return WorkloadResult(nil, string(output))
-	}
-	return WorkloadResult(string(output), nil)
+	return describeK8s("StatefulSet", namespace, name, contextId)
 }
 
 func CreateK8sStatefulset(data v1.StatefulSet, contextId *string) utils.K8sWorkloadResult {
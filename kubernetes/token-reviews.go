@@ -0,0 +1,27 @@
+package kubernetes
+
+import (
+	"context"
+
+	authv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ReviewToken asks the api server of the given context whether a bearer token (e.g. a projected ServiceAccount
+// token) is valid. Empty audiences default to the audience of the api server.
+func ReviewToken(token string, audiences []string, contextId *string) (*authv1.TokenReviewStatus, error) {
+	provider, err := NewKubeProvider(contextId)
+	if err != nil {
+		return nil, err
+	}
+	review, err := provider.ClientSet.AuthenticationV1().TokenReviews().Create(context.TODO(), &authv1.TokenReview{
+		Spec: authv1.TokenReviewSpec{
+			Token:     token,
+			Audiences: audiences,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &review.Status, nil
+}
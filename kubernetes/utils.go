@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"gopkg.in/yaml.v2"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	version2 "k8s.io/apimachinery/pkg/version"
 	v1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
@@ -218,6 +219,15 @@ func WorkloadsForAccesslevel(access dtos.AccessLevel) []string {
 }
 
 func WorkloadResult(result interface{}, err interface{}) utils.K8sWorkloadResult {
+	// keep the status of api server errors (e.g. 403 of impersonated requests) instead of the raw error object
+	if statusErr, ok := err.(apierrors.APIStatus); ok {
+		status := statusErr.Status()
+		return utils.K8sWorkloadResult{
+			Result:     result,
+			Error:      status.Message,
+			StatusCode: int(status.Code),
+		}
+	}
 	if fmt.Sprint(reflect.TypeOf(err)) == "*errors.errorString" {
 		err = err.(error).Error()
 	}
@@ -348,7 +358,7 @@ func listAllPods(contextId *string) []v1.Pod {
 	if err != nil {
 		return result
 	}
-	pods, err := provider.ClientSet.CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{FieldSelector: "metadata.namespace!=kube-system,metadata.namespace!=default"})
+	pods, err := cachedList(contextId, informerPods, "", metav1.ListOptions{FieldSelector: "metadata.namespace!=kube-system,metadata.namespace!=default"}, provider.ClientSet.CoreV1().Pods("").List)
 
 	if err != nil {
 		logger.Log.Error("Error listAllPods:", err)
@@ -364,7 +374,7 @@ func ListNodes(contextId *string) []v1.Node {
 		return []v1.Node{}
 	}
 
-	nodeMetricsList, err := provider.ClientSet.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	nodeMetricsList, err := cachedList(contextId, informerNodes, "", metav1.ListOptions{}, provider.ClientSet.CoreV1().Nodes().List)
 	if err != nil {
 		logger.Log.Errorf("ListNodeMetrics ERROR: %s", err.Error())
 		return []v1.Node{}
@@ -644,7 +654,7 @@ func GuessClusterProvider(contextId *string) (dtos.KubernetesProvider, error) {
 		return dtos.SELF_HOSTED, err
 	}
 
-	nodes, err := provider.ClientSet.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	nodes, err := cachedList(contextId, informerNodes, "", metav1.ListOptions{}, provider.ClientSet.CoreV1().Nodes().List)
 	if err != nil {
 		return dtos.SELF_HOSTED, err
 	}
@@ -63,15 +63,7 @@ func DeleteK8sVolumeSnapshotBy(namespace string, name string, contextId *string)
 }
 
 func DescribeK8sVolumeSnapshot(namespace string, name string, contextId *string) utils.K8sWorkloadResult {
-	cmd := utils.RunOnLocalShell(fmt.Sprintf("kubectl describe volumesnapshots %s -n %s%s", name, namespace, ContextFlag(contextId)))
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		logger.Log.Errorf("Failed to execute command (%s): %v", cmd.String(), err)
-		logger.Log.Errorf("Error: %s", string(output))
-		return WorkloadResult(nil, string(output))
-	}
-	return WorkloadResult(string(output), nil)
+	return describeK8s("VolumeSnapshot", namespace, name, contextId)
 }
 
 func CreateK8sVolumeSnapshot(data snap.VolumeSnapshot, contextId *string) utils.K8sWorkloadResult {
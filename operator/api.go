@@ -49,7 +49,9 @@ func InitBackend() {
 	config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "authorization", "x-context-id"}
 
 	router.Use(cors.New(config))
+	trustProxies(router)
 	router.Use(CreateLogger("BACKEND"))
+	router.Use(AuditLog())
 
 	docs.SwaggerInfo.BasePath = "/"
 	docs.SwaggerInfo.Title = "punq API documentation"
@@ -60,8 +62,10 @@ func InitBackend() {
 	InitContextRoutes(router)
 	InitAuthRoutes(router)
 	InitUserRoutes(router)
+	InitGroupRoutes(router)
 	InitGeneralRoutes(router)
 	InitWorkloadRoutes(router)
+	InitAuditRoutes(router)
 
 	utils.PrintInfo(fmt.Sprintf("Backend started:   http://%s:%d", utils.CONFIG.Backend.Host, utils.CONFIG.Backend.Port))
 	err := router.Run(fmt.Sprintf(":%d", utils.CONFIG.Backend.Port))
@@ -75,6 +79,7 @@ func InitWebsocket() {
 	config.AllowAllOrigins = true
 
 	router.Use(cors.New(config))
+	trustProxies(router)
 	router.Use(CreateLogger("WEBSOCK"))
 
 	InitWebsocketRoutes(router)
@@ -84,6 +89,21 @@ func InitWebsocket() {
 	logger.Log.Errorf("Websocket (gin) stopped with error: %s", err.Error())
 }
 
+// trustProxies makes c.ClientIP() (login lockout, sessions, audit log) honor X-Forwarded-For only for requests of
+// the configured proxies. Without proxies it is the remote address of the connection.
+func trustProxies(router *gin.Engine) {
+	var proxies []string
+	for _, proxy := range utils.CONFIG.Backend.TrustedProxies {
+		if proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	err := router.SetTrustedProxies(proxies)
+	if err != nil {
+		logger.Log.Fatalf("Invalid backend.trusted_proxies: %s", err.Error())
+	}
+}
+
 func embedFs() http.FileSystem {
 	sub, err := fs.Sub(HtmlDirFs, "ui/dist")
 	if err != nil {
@@ -0,0 +1,43 @@
+package operator
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mogenius/punq/utils"
+)
+
+func TestTrustProxies(t *testing.T) {
+	tests := []struct {
+		name       string
+		proxies    []string
+		remoteAddr string
+		want       string
+	}{
+		{"no proxies", nil, "203.0.113.7:1234", "203.0.113.7"},
+		{"empty entry", []string{""}, "203.0.113.7:1234", "203.0.113.7"},
+		{"untrusted proxy", []string{"10.0.0.0/8"}, "203.0.113.7:1234", "203.0.113.7"},
+		{"trusted proxy", []string{"10.0.0.0/8"}, "10.1.2.3:1234", "198.51.100.1"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			utils.CONFIG.Backend.TrustedProxies = test.proxies
+			t.Cleanup(func() { utils.CONFIG.Backend.TrustedProxies = nil })
+
+			router := gin.New()
+			trustProxies(router)
+			clientIp := ""
+			router.GET("/", func(c *gin.Context) { clientIp = c.ClientIP() })
+
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			request.RemoteAddr = test.remoteAddr
+			request.Header.Set("X-Forwarded-For", "198.51.100.1")
+			router.ServeHTTP(httptest.NewRecorder(), request)
+			if clientIp != test.want {
+				t.Errorf("ClientIP() = %s, want %s", clientIp, test.want)
+			}
+		})
+	}
+}
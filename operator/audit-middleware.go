@@ -0,0 +1,161 @@
+package operator
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/mogenius/punq/dtos"
+	"github.com/mogenius/punq/services"
+)
+
+// AuditLog records every mutating api call (POST, PATCH, PUT, DELETE). Token endpoints of /auth are skipped.
+func AuditLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		method := c.Request.Method
+		if method != http.MethodPost && method != http.MethodPatch && method != http.MethodPut && method != http.MethodDelete {
+			c.Next()
+			return
+		}
+		if strings.HasPrefix(c.Request.URL.Path, "/auth/") {
+			c.Next()
+			return
+		}
+
+		var body []byte
+		if c.Request.Body != nil {
+			body, _ = io.ReadAll(c.Request.Body)
+			// restore body for the handler
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		c.Next()
+
+		entry := dtos.PunqAuditEntry{
+			ClientIp: c.ClientIP(),
+			Verb:     method,
+			Path:     c.Request.URL.Path,
+			Kind:     auditKind(c.FullPath()),
+			Status:   c.Writer.Status(),
+			Result:   dtos.AuditResultForStatus(c.Writer.Status()),
+		}
+		if contextId := services.GetGinContextId(c); contextId != nil {
+			entry.ContextId = *contextId
+		}
+		if user := ginContextUser(c); user != nil {
+			entry.UserId = user.Id
+			entry.UserEmail = user.Email
+		}
+		if token := ginContextApiToken(c); token != nil {
+			entry.ApiTokenId = token.Id
+		}
+
+		entry.Namespace = c.Param("namespace")
+		if entry.Namespace == "" {
+			entry.Namespace = c.Query("namespace")
+		}
+		// generic workload routes: e.g. "rollouts.argoproj.io" instead of "generic"
+		if temp, exists := c.Get("genericResource"); exists {
+			entry.Kind = temp.(dtos.GenericResource).FullName()
+		}
+		entry.Name = c.Param("name")
+		if entry.Name == "" {
+			entry.Name = c.Param("id")
+		}
+		if len(body) > 0 {
+			hash := sha256.Sum256(body)
+			entry.BodyHash = hex.EncodeToString(hash[:])
+
+			object := metav1.PartialObjectMetadata{}
+			if yaml.NewYAMLOrJSONDecoder(bytes.NewReader(body), 4096).Decode(&object) == nil {
+				if entry.Namespace == "" {
+					entry.Namespace = object.Namespace
+				}
+				if entry.Name == "" {
+					entry.Name = object.Name
+				}
+			}
+		}
+
+		services.RecordAudit(entry)
+	}
+}
+
+// recordExecSession records a finished /exec-sh session.
+func recordExecSession(c *gin.Context, contextId string, namespace string, podName string, container string, status int, duration time.Duration) {
+	entry := dtos.PunqAuditEntry{
+		ClientIp:    c.ClientIP(),
+		ContextId:   contextId,
+		Verb:        "EXEC",
+		Path:        c.Request.URL.Path,
+		Kind:        "pod",
+		Namespace:   namespace,
+		Name:        podName,
+		Subresource: "exec/" + container,
+		Status:      status,
+		Result:      dtos.AuditResultForStatus(status),
+		Duration:    duration.Round(time.Second).String(),
+	}
+	if user := ginContextUser(c); user != nil {
+		entry.UserId = user.Id
+		entry.UserEmail = user.Email
+	}
+	services.RecordAudit(entry)
+}
+
+// recordKubeconfigExport records that the credentials of a context were handed out via GET /context/kubeconfig.
+func recordKubeconfigExport(c *gin.Context, contextId string) {
+	entry := dtos.PunqAuditEntry{
+		ClientIp:  c.ClientIP(),
+		ContextId: contextId,
+		Verb:      "EXPORT",
+		Path:      c.Request.URL.Path,
+		Kind:      "kubeconfig",
+		Status:    http.StatusOK,
+		Result:    dtos.AuditResultSuccess,
+	}
+	if user := ginContextUser(c); user != nil {
+		entry.UserId = user.Id
+		entry.UserEmail = user.Email
+	}
+	if token := ginContextApiToken(c); token != nil {
+		entry.ApiTokenId = token.Id
+	}
+	services.RecordAudit(entry)
+}
+
+// auditKind derives the resource kind from the route, e.g. /workload/pod/:namespace/:name -> pod.
+func auditKind(fullPath string) string {
+	segments := strings.Split(strings.Trim(fullPath, "/"), "/")
+	if len(segments) > 1 && segments[0] == "workload" {
+		return segments[1]
+	}
+	return segments[0]
+}
+
+// the getters of services write an error response on type mismatches, which must not happen after the handler
+func ginContextUser(c *gin.Context) *dtos.PunqUser {
+	if temp, exists := c.Get("user"); exists {
+		if user, ok := temp.(dtos.PunqUser); ok {
+			return &user
+		}
+	}
+	return nil
+}
+
+func ginContextApiToken(c *gin.Context) *dtos.PunqApiToken {
+	if temp, exists := c.Get("apiToken"); exists {
+		if token, ok := temp.(dtos.PunqApiToken); ok {
+			return &token
+		}
+	}
+	return nil
+}
@@ -1,15 +1,21 @@
 package operator
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"regexp"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/mogenius/punq/dtos"
 	"github.com/mogenius/punq/logger"
 	"github.com/mogenius/punq/services"
 	"github.com/mogenius/punq/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
 )
 
 // var users []dtos.PunqUser = []dtos.PunqUser{}
@@ -48,6 +54,11 @@ func Auth(requiredAccessLevel dtos.AccessLevel) gin.HandlerFunc {
 		}
 
 		if isAuthorized {
+			if isContextRoute(c) {
+				// contexts with impersonation use the identity of the user for all cluster requests
+				release := services.StartGinImpersonation(c, services.GetGinContextUser(c))
+				defer release()
+			}
 			c.Next()
 		}
 	}
@@ -87,10 +98,16 @@ func CheckUserAuthorization(c *gin.Context) (*dtos.PunqUser, error) {
 		return nil, err
 	}
 
+	if strings.EqualFold(authorization.Scheme, services.ApiTokenScheme) {
+		return checkApiTokenAuthorization(c, authorization.Value)
+	}
+
 	claims, err := services.ValidationToken(authorization.Value)
 	if err != nil {
 		return nil, err
 	}
+	c.Set("claims", *claims)
+	services.TouchSession(claims.SessionId)
 	userId := claims.UserID
 
 	// updateLocalUserStore()
@@ -107,6 +124,31 @@ func CheckUserAuthorization(c *gin.Context) (*dtos.PunqUser, error) {
 	return user, nil
 }
 
+// checkApiTokenAuthorization validates a personal access token and its context/namespace/verb restrictions.
+// Context-restricted tokens are rejected on all routes which are no routes of a context (see RequireContextId),
+// these routes use the global AccessLevel of the user whatever X-Context-Id says.
+func checkApiTokenAuthorization(c *gin.Context, plainToken string) (*dtos.PunqUser, error) {
+	apiToken, user, err := services.ValidateApiToken(plainToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if !apiToken.AllowsVerb(c.Request.Method) {
+		return nil, fmt.Errorf("api token '%s' does not allow %s requests", apiToken.Name, c.Request.Method)
+	}
+	var contextId *string
+	if isContextRoute(c) {
+		contextId = services.GetGinContextId(c)
+	}
+	if !apiToken.AllowsContext(contextId) {
+		return nil, fmt.Errorf("api token '%s' is not allowed for this context", apiToken.Name)
+	}
+	// the namespace restriction is checked by hasSufficientScopedAccess (the namespace depends on the route)
+
+	c.Set("apiToken", *apiToken)
+	return user, nil
+}
+
 func CheckUserAuthorizationByParameter(c *gin.Context) (*dtos.PunqUser, error) {
 	token, tokenOk := c.GetQuery("token")
 	if !tokenOk || token == "" {
@@ -117,6 +159,8 @@ func CheckUserAuthorizationByParameter(c *gin.Context) (*dtos.PunqUser, error) {
 	if err != nil {
 		return nil, err
 	}
+	c.Set("claims", *claims)
+	services.TouchSession(claims.SessionId)
 	userId := claims.UserID
 
 	getGinContextUser := services.GetGinContextUser(c)
@@ -136,14 +180,12 @@ func HasSufficientAccess(c *gin.Context, requiredAccessLevel dtos.AccessLevel) (
 	if err != nil {
 		return false, err
 	}
-	if user != nil {
-		if user.AccessLevel >= requiredAccessLevel {
-			c.Set("user", *user)
-			return true, err
-		}
+	// role bindings only apply to routes of a context (see RequireContextId), all other routes use the global AccessLevel
+	var contextId *string
+	if isContextRoute(c) {
+		contextId = services.GetGinContextId(c)
 	}
-	errStr := fmt.Sprintf("AccessLevel is insufficient (Current:%d - Required:%d).", user.AccessLevel, requiredAccessLevel)
-	return false, fmt.Errorf(errStr)
+	return hasSufficientScopedAccess(c, user, contextId, requiredAccessLevel)
 }
 
 func HasSufficientAccessByParameter(c *gin.Context, requiredAccessLevel dtos.AccessLevel) (bool, error) {
@@ -151,12 +193,108 @@ func HasSufficientAccessByParameter(c *gin.Context, requiredAccessLevel dtos.Acc
 	if err != nil {
 		return false, err
 	}
-	if user != nil {
+	var contextId *string
+	if ctxId := c.Query("context"); ctxId != "" {
+		contextId = &ctxId
+	}
+	return hasSufficientScopedAccess(c, user, contextId, requiredAccessLevel)
+}
+
+func hasSufficientScopedAccess(c *gin.Context, user *dtos.PunqUser, contextId *string, requiredAccessLevel dtos.AccessLevel) (bool, error) {
+	if user == nil {
+		return false, errors.New("user not found")
+	}
+	namespace := ""
+	if contextId != nil {
+		var err error
+		namespace, err = requestNamespace(c)
+		if err != nil {
+			return false, err
+		}
+	}
+	if apiToken := services.GetGinContextApiToken(c); apiToken != nil && !apiToken.AllowsNamespace(namespace) {
+		return false, fmt.Errorf("api token '%s' is not allowed for namespace '%s'", apiToken.Name, namespace)
+	}
+
+	if contextId == nil {
 		if user.AccessLevel >= requiredAccessLevel {
 			c.Set("user", *user)
-			return true, err
+			return true, nil
+		}
+		return false, fmt.Errorf("AccessLevel is insufficient (Current:%d - Required:%d).", user.AccessLevel, requiredAccessLevel)
+	}
+
+	currentAccessLevel := services.EffectiveAccessLevel(user, contextId, namespace)
+	if currentAccessLevel >= requiredAccessLevel {
+		c.Set("user", *user)
+		return true, nil
+	}
+	if namespace == "" {
+		return false, fmt.Errorf("AccessLevel is insufficient for context '%s' (cluster-wide) (Current:%d - Required:%d).", *contextId, currentAccessLevel, requiredAccessLevel)
+	}
+	return false, fmt.Errorf("AccessLevel is insufficient for context '%s' namespace '%s' (Current:%d - Required:%d).", *contextId, namespace, currentAccessLevel, requiredAccessLevel)
+}
+
+// requestNamespace determines the namespace a request acts on. An empty result means cluster-scoped or all
+// namespaces, which is only granted by cluster-wide bindings.
+//   - routes of cluster-scoped kinds (see ClusterScoped): always empty
+//   - the namespace pinned by a middleware that knows the route better (see RequireGenericResource)
+//   - the :namespace path parameter
+//   - POST/PATCH/PUT: metadata.namespace of the (json/yaml) request body. A different ?namespace= is rejected.
+//   - GET: the ?namespace= query of list routes
+func requestNamespace(c *gin.Context) (string, error) {
+	if isClusterScopedRoute(c) {
+		return "", nil
+	}
+	if namespace, exists := c.Get("namespace"); exists {
+		return namespace.(string), nil
+	}
+	if namespace := c.Param("namespace"); namespace != "" {
+		return namespace, nil
+	}
+	switch c.Request.Method {
+	case http.MethodPost, http.MethodPatch, http.MethodPut:
+		namespace := bodyNamespace(c)
+		if query := c.Query("namespace"); query != "" && query != namespace {
+			return "", fmt.Errorf("namespace '%s' of the query does not match namespace '%s' of the body", query, namespace)
+		}
+		return namespace, nil
+	case http.MethodGet:
+		return c.Query("namespace"), nil
+	}
+	return "", nil
+}
+
+// bodyNamespace returns metadata.namespace of the (json/yaml) request body and restores the body for the handler.
+func bodyNamespace(c *gin.Context) string {
+	if c.Request.Body == nil {
+		return ""
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return ""
+	}
+	// restore body for the handler
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	object := metav1.PartialObjectMetadata{}
+	err = yaml.NewYAMLOrJSONDecoder(bytes.NewReader(body), 4096).Decode(&object)
+	if err != nil {
+		return ""
+	}
+	return object.Namespace
+}
+
+// RequireSessionAuth rejects requests authenticated by a personal access token (e.g. to manage tokens).
+func RequireSessionAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if services.GetGinContextApiToken(c) != nil {
+			c.JSON(http.StatusForbidden, gin.H{
+				"err": "This route cannot be used with an api token.",
+			})
+			c.Abort()
+			return
 		}
+		c.Next()
 	}
-	errStr := fmt.Sprintf("AccessLevel is insufficient (Current:%d - Required:%d).", user.AccessLevel, requiredAccessLevel)
-	return false, fmt.Errorf(errStr)
 }
@@ -0,0 +1,122 @@
+package operator
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mogenius/punq/dtos"
+	"github.com/mogenius/punq/services"
+)
+
+func TestRequestNamespace(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name          string
+		method        string
+		target        string
+		params        gin.Params
+		body          string
+		clusterScoped bool
+		want          string
+		wantErr       bool
+	}{
+		{name: "path parameter", method: http.MethodDelete, target: "/?namespace=team-a", params: gin.Params{{Key: "namespace", Value: "team-b"}}, want: "team-b"},
+		{name: "list query", method: http.MethodGet, target: "/?namespace=team-a", want: "team-a"},
+		{name: "cluster-scoped ignores query", method: http.MethodDelete, target: "/?namespace=team-a", clusterScoped: true, want: ""},
+		{name: "cluster-scoped list ignores query", method: http.MethodGet, target: "/?namespace=team-a", clusterScoped: true, want: ""},
+		{name: "delete without path ignores query", method: http.MethodDelete, target: "/?namespace=team-a", want: ""},
+		{name: "body namespace", method: http.MethodPost, target: "/", body: "metadata:\n  name: x\n  namespace: team-a\n", want: "team-a"},
+		{name: "json body namespace", method: http.MethodPatch, target: "/", body: `{"metadata":{"name":"x","namespace":"team-a"}}`, want: "team-a"},
+		{name: "matching query and body", method: http.MethodPost, target: "/?namespace=team-a", body: "metadata:\n  namespace: team-a\n", want: "team-a"},
+		{name: "query differs from body", method: http.MethodPost, target: "/?namespace=team-a", body: "metadata:\n  namespace: kube-system\n", wantErr: true},
+		{name: "query without body namespace", method: http.MethodPost, target: "/?namespace=team-a", body: "metadata:\n  name: x\n", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(test.method, test.target, strings.NewReader(test.body))
+			c.Params = test.params
+			if test.clusterScoped {
+				c.Set("clusterScoped", true)
+			}
+
+			got, err := requestNamespace(c)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("requestNamespace() = %q, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("requestNamespace() failed: %s", err.Error())
+			}
+			if got != test.want {
+				t.Errorf("requestNamespace() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestApiTokenContextRestriction(t *testing.T) {
+	user, err := services.AddUser(dtos.PunqUserCreateInput{Email: "ci@punq.dev", Password: "secret", DisplayName: "ci", AccessLevel: dtos.ADMIN})
+	if err != nil {
+		t.Fatal(err)
+	}
+	binding, err := services.AddRoleBinding(dtos.PunqRoleBindingCreateInput{UserId: user.Id, Role: dtos.ADMIN, ContextId: "staging"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scoped, err := services.AddApiToken(user.Id, dtos.PunqApiTokenCreateInput{Name: "staging", Contexts: []string{"staging"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	unscoped, err := services.AddApiToken(user.Id, dtos.PunqApiTokenCreateInput{Name: "all"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		services.DeleteRoleBinding(binding.Id)
+		services.RevokeApiTokensForUser(user.Id)
+		services.DeleteUser(user.Id)
+	})
+
+	router := gin.New()
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.POST("/user", Auth(dtos.ADMIN), ok)
+	router.GET("/workload", RequireContextId(), Auth(dtos.READER), ok)
+
+	tests := []struct {
+		name      string
+		token     string
+		method    string
+		target    string
+		contextId string
+		want      int
+	}{
+		{"context route of the token", scoped.Token, http.MethodGet, "/workload", "staging", http.StatusOK},
+		{"context route of another context", scoped.Token, http.MethodGet, "/workload", "production", http.StatusUnauthorized},
+		{"global route with header of the token context", scoped.Token, http.MethodPost, "/user", "staging", http.StatusUnauthorized},
+		{"global route without header", scoped.Token, http.MethodPost, "/user", "", http.StatusUnauthorized},
+		{"unrestricted token on global route", unscoped.Token, http.MethodPost, "/user", "staging", http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(test.method, test.target, nil)
+			request.Header.Set("Authorization", services.ApiTokenScheme+" "+test.token)
+			if test.contextId != "" {
+				request.Header.Set("X-Context-Id", test.contextId)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+			if recorder.Code != test.want {
+				t.Errorf("status = %d, want %d (%s)", recorder.Code, test.want, recorder.Body.String())
+			}
+		})
+	}
+}
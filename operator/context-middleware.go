@@ -1,11 +1,15 @@
 package operator
 
 import (
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 	"github.com/mogenius/punq/services"
 	"github.com/mogenius/punq/utils"
 )
 
+// RequireContextId marks the route as a route of the context given by X-Context-Id. Must be used before Auth,
+// which then evaluates the role bindings of the user for the context (and the namespace of the request).
 func RequireContextId() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		contextId := services.GetGinContextId(c)
@@ -13,8 +17,38 @@ func RequireContextId() gin.HandlerFunc {
 			utils.MissingHeader(c, "X-Context-Id")
 			c.Abort()
 			return
-		} else {
-			c.Next()
 		}
+		// the same pointer is handed out for the whole request (impersonation is registered for it)
+		c.Set("contextId", contextId)
+		c.Set("contextRoute", true)
+
+		// e.g. contexts labeled environment=production (see utils.CONFIG.Kubernetes.ConfirmDeleteSelector)
+		if c.Request.Method == http.MethodDelete {
+			if err := services.CheckDeleteConfirmation(*contextId, c.GetHeader("X-Confirm-Context")); err != nil {
+				c.JSON(http.StatusPreconditionRequired, gin.H{
+					"err": err.Error(),
+				})
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
 	}
 }
+
+// ClusterScoped marks routes of cluster-scoped kinds (nodes, cluster roles, crds, ...) or the context itself.
+// Namespace bindings never grant access to them, whatever namespace the request names.
+func ClusterScoped() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("clusterScoped", true)
+		c.Next()
+	}
+}
+
+func isContextRoute(c *gin.Context) bool {
+	return c.GetBool("contextRoute")
+}
+
+func isClusterScopedRoute(c *gin.Context) bool {
+	return c.GetBool("clusterScoped")
+}
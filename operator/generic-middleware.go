@@ -0,0 +1,115 @@
+package operator
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mogenius/punq/dtos"
+	"github.com/mogenius/punq/kubernetes"
+	"github.com/mogenius/punq/services"
+	"github.com/mogenius/punq/utils"
+)
+
+// group of the core resources (pods, services, ...) in generic routes
+const genericCoreGroup = "core"
+
+var genericVerbs = map[string]string{
+	http.MethodGet:    "get",
+	http.MethodPost:   "create",
+	http.MethodPatch:  "patch",
+	http.MethodDelete: "delete",
+}
+
+// RequireGenericResource resolves :group/:version/:resource of the context by discovery and authorizes the request
+// like Auth does, with the access level of the resource (see kubernetes.GenericResourceAccessLevel) for the
+// namespace the request acts on (see genericRequestNamespace). Must be used after RequireContextId instead of Auth.
+func RequireGenericResource() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		contextId := services.GetGinContextId(c)
+		user, err := CheckUserAuthorization(c)
+		if err == nil && !services.HasContextAccess(user, *contextId) {
+			err = fmt.Errorf("No access to context '%s'.", *contextId)
+		}
+		if err != nil {
+			utils.Unauthorized(c, err.Error())
+			c.Abort()
+			return
+		}
+		// discovery already runs with the identity of the user
+		release := services.StartGinImpersonation(c, user)
+		defer release()
+
+		group := c.Param("group")
+		if group == genericCoreGroup {
+			group = ""
+		}
+
+		resource, err := kubernetes.FindGenericResource(group, c.Param("version"), c.Param("resource"), contextId)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"err": err.Error(),
+			})
+			c.Abort()
+			return
+		}
+
+		verb := genericVerbs[c.Request.Method]
+		if verb == "get" && c.Param("name") == "" {
+			verb = "list"
+		}
+		if !utils.ContainsEqual(resource.Verbs, verb) {
+			c.JSON(http.StatusMethodNotAllowed, gin.H{
+				"err": fmt.Sprintf("%s does not support '%s'.", resource.FullName(), verb),
+			})
+			c.Abort()
+			return
+		}
+
+		namespace, err := genericRequestNamespace(c, *resource)
+		if err != nil {
+			utils.MalformedMessage(c, err.Error())
+			c.Abort()
+			return
+		}
+		if resource.Namespaced {
+			c.Set("namespace", namespace)
+		} else {
+			c.Set("clusterScoped", true)
+		}
+
+		isAuthorized, err := hasSufficientScopedAccess(c, user, contextId, kubernetes.GenericResourceAccessLevel(*resource, verb))
+		if !isAuthorized {
+			if err == nil {
+				err = fmt.Errorf("AccessLevel is insufficient for %s.", resource.FullName())
+			}
+			utils.Unauthorized(c, err.Error())
+			c.Abort()
+			return
+		}
+
+		c.Set("genericResource", *resource)
+		c.Next()
+	}
+}
+
+// genericRequestNamespace returns the namespace the generic handlers act on: metadata.namespace of the manifest
+// for create (a different ?namespace= is rejected), the ?namespace= query otherwise. Cluster-scoped resources
+// have no namespace.
+func genericRequestNamespace(c *gin.Context, resource dtos.GenericResource) (string, error) {
+	if !resource.Namespaced {
+		return "", nil
+	}
+	if c.Request.Method != http.MethodPost {
+		return c.Query("namespace"), nil
+	}
+	namespace := bodyNamespace(c)
+	if query := c.Query("namespace"); query != "" && query != namespace {
+		return "", fmt.Errorf("namespace '%s' of the query does not match namespace '%s' of the manifest", query, namespace)
+	}
+	return namespace, nil
+}
+
+func getGinGenericResource(c *gin.Context) dtos.GenericResource {
+	return c.MustGet("genericResource").(dtos.GenericResource)
+}
@@ -0,0 +1,55 @@
+package operator
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mogenius/punq/dtos"
+)
+
+func TestGenericRequestNamespace(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	namespaced := dtos.GenericResource{Resource: "rollouts", Namespaced: true}
+	clusterScoped := dtos.GenericResource{Resource: "clusterroles"}
+	tests := []struct {
+		name     string
+		resource dtos.GenericResource
+		method   string
+		target   string
+		body     string
+		want     string
+		wantErr  bool
+	}{
+		{name: "list query", resource: namespaced, method: http.MethodGet, target: "/?namespace=team-a", want: "team-a"},
+		{name: "patch uses query not merge patch", resource: namespaced, method: http.MethodPatch, target: "/?namespace=team-a", body: `{"spec":{"replicas":2}}`, want: "team-a"},
+		{name: "create uses manifest", resource: namespaced, method: http.MethodPost, target: "/", body: "metadata:\n  namespace: team-a\n", want: "team-a"},
+		{name: "create rejects other manifest namespace", resource: namespaced, method: http.MethodPost, target: "/?namespace=team-a", body: "metadata:\n  namespace: kube-system\n", wantErr: true},
+		{name: "cluster-scoped ignores query", resource: clusterScoped, method: http.MethodDelete, target: "/?namespace=team-a", want: ""},
+		{name: "cluster-scoped create ignores manifest", resource: clusterScoped, method: http.MethodPost, target: "/?namespace=team-a", body: "metadata:\n  namespace: team-a\n", want: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(test.method, test.target, strings.NewReader(test.body))
+
+			got, err := genericRequestNamespace(c, test.resource)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("genericRequestNamespace() = %q, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("genericRequestNamespace() failed: %s", err.Error())
+			}
+			if got != test.want {
+				t.Errorf("genericRequestNamespace() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package operator
+
+import (
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mogenius/punq/store"
+	"github.com/mogenius/punq/utils"
+)
+
+// TestMain runs all tests against a file store in a temporary directory.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "punq-operator-test")
+	if err != nil {
+		panic(err)
+	}
+	utils.CONFIG.Store.Type = store.TypeFile
+	utils.CONFIG.Store.FilePath = dir
+	gin.SetMode(gin.TestMode)
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
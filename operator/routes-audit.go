@@ -0,0 +1,58 @@
+package operator
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mogenius/punq/dtos"
+	"github.com/mogenius/punq/services"
+	"github.com/mogenius/punq/utils"
+)
+
+func InitAuditRoutes(router *gin.Engine) {
+
+	auditRoutes := router.Group("/audit")
+	{
+		auditRoutes.GET("", Auth(dtos.ADMIN), auditList)
+	}
+}
+
+// @Tags Audit
+// @Produce json
+// @Success 200 {array} dtos.PunqAuditEntry
+// @Router /backend/audit [get]
+// @Param since query string false "duration (e.g. 24h) or RFC3339 timestamp, default: 24h"
+// @Param userId query string false "filter by user"
+// @Param contextId query string false "filter by context"
+// @Param verb query string false "filter by verb (POST, PATCH, PUT, DELETE, EXEC)"
+// @Param limit query int false "max number of entries"
+// @Param format query string false "json (default) or jsonl"
+// @Security Bearer
+func auditList(c *gin.Context) {
+	since, err := services.ParseAuditSince(c.Query("since"))
+	if err != nil {
+		utils.MalformedMessage(c, err.Error())
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	entries := services.ListAuditEntries(services.AuditFilter{
+		Since:     since,
+		UserId:    c.Query("userId"),
+		ContextId: c.Query("contextId"),
+		Verb:      c.Query("verb"),
+		Limit:     limit,
+	})
+
+	if c.Query("format") == "jsonl" {
+		lines, err := services.AuditEntriesToJsonLines(entries)
+		if err != nil {
+			utils.MalformedMessage(c, err.Error())
+			return
+		}
+		c.Data(http.StatusOK, "application/x-ndjson", lines)
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
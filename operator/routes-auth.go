@@ -1,7 +1,10 @@
 package operator
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
@@ -15,12 +18,40 @@ type LoginInput struct {
 	Password string `json:"password" binding:"required"`
 }
 
+type RefreshInput struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+type LogoutInput struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+type ServiceAccountLoginInput struct {
+	Token string `json:"token" binding:"required"` // projected ServiceAccount token
+}
+
+type MfaEnrollInput struct {
+	MfaToken string `json:"mfaToken" binding:"required"`
+}
+
+type MfaVerifyInput struct {
+	MfaToken string `json:"mfaToken" binding:"required"`
+	Code     string `json:"code" binding:"required"` // totp or recovery code
+}
+
 func InitAuthRoutes(router *gin.Engine) {
 
 	authRoutes := router.Group("/auth")
 	{
 		authRoutes.POST("/login", login)
+		authRoutes.POST("/serviceaccount", serviceAccountLogin)
+		authRoutes.POST("/refresh", refresh)
+		authRoutes.POST("/logout", Auth(dtos.READER), logout)
+		authRoutes.POST("/mfa/enroll", mfaEnroll)
+		authRoutes.POST("/mfa/verify", mfaVerify)
 		authRoutes.GET("/authenticate", Auth(dtos.READER), authenticate)
+		authRoutes.GET("/oidc/login", oidcLogin)
+		authRoutes.GET("/oidc/callback", oidcCallback)
 	}
 
 }
@@ -28,6 +59,7 @@ func InitAuthRoutes(router *gin.Engine) {
 // @Tags Auth
 // @Produce json
 // @Success 200 {object} dtos.PunqToken
+// @Success 202 {object} dtos.PunqMfaChallenge
 // @Router /backend/auth/login [post]
 // @Param body body LoginInput true "LoginInput"
 func login(c *gin.Context) {
@@ -39,20 +71,80 @@ func login(c *gin.Context) {
 		return
 	}
 
-	user, err := services.GetUserByEmail(input.Email)
+	user, err := services.Login(input.Email, input.Password, c.ClientIP())
+	if err != nil {
+		utils.Unauthorized(c, err.Error())
+		return
+	}
+
+	respondLogin(c, user)
+}
 
+// respondLogin issues the token pair of an authenticated user, or an MFA challenge if the user has a second factor
+// (or has to enroll one).
+func respondLogin(c *gin.Context, user *dtos.PunqUser) {
+	if user.TotpEnabled || user.MfaRequired {
+		challenge, err := services.MfaChallenge(user)
+		if err != nil {
+			utils.Unauthorized(c, err.Error())
+			return
+		}
+		c.JSON(http.StatusAccepted, challenge)
+		return
+	}
+
+	token, err := services.GenerateToken(user, c.ClientIP(), c.Request.UserAgent())
 	if err != nil {
-		utils.NotFound(c, err.Error())
+		utils.Unauthorized(c, err.Error())
 		return
 	}
+	c.JSON(http.StatusOK, token)
+}
 
-	_, err = user.PasswordCheck(input.Password)
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} dtos.PunqToken
+// @Success 202 {object} dtos.PunqMfaChallenge
+// @Failure 429
+// @Router /backend/auth/serviceaccount [post]
+// @Param body body ServiceAccountLoginInput true "ServiceAccountLoginInput"
+func serviceAccountLogin(c *gin.Context) {
+	input := ServiceAccountLoginInput{}
+
+	err := c.MustBindWith(&input, binding.JSON)
 	if err != nil {
-		utils.Unauthorized(c, "username or password is incorrect")
+		c.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
 		return
 	}
 
-	token, err := services.GenerateToken(user)
+	user, err := services.ServiceAccountLogin(input.Token, c.ClientIP())
+	if errors.Is(err, services.ErrServiceAccountLoginThrottled) {
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"err": err.Error()})
+		return
+	}
+	if err != nil {
+		utils.Unauthorized(c, err.Error())
+		return
+	}
+
+	respondLogin(c, user)
+}
+
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} dtos.PunqToken
+// @Router /backend/auth/refresh [post]
+// @Param body body RefreshInput true "RefreshInput"
+func refresh(c *gin.Context) {
+	input := RefreshInput{}
+
+	err := c.MustBindWith(&input, binding.JSON)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+		return
+	}
+
+	token, err := services.RefreshToken(input.RefreshToken, c.ClientIP(), c.Request.UserAgent())
 	if err != nil {
 		utils.Unauthorized(c, err.Error())
 		return
@@ -60,6 +152,75 @@ func login(c *gin.Context) {
 	c.JSON(http.StatusOK, token)
 }
 
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} dtos.PunqTotpEnrollment
+// @Router /backend/auth/mfa/enroll [post]
+// @Param body body MfaEnrollInput true "MfaEnrollInput"
+func mfaEnroll(c *gin.Context) {
+	input := MfaEnrollInput{}
+
+	err := c.MustBindWith(&input, binding.JSON)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+		return
+	}
+
+	enrollment, err := services.EnrollTotpWithMfaToken(input.MfaToken)
+	if err != nil {
+		utils.Unauthorized(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} dtos.PunqMfaVerifyResult
+// @Router /backend/auth/mfa/verify [post]
+// @Param body body MfaVerifyInput true "MfaVerifyInput"
+func mfaVerify(c *gin.Context) {
+	input := MfaVerifyInput{}
+
+	err := c.MustBindWith(&input, binding.JSON)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+		return
+	}
+
+	result, err := services.VerifyMfa(input.MfaToken, input.Code, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		utils.Unauthorized(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// @Tags Auth
+// @Produce json
+// @Success 200
+// @Router /backend/auth/logout [post]
+// @Param body body LogoutInput false "LogoutInput"
+// @Security Bearer
+func logout(c *gin.Context) {
+	input := LogoutInput{}
+	// body is optional
+	_ = c.ShouldBindWith(&input, binding.JSON)
+
+	claims := services.GetGinContextClaims(c)
+	if claims == nil {
+		utils.Unauthorized(c, "Unauthorized")
+		return
+	}
+
+	err := services.Logout(claims, input.RefreshToken)
+	if err != nil {
+		utils.MalformedMessage(c, err.Error())
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
 // @Tags Auth
 // @Produce json
 // @Success 200 {object} dtos.PunqToken
@@ -73,3 +234,73 @@ func authenticate(c *gin.Context) {
 	}
 	utils.Unauthorized(c, "Unauthorized")
 }
+
+// @Tags Auth
+// @Produce json
+// @Success 302
+// @Router /backend/auth/oidc/login [get]
+func oidcLogin(c *gin.Context) {
+	url, err := services.OidcAuthCodeUrl()
+	if err != nil {
+		utils.MalformedMessage(c, err.Error())
+		return
+	}
+	c.Redirect(http.StatusFound, url)
+}
+
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} dtos.PunqToken
+// @Success 202 {object} dtos.PunqMfaChallenge
+// @Router /backend/auth/oidc/callback [get]
+// @Param state query string true "state"
+// @Param code query string true "code"
+func oidcCallback(c *gin.Context) {
+	if errParam := c.Query("error"); errParam != "" {
+		utils.Unauthorized(c, fmt.Sprintf("%s: %s", errParam, c.Query("error_description")))
+		return
+	}
+
+	user, err := services.OidcLogin(c.Query("state"), c.Query("code"))
+	if err != nil {
+		utils.Unauthorized(c, err.Error())
+		return
+	}
+
+	if utils.CONFIG.Oidc.FrontendUrl == "" {
+		respondLogin(c, user)
+		return
+	}
+
+	fragment, err := oidcLoginFragment(c, user)
+	if err != nil {
+		utils.Unauthorized(c, err.Error())
+		return
+	}
+	c.Redirect(http.StatusFound, fmt.Sprintf("%s#%s", utils.CONFIG.Oidc.FrontendUrl, fragment.Encode()))
+}
+
+// oidcLoginFragment is the redirect counterpart of respondLogin: the token pair, or the MFA challenge which the
+// frontend completes with /auth/mfa/verify.
+func oidcLoginFragment(c *gin.Context, user *dtos.PunqUser) (url.Values, error) {
+	fragment := url.Values{}
+	if user.TotpEnabled || user.MfaRequired {
+		challenge, err := services.MfaChallenge(user)
+		if err != nil {
+			return nil, err
+		}
+		fragment.Set("mfaToken", challenge.MfaToken)
+		fragment.Set("enrollmentRequired", fmt.Sprint(challenge.EnrollmentRequired))
+		fragment.Set("expiresAt", challenge.ExpiresAt)
+		return fragment, nil
+	}
+
+	token, err := services.GenerateToken(user, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		return nil, err
+	}
+	fragment.Set("token", token.Token)
+	fragment.Set("refreshToken", token.RefreshToken)
+	fragment.Set("expiresAt", token.ExpiresAt)
+	return fragment, nil
+}
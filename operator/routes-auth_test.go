@@ -0,0 +1,87 @@
+package operator
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mogenius/punq/dtos"
+	"github.com/mogenius/punq/services"
+)
+
+func TestOidcLoginFragment(t *testing.T) {
+	services.InitAuthService()
+
+	tests := []struct {
+		name     string
+		user     dtos.PunqUser
+		wantKeys []string
+	}{
+		{"no second factor", dtos.PunqUser{Id: "oidc-plain", AccessLevel: dtos.USER}, []string{"token", "refreshToken", "expiresAt"}},
+		{"totp enabled", dtos.PunqUser{Id: "oidc-totp", AccessLevel: dtos.USER, TotpEnabled: true}, []string{"mfaToken", "enrollmentRequired", "expiresAt"}},
+		{"mfa required", dtos.PunqUser{Id: "oidc-required", AccessLevel: dtos.USER, MfaRequired: true}, []string{"mfaToken", "enrollmentRequired", "expiresAt"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/auth/oidc/callback", nil)
+
+			fragment, err := oidcLoginFragment(c, &test.user)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(fragment) != len(test.wantKeys) {
+				t.Errorf("fragment = %v, want keys %v", fragment, test.wantKeys)
+			}
+			for _, key := range test.wantKeys {
+				if fragment.Get(key) == "" {
+					t.Errorf("fragment has no '%s': %v", key, fragment)
+				}
+			}
+			if fragment.Has("mfaToken") && fragment.Has("token") {
+				t.Error("mfa challenge carries a token")
+			}
+		})
+	}
+}
+
+func TestRespondLogin(t *testing.T) {
+	services.InitAuthService()
+
+	tests := []struct {
+		name                   string
+		user                   dtos.PunqUser
+		wantStatus             int
+		wantEnrollmentRequired bool
+	}{
+		{"no second factor", dtos.PunqUser{Id: "login-plain", AccessLevel: dtos.USER}, http.StatusOK, false},
+		{"totp enabled", dtos.PunqUser{Id: "login-totp", AccessLevel: dtos.USER, TotpEnabled: true}, http.StatusAccepted, false},
+		{"mfa required", dtos.PunqUser{Id: "login-required", AccessLevel: dtos.USER, MfaRequired: true}, http.StatusAccepted, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Request = httptest.NewRequest(http.MethodPost, "/auth/login", nil)
+
+			respondLogin(c, &test.user)
+			if recorder.Code != test.wantStatus {
+				t.Fatalf("status = %d, want %d", recorder.Code, test.wantStatus)
+			}
+			if test.wantStatus != http.StatusAccepted {
+				return
+			}
+			challenge := dtos.PunqMfaChallenge{}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &challenge); err != nil {
+				t.Fatal(err)
+			}
+			if challenge.MfaToken == "" || challenge.EnrollmentRequired != test.wantEnrollmentRequired {
+				t.Errorf("challenge = %+v", challenge)
+			}
+		})
+	}
+}
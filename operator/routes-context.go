@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
 
 	"github.com/mogenius/punq/kubernetes"
 	"github.com/mogenius/punq/logger"
@@ -19,9 +20,11 @@ func InitContextRoutes(router *gin.Engine) {
 	contextRoutes := router.Group("/context")
 	{
 		contextRoutes.GET("/all", Auth(dtos.READER), allContexts)
-		contextRoutes.GET("/info", Auth(dtos.ADMIN), RequireContextId(), getInfoContexts)
-		contextRoutes.GET("", Auth(dtos.ADMIN), RequireContextId(), getContext)
-		contextRoutes.DELETE("", Auth(dtos.ADMIN), RequireContextId(), deleteContext)
+		contextRoutes.GET("/health", Auth(dtos.READER), contextHealth)
+		contextRoutes.GET("/kubeconfig", Auth(dtos.READER), contextKubeconfig)
+		contextRoutes.GET("/info", RequireContextId(), ClusterScoped(), Auth(dtos.ADMIN), getInfoContexts)
+		contextRoutes.GET("", RequireContextId(), ClusterScoped(), Auth(dtos.ADMIN), getContext)
+		contextRoutes.DELETE("", RequireContextId(), ClusterScoped(), Auth(dtos.ADMIN), deleteContext)
 		contextRoutes.POST("/validate-config", Auth(dtos.ADMIN), validateConfig)
 		contextRoutes.POST("", Auth(dtos.ADMIN), addContext)
 		contextRoutes.PATCH("", Auth(dtos.ADMIN), updateContext)
@@ -32,27 +35,62 @@ func InitContextRoutes(router *gin.Engine) {
 // @Produce json
 // @Success 200 {array} dtos.PunqContext
 // @Router /backend/context/all [get]
+// @Param selector query string false "label selector, e.g. environment=production,team=payments"
 // @Security Bearer
 func allContexts(c *gin.Context) {
-	resultingContexts := []dtos.PunqContext{}
+	user := services.GetGinContextUser(c)
+	contexts, err := dtos.FilterContextsBySelector(services.ListContexts(), c.Query("selector"))
+	if err != nil {
+		utils.MalformedMessage(c, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, services.WithoutKubeconfigs(services.WithCredentials(services.FilterAccessibleContexts(user, contexts))))
+}
 
+// @Tags Context
+// @Produce json
+// @Success 200 {array} dtos.PunqContextHealth
+// @Router /backend/context/health [get]
+// @Security Bearer
+func contextHealth(c *gin.Context) {
 	user := services.GetGinContextUser(c)
-	contexts := services.ListContexts()
+	contexts := services.FilterAccessibleContexts(user, services.ListContexts())
 
-	for _, ctx := range contexts {
-		if ctx.AccessLevel >= user.AccessLevel {
-			// USER IS IN A GROUP THAT IS ALLOWED
-			resultingContexts = append(resultingContexts, ctx)
-			continue
-		}
-		for _, userFromList := range ctx.Users {
-			if userFromList == user.Id {
-				// USERID IS EXPLICITLY ALLOWED
-				resultingContexts = append(resultingContexts, ctx)
-			}
-		}
+	c.JSON(http.StatusOK, services.ListContextHealth(contexts))
+}
+
+// @Tags Context
+// @Produce application/yaml
+// @Success 200 {string} string "kubeconfig"
+// @Router /backend/context/kubeconfig [get]
+// @Param selector query string false "label selector, e.g. environment=production,team=payments"
+// @Security Bearer
+func contextKubeconfig(c *gin.Context) {
+	user := services.GetGinContextUser(c)
+	contexts, err := dtos.FilterContextsBySelector(services.ListContexts(), c.Query("selector"))
+	if err != nil {
+		utils.MalformedMessage(c, err.Error())
+		return
 	}
-	c.JSON(http.StatusOK, resultingContexts)
+
+	kubeconfig, exported, err := services.MergedKubeconfig(user, services.FilterAccessibleContexts(user, contexts))
+	if err != nil {
+		utils.MalformedMessage(c, err.Error())
+		return
+	}
+	if len(exported) == 0 {
+		c.JSON(http.StatusForbidden, gin.H{
+			"err": "None of the contexts may be exported for this user.",
+		})
+		return
+	}
+	for _, ctx := range exported {
+		recordKubeconfigExport(c, ctx.Id)
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="punq-kubeconfig.yaml"`)
+	c.Data(http.StatusOK, "application/yaml", kubeconfig)
 }
 
 // @Tags Context
@@ -80,7 +118,7 @@ func getContext(c *gin.Context) {
 			utils.NotFound(c, "Context not found.")
 			return
 		}
-		c.JSON(http.StatusOK, result)
+		c.JSON(http.StatusOK, services.WithoutKubeconfig(*result))
 	} else {
 		utils.MalformedMessage(c, "No context-id found.")
 		return
@@ -92,12 +130,20 @@ func getContext(c *gin.Context) {
 // @Success 200 {object} dtos.PunqContext
 // @Router /backend/context [delete]
 // @Param X-Context-Id header string true "X-Context-Id"
+// @Param X-Confirm-Context header string false "name of the context (required if it matches confirm_delete_selector)"
+// @Param body body string false "kubeconfig of a cluster admin (required to revoke the ServiceAccount of a minted context)"
+// @Param keepServiceAccount query bool false "delete a minted context without revoking its ServiceAccount"
 // @Security Bearer
 func deleteContext(c *gin.Context) {
 	ctxId := services.GetGinContextId(c)
 
 	if ctxId != nil {
-		result, err := services.DeleteContext(*ctxId)
+		adminKubeconfig, err := c.GetRawData()
+		if err != nil {
+			utils.MalformedMessage(c, err.Error())
+			return
+		}
+		result, err := services.DeleteContext(*ctxId, string(adminKubeconfig), c.Query("keepServiceAccount") == "true")
 		if err != nil {
 			utils.MalformedMessage(c, err.Error())
 			return
@@ -144,6 +190,11 @@ func validateConfig(c *gin.Context) {
 	if err != nil {
 		logger.Log.Error(err.Error())
 	}
+	for _, ctx := range contexts {
+		for _, warning := range ctx.Credentials.Warnings {
+			logger.Log.Warningf("Context '%s': %s", ctx.Name, warning)
+		}
+	}
 
 	// CLEANUP
 	err = os.Remove(tempFilename)
@@ -157,30 +208,50 @@ func validateConfig(c *gin.Context) {
 // @Tags Context
 // @Produce json
 // @Success 200 {array} dtos.PunqContext
+// @Failure 500
 // @Router /backend/context [post]
 // @Param body body dtos.PunqContext false "PunqContext"
+// @Param serviceAccount query bool false "mint a ServiceAccount per context and store its token instead of the given credentials"
+// @Param clusterRole query string false "ClusterRole of the minted ServiceAccount (default: view)"
 // @Security Bearer
 func addContext(c *gin.Context) {
+	mintServiceAccount := c.Query("serviceAccount") == "true"
+	clusterRole := c.DefaultQuery("clusterRole", "view")
+
 	receivedContexts := []dtos.PunqContext{}
 	if err := c.BindJSON(&receivedContexts); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	// the other contexts are still added if one fails, the response lists the added ones in any case
 	addedContexts := []dtos.PunqContext{}
+	failures := []string{}
 	for _, ctx := range receivedContexts {
-		addedCtx, err := services.AddContext(ctx)
+		var addedCtx *dtos.PunqContext
+		var err error
+		if mintServiceAccount {
+			addedCtx, err = services.AddServiceAccountContext(ctx, clusterRole)
+		} else {
+			addedCtx, err = services.AddContext(ctx)
+		}
 		if err != nil {
 			fmt.Println(err.Error())
-			c.JSON(http.StatusInternalServerError, err)
+			failures = append(failures, fmt.Sprintf("context '%s': %s", ctx.Name, err.Error()))
+			continue
 		}
 		fmt.Printf("Context '%s' added ✅.\n", addedCtx.Name)
-		if addedCtx != nil {
-			addedContexts = append(addedContexts, *addedCtx)
-		}
+		addedContexts = append(addedContexts, *addedCtx)
 	}
 
-	c.JSON(200, addedContexts)
+	if len(failures) > 0 {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"err":      strings.Join(failures, "; "),
+			"contexts": services.WithoutKubeconfigs(addedContexts),
+		})
+		return
+	}
+	c.JSON(200, services.WithoutKubeconfigs(addedContexts))
 }
 
 // @Tags Context
@@ -199,9 +270,10 @@ func updateContext(c *gin.Context) {
 	updateContext, err := services.UpdateContext(receivedContext)
 	if err != nil {
 		fmt.Println(err.Error())
-		c.JSON(http.StatusInternalServerError, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
 	}
 	fmt.Printf("Context '%s' updated ✅.\n", receivedContext.Name)
 
-	c.JSON(200, updateContext)
+	c.JSON(200, services.WithoutKubeconfig(*updateContext))
 }
@@ -0,0 +1,41 @@
+package operator
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mogenius/punq/dtos"
+	"github.com/mogenius/punq/services"
+)
+
+func TestAddContextRespondsOnce(t *testing.T) {
+	t.Cleanup(func() {
+		services.DeleteContext("ctx-added", "", false)
+	})
+
+	router := gin.New()
+	router.POST("/context", addContext)
+
+	// the second context duplicates the first one
+	body := `[{"id":"ctx-added","name":"added","contextHash":"hash-added"},{"id":"ctx-duplicate","name":"duplicate","contextHash":"hash-added"}]`
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/context", strings.NewReader(body)))
+
+	if recorder.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusInternalServerError)
+	}
+	response := struct {
+		Err      string             `json:"err"`
+		Contexts []dtos.PunqContext `json:"contexts"`
+	}{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("response is no single json object: %s (%s)", recorder.Body.String(), err.Error())
+	}
+	if !strings.Contains(response.Err, "duplicate") || len(response.Contexts) != 1 || response.Contexts[0].Id != "ctx-added" {
+		t.Errorf("response = %+v", response)
+	}
+}
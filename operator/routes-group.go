@@ -0,0 +1,139 @@
+package operator
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/mogenius/punq/dtos"
+	"github.com/mogenius/punq/services"
+	"github.com/mogenius/punq/utils"
+)
+
+func InitGroupRoutes(router *gin.Engine) {
+
+	groupRoutes := router.Group("/group")
+	{
+		groupRoutes.GET("/all", Auth(dtos.ADMIN), groupList)
+		groupRoutes.GET("/:id", validateParam("id"), Auth(dtos.ADMIN), groupGet)
+		groupRoutes.DELETE("/:id", validateParam("id"), Auth(dtos.ADMIN), groupDelete)
+		groupRoutes.PATCH("/", Auth(dtos.ADMIN), groupUpdate)
+		groupRoutes.POST("/", Auth(dtos.ADMIN), groupAdd)
+		groupRoutes.POST("/:id/member/:userId", validateParam("id", "userId"), Auth(dtos.ADMIN), groupAddMember)
+		groupRoutes.DELETE("/:id/member/:userId", validateParam("id", "userId"), Auth(dtos.ADMIN), groupRemoveMember)
+	}
+
+}
+
+// @Tags Group
+// @Produce json
+// @Success 200 {array} dtos.PunqGroup
+// @Router /backend/group/all [get]
+// @Security Bearer
+func groupList(c *gin.Context) {
+	c.JSON(http.StatusOK, services.ListGroups())
+}
+
+// @Tags Group
+// @Produce json
+// @Success 200 {object} dtos.PunqGroup
+// @Router /backend/group/{id} [get]
+// @Param id path string true "ID of the group"
+// @Security Bearer
+func groupGet(c *gin.Context) {
+	group, err := services.GetGroup(c.Param("id"))
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, group)
+}
+
+// @Tags Group
+// @Produce json
+// @Success 200
+// @Router /backend/group/{id} [delete]
+// @Param id path string true "ID of the group"
+// @Security Bearer
+func groupDelete(c *gin.Context) {
+	err := services.DeleteGroup(c.Param("id"))
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// @Tags Group
+// @Produce json
+// @Success 200 {object} dtos.PunqGroup
+// @Router /backend/group [patch]
+// @Param body body dtos.PunqGroup false "PunqGroup"
+// @Security Bearer
+func groupUpdate(c *gin.Context) {
+	var data dtos.PunqGroup
+	err := c.MustBindWith(&data, binding.JSON)
+	if err != nil {
+		utils.MalformedMessage(c, err.Error())
+		return
+	}
+	group, err := services.UpdateGroup(data)
+	if err != nil {
+		utils.MalformedMessage(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, group)
+}
+
+// @Tags Group
+// @Produce json
+// @Success 200 {object} dtos.PunqGroup
+// @Router /backend/group [post]
+// @Param body body dtos.PunqGroupCreateInput false "PunqGroupCreateInput"
+// @Security Bearer
+func groupAdd(c *gin.Context) {
+	var data dtos.PunqGroupCreateInput
+	err := c.MustBindWith(&data, binding.JSON)
+	if err != nil {
+		utils.MalformedMessage(c, err.Error())
+		return
+	}
+	group, err := services.AddGroup(data)
+	if err != nil {
+		utils.MalformedMessage(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, group)
+}
+
+// @Tags Group
+// @Produce json
+// @Success 200 {object} dtos.PunqGroup
+// @Router /backend/group/{id}/member/{userId} [post]
+// @Param id path string true "ID of the group"
+// @Param userId path string true "ID of the user"
+// @Security Bearer
+func groupAddMember(c *gin.Context) {
+	group, err := services.AddGroupMember(c.Param("id"), c.Param("userId"))
+	if err != nil {
+		utils.MalformedMessage(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, group)
+}
+
+// @Tags Group
+// @Produce json
+// @Success 200 {object} dtos.PunqGroup
+// @Router /backend/group/{id}/member/{userId} [delete]
+// @Param id path string true "ID of the group"
+// @Param userId path string true "ID of the user"
+// @Security Bearer
+func groupRemoveMember(c *gin.Context) {
+	group, err := services.RemoveGroupMember(c.Param("id"), c.Param("userId"))
+	if err != nil {
+		utils.MalformedMessage(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, group)
+}
@@ -11,14 +11,36 @@ import (
 	"github.com/mogenius/punq/utils"
 )
 
+type MfaCodeInput struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type MfaRequiredInput struct {
+	Required bool `json:"required"`
+}
+
 func InitUserRoutes(router *gin.Engine) {
 
 	userRoutes := router.Group("/user")
 	{
 		userRoutes.GET("/all", Auth(dtos.ADMIN), userList)
+		userRoutes.GET("/tokens", Auth(dtos.READER), RequireSessionAuth(), apiTokenList)
+		userRoutes.POST("/tokens", Auth(dtos.READER), RequireSessionAuth(), apiTokenAdd)
+		userRoutes.DELETE("/tokens/:id", validateParam("id"), Auth(dtos.READER), RequireSessionAuth(), apiTokenRevoke)
+		userRoutes.GET("/sessions", Auth(dtos.READER), sessionList)
+		userRoutes.DELETE("/sessions/:id", validateParam("id"), Auth(dtos.READER), sessionDelete)
+		userRoutes.POST("/mfa/enroll", Auth(dtos.READER), RequireSessionAuth(), mfaEnrollSelf)
+		userRoutes.POST("/mfa/confirm", Auth(dtos.READER), RequireSessionAuth(), mfaConfirmSelf)
+		userRoutes.DELETE("/mfa", Auth(dtos.READER), RequireSessionAuth(), mfaDisableSelf)
+		userRoutes.GET("/bindings", Auth(dtos.ADMIN), roleBindingList)
+		userRoutes.POST("/bindings", Auth(dtos.ADMIN), roleBindingAdd)
+		userRoutes.DELETE("/bindings/:id", validateParam("id"), Auth(dtos.ADMIN), roleBindingDelete)
 		userRoutes.GET("/", Auth(dtos.READER), currentUserGet)
 		userRoutes.GET("/:id", validateParam("id"), Auth(dtos.ADMIN), userGet)
 		userRoutes.DELETE("/:id", validateParam("id"), Auth(dtos.ADMIN), userDelete)
+		userRoutes.POST("/:id/unlock", validateParam("id"), Auth(dtos.ADMIN), userUnlock)
+		userRoutes.POST("/:id/mfa/reset", validateParam("id"), Auth(dtos.ADMIN), userMfaReset)
+		userRoutes.PUT("/:id/mfa/required", validateParam("id"), Auth(dtos.ADMIN), userMfaRequired)
 		userRoutes.PATCH("/", Auth(dtos.ADMIN), userUpdate)
 		userRoutes.POST("/", Auth(dtos.ADMIN), userAdd)
 	}
@@ -78,6 +100,129 @@ func userGet(c *gin.Context) {
 	c.JSON(http.StatusOK, user)
 }
 
+// @Tags User
+// @Produce json
+// @Success 200 {object} dtos.PunqUser
+// @Router /backend/user/{id}/unlock [post]
+// @Param id path string false  "ID of the user"
+// @Security Bearer
+func userUnlock(c *gin.Context) {
+	user, err := services.UnlockUser(c.Param("id"))
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// @Tags User
+// @Produce json
+// @Success 200 {object} dtos.PunqUser
+// @Router /backend/user/{id}/mfa/reset [post]
+// @Param id path string false  "ID of the user"
+// @Security Bearer
+func userMfaReset(c *gin.Context) {
+	user, err := services.ResetTotp(c.Param("id"))
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// @Tags User
+// @Produce json
+// @Success 200 {object} dtos.PunqUser
+// @Router /backend/user/{id}/mfa/required [put]
+// @Param id path string false  "ID of the user"
+// @Param body body MfaRequiredInput true "MfaRequiredInput"
+// @Security Bearer
+func userMfaRequired(c *gin.Context) {
+	var data MfaRequiredInput
+	err := c.MustBindWith(&data, binding.JSON)
+	if err != nil {
+		utils.MalformedMessage(c, err.Error())
+		return
+	}
+	user, err := services.SetMfaRequired(c.Param("id"), data.Required)
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// @Tags User
+// @Produce json
+// @Success 200 {object} dtos.PunqTotpEnrollment
+// @Router /backend/user/mfa/enroll [post]
+// @Security Bearer
+func mfaEnrollSelf(c *gin.Context) {
+	user := services.GetGinContextUser(c)
+	if user == nil {
+		utils.Unauthorized(c, "Unauthorized")
+		return
+	}
+	enrollment, err := services.BeginTotpEnrollment(user)
+	if err != nil {
+		utils.MalformedMessage(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// @Tags User
+// @Produce json
+// @Success 200 {array} string
+// @Router /backend/user/mfa/confirm [post]
+// @Param body body MfaCodeInput true "MfaCodeInput"
+// @Security Bearer
+func mfaConfirmSelf(c *gin.Context) {
+	var data MfaCodeInput
+	err := c.MustBindWith(&data, binding.JSON)
+	if err != nil {
+		utils.MalformedMessage(c, err.Error())
+		return
+	}
+	user := services.GetGinContextUser(c)
+	if user == nil {
+		utils.Unauthorized(c, "Unauthorized")
+		return
+	}
+	recoveryCodes, err := services.ConfirmTotpEnrollment(user, data.Code)
+	if err != nil {
+		utils.MalformedMessage(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, recoveryCodes)
+}
+
+// @Tags User
+// @Produce json
+// @Success 200
+// @Router /backend/user/mfa [delete]
+// @Param body body MfaCodeInput true "MfaCodeInput"
+// @Security Bearer
+func mfaDisableSelf(c *gin.Context) {
+	var data MfaCodeInput
+	err := c.MustBindWith(&data, binding.JSON)
+	if err != nil {
+		utils.MalformedMessage(c, err.Error())
+		return
+	}
+	user := services.GetGinContextUser(c)
+	if user == nil {
+		utils.Unauthorized(c, "Unauthorized")
+		return
+	}
+	err = services.DisableTotp(user, data.Code)
+	if err != nil {
+		utils.MalformedMessage(c, err.Error())
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
 // @Tags User
 // @Produce json
 // @Success 200 {object} dtos.PunqUser
@@ -119,3 +264,159 @@ func userAdd(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, user)
 }
+
+// @Tags User
+// @Produce json
+// @Success 200 {array} dtos.PunqRoleBinding
+// @Router /backend/user/bindings [get]
+// @Param userId query string false "only bindings of this user (including group bindings)"
+// @Param groupId query string false "only bindings of this group"
+// @Security Bearer
+func roleBindingList(c *gin.Context) {
+	if userId := c.Query("userId"); userId != "" {
+		c.JSON(http.StatusOK, services.ListRoleBindingsForUser(userId))
+		return
+	}
+	if groupId := c.Query("groupId"); groupId != "" {
+		c.JSON(http.StatusOK, services.ListRoleBindingsForGroup(groupId))
+		return
+	}
+	c.JSON(http.StatusOK, services.ListRoleBindings())
+}
+
+// @Tags User
+// @Produce json
+// @Success 200 {object} dtos.PunqRoleBinding
+// @Router /backend/user/bindings [post]
+// @Param body body dtos.PunqRoleBindingCreateInput false "PunqRoleBindingCreateInput"
+// @Security Bearer
+func roleBindingAdd(c *gin.Context) {
+	var data dtos.PunqRoleBindingCreateInput
+	err := c.MustBindWith(&data, binding.JSON)
+	if err != nil {
+		utils.MalformedMessage(c, err.Error())
+		return
+	}
+	roleBinding, err := services.AddRoleBinding(data)
+	if err != nil {
+		utils.MalformedMessage(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, roleBinding)
+}
+
+// @Tags User
+// @Produce json
+// @Success 200
+// @Router /backend/user/bindings/{id} [delete]
+// @Param id path string true "ID of the role binding"
+// @Security Bearer
+func roleBindingDelete(c *gin.Context) {
+	err := services.DeleteRoleBinding(c.Param("id"))
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// @Tags User
+// @Produce json
+// @Success 200 {array} dtos.PunqApiToken
+// @Router /backend/user/tokens [get]
+// @Param userId query string false "tokens of another user (ADMIN only)"
+// @Security Bearer
+func apiTokenList(c *gin.Context) {
+	user := services.GetGinContextUser(c)
+	userId := user.Id
+	if otherUserId := c.Query("userId"); otherUserId != "" && user.AccessLevel >= dtos.ADMIN {
+		userId = otherUserId
+	}
+	c.JSON(http.StatusOK, services.ListApiTokens(userId))
+}
+
+// @Tags User
+// @Produce json
+// @Success 200 {object} dtos.PunqApiTokenCreated
+// @Router /backend/user/tokens [post]
+// @Param body body dtos.PunqApiTokenCreateInput false "PunqApiTokenCreateInput"
+// @Security Bearer
+func apiTokenAdd(c *gin.Context) {
+	var data dtos.PunqApiTokenCreateInput
+	err := c.MustBindWith(&data, binding.JSON)
+	if err != nil {
+		utils.MalformedMessage(c, err.Error())
+		return
+	}
+	token, err := services.AddApiToken(services.GetGinContextUser(c).Id, data)
+	if err != nil {
+		utils.MalformedMessage(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, token)
+}
+
+// @Tags User
+// @Produce json
+// @Success 200
+// @Router /backend/user/tokens/{id} [delete]
+// @Param id path string true "ID of the api token"
+// @Security Bearer
+func apiTokenRevoke(c *gin.Context) {
+	user := services.GetGinContextUser(c)
+	ownerId := user.Id
+	if user.AccessLevel >= dtos.ADMIN {
+		// admins may revoke any token
+		ownerId = ""
+	}
+	err := services.RevokeApiToken(c.Param("id"), ownerId)
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// @Tags User
+// @Produce json
+// @Success 200 {array} dtos.PunqSession
+// @Router /backend/user/sessions [get]
+// @Param userId query string false "sessions of a single user (ADMIN only, default: all users)"
+// @Security Bearer
+func sessionList(c *gin.Context) {
+	user := services.GetGinContextUser(c)
+	userId := user.Id
+	if user.AccessLevel >= dtos.ADMIN {
+		// admins see the sessions of all users
+		userId = c.Query("userId")
+	}
+
+	sessions := services.ListSessions(userId)
+	if claims := services.GetGinContextClaims(c); claims != nil {
+		for index := range sessions {
+			sessions[index].Current = sessions[index].Id == claims.SessionId
+		}
+	}
+	c.JSON(http.StatusOK, sessions)
+}
+
+// @Tags User
+// @Produce json
+// @Success 200
+// @Router /backend/user/sessions/{id} [delete]
+// @Param id path string true "ID of the session"
+// @Security Bearer
+func sessionDelete(c *gin.Context) {
+	user := services.GetGinContextUser(c)
+	ownerId := user.Id
+	if user.AccessLevel >= dtos.ADMIN {
+		// admins may sign out any session
+		ownerId = ""
+	}
+	err := services.DeleteSession(c.Param("id"), ownerId)
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	c.Status(http.StatusOK)
+}
@@ -1,19 +1,23 @@
 package operator
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
-	"os"
 	"strings"
+	"sync"
+	"time"
 
-	"github.com/creack/pty"
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 	"github.com/mogenius/punq/dtos"
 	"github.com/mogenius/punq/kubernetes"
+	"github.com/mogenius/punq/services"
 	"github.com/mogenius/punq/utils"
+	"k8s.io/client-go/tools/remotecommand"
 )
 
 type windowSize struct {
@@ -58,6 +62,9 @@ func connectWs(c *gin.Context) {
 		return
 	}
 
+	release := services.StartImpersonation(services.GetGinContextUser(c), &contextId)
+	defer release()
+
 	ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		log.Printf("Failed to upgrade ws: %s", err.Error())
@@ -71,37 +78,62 @@ func connectWs(c *gin.Context) {
 		return
 	}
 
-	selectedShell := FindValidShell(container, namespace, podName)
-
-	cmd := utils.RunOnLocalShell(fmt.Sprintf("kubectl exec -it -c %s -n %s %s %s -- %s -c 'echo -e \"\033[1;34mConnected to %s/%s/%s using \"$(echo $0)\". Happy hacking!\033[0m 🚀 🚀 🚀\"; %s'", container, namespace, podName, kubernetes.ContextFlag(&contextId), selectedShell, namespace, podName, container, selectedShell))
-	fmt.Println(cmd.String())
-	cmd.Env = append(os.Environ(), "TERM=xterm-color")
+	output := &wsWriter{ws: ws}
 
-	tty, err := pty.Start(cmd)
-	if err != nil {
-		log.Printf("Unable to start pty/cmd: %s", err.Error())
-		ws.WriteMessage(websocket.TextMessage, []byte(err.Error()))
-		return
+	// forced sign-out of the session closes the shell
+	if claims := services.GetGinContextClaims(c); claims != nil {
+		releaseSession := services.TrackSessionConnection(claims.SessionId, func() {
+			output.Write([]byte("Session has been signed out."))
+			output.close(websocket.ClosePolicyViolation, "signed out")
+		})
+		defer releaseSession()
 	}
 
+	sessionStart := time.Now()
+	sessionStatus := http.StatusSwitchingProtocols
 	defer func() {
-		cmd.Process.Kill()
-		cmd.Process.Wait()
-		tty.Close()
-		ws.Close()
+		recordExecSession(c, contextId, namespace, podName, container, sessionStatus, time.Since(sessionStart))
 	}()
 
+	selectedShell := kubernetes.FindValidShell(namespace, podName, container, &contextId)
+
+	output.Write([]byte(fmt.Sprintf("\033[1;34mConnected to %s/%s/%s using %s. Happy hacking!\033[0m 🚀 🚀 🚀\r\n", namespace, podName, container, selectedShell)))
+
+	stdinReader, stdinWriter := io.Pipe()
+	sizeQueue := make(wsTerminalSizeQueue, 1)
+	execCtx, cancelExec := context.WithCancel(context.Background())
+	execDone := make(chan struct{})
+
 	go func() {
-		for {
-			buf := make([]byte, 1024)
-			read, err := tty.Read(buf)
-			if err != nil {
-				ws.WriteMessage(websocket.TextMessage, []byte(err.Error()))
-				log.Printf("Unable to read from pty/cmd: %s", err.Error())
+		defer close(execDone)
+		err := kubernetes.ExecInPod(execCtx, namespace, podName, container, []string{selectedShell}, kubernetes.ExecStreams{
+			Stdin:     stdinReader,
+			Stdout:    output,
+			Tty:       true,
+			SizeQueue: sizeQueue,
+		}, &contextId)
+
+		// the exit code of the shell is sent as reason of the close message, e.g. "exit code 130"
+		exitCode, exited := kubernetes.ExecExitCode(err)
+		if !exited {
+			if execCtx.Err() != nil {
+				// closed by the client
 				return
 			}
-			ws.WriteMessage(websocket.BinaryMessage, buf[:read])
+			log.Printf("Exec failed: %s", err.Error())
+			sessionStatus = http.StatusInternalServerError
+			output.Write([]byte(err.Error()))
+			output.close(websocket.CloseInternalServerErr, "exec failed")
+			return
 		}
+		output.close(websocket.CloseNormalClosure, fmt.Sprintf("exit code %d", exitCode))
+	}()
+
+	defer func() {
+		cancelExec()
+		stdinWriter.Close()
+		close(sizeQueue)
+		<-execDone
 	}()
 
 	for {
@@ -120,26 +152,67 @@ func connectWs(c *gin.Context) {
 				log.Printf("%s", err.Error())
 				continue
 			}
-
-			if err := pty.Setsize(tty, &pty.Winsize{Rows: uint16(resizeMessage.Rows), Cols: uint16(resizeMessage.Cols)}); err != nil {
-				log.Printf("Unable to resize: %s", err.Error())
-				continue
-			}
+			sizeQueue.push(remotecommand.TerminalSize{Width: resizeMessage.Cols, Height: resizeMessage.Rows})
 			continue
 		}
 
-		tty.Write(reader)
+		if _, err := stdinWriter.Write(reader); err != nil {
+			return
+		}
+	}
+}
+
+// wsWriter sends the output of the shell as binary messages (gorilla websockets allow one writer at a time).
+type wsWriter struct {
+	lock   sync.Mutex
+	ws     *websocket.Conn
+	closed bool
+}
+
+func (w *wsWriter) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.closed {
+		return 0, io.ErrClosedPipe
+	}
+	if err := w.ws.WriteMessage(websocket.BinaryMessage, p); err != nil {
+		return 0, err
 	}
+	return len(p), nil
 }
 
-func FindValidShell(container string, namespace string, podName string) string {
-	availableShells := []string{"bash", "ash", "zsh", "sh", "ksh", "csh"}
-	for _, shell := range availableShells {
-		cmd := utils.RunOnLocalShell(fmt.Sprintf("kubectl exec -it -c %s -n %s %s -- sh -c '%s'", container, namespace, podName, shell))
-		err := cmd.Run()
-		if err == nil {
-			return shell
-		}
+func (w *wsWriter) close(code int, reason string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.closed {
+		return
+	}
+	w.closed = true
+	w.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(time.Second))
+	w.ws.Close()
+}
+
+// wsTerminalSizeQueue passes the resize messages (\x04{"rows":..,"cols":..}) of the client to the terminal.
+type wsTerminalSizeQueue chan remotecommand.TerminalSize
+
+func (q wsTerminalSizeQueue) Next() *remotecommand.TerminalSize {
+	size, ok := <-q
+	if !ok {
+		return nil
+	}
+	return &size
+}
+
+// push replaces a pending size which has not been sent yet.
+func (q wsTerminalSizeQueue) push(size remotecommand.TerminalSize) {
+	select {
+	case <-q:
+	default:
+	}
+	select {
+	case q <- size:
+	default:
 	}
-	return "sh"
 }
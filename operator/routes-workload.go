@@ -38,7 +38,7 @@ func InitWorkloadRoutes(router *gin.Engine) {
 		workloadRoutes.GET("/available-resources", Auth(dtos.READER), allKubernetesResources)
 
 		// namespace
-		namespaceWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_NAMESPACE)), Auth(dtos.USER), RequireContextId())
+		namespaceWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_NAMESPACE)), RequireContextId(), ClusterScoped(), Auth(dtos.USER))
 		{
 			namespaceWorkloadRoutes.GET("/", allNamespaces)                                                    // PARAM: -
 			namespaceWorkloadRoutes.GET("/describe/:name", validateParam("name"), describeNamespaces)          // PARAM: name
@@ -48,7 +48,7 @@ func InitWorkloadRoutes(router *gin.Engine) {
 		}
 
 		// pod
-		podWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_POD)), Auth(dtos.USER), RequireContextId())
+		podWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_POD)), RequireContextId(), Auth(dtos.USER))
 		{
 			podWorkloadRoutes.GET("/", allPods)
 			podWorkloadRoutes.GET("/describe/:namespace/:name", validateParam("namespace", "name"), describePod) // PARAM: namespace
@@ -59,7 +59,7 @@ func InitWorkloadRoutes(router *gin.Engine) {
 		}
 
 		// deployment
-		deploymentWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_DEPLOYMENT)), Auth(dtos.USER), RequireContextId())
+		deploymentWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_DEPLOYMENT)), RequireContextId(), Auth(dtos.USER))
 		{
 			deploymentWorkloadRoutes.GET("/", allDeployments)                                                                  // PARAM: namespace
 			deploymentWorkloadRoutes.GET("/describe/:namespace/:name", validateParam("namespace", "name"), describeDeployment) // PARAM: namespace, name
@@ -69,7 +69,7 @@ func InitWorkloadRoutes(router *gin.Engine) {
 		}
 
 		// service
-		serviceWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_SERVICE)), Auth(dtos.USER), RequireContextId())
+		serviceWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_SERVICE)), RequireContextId(), Auth(dtos.USER))
 		{
 			serviceWorkloadRoutes.GET("/", allServices)                                                                  // PARAM: namespace
 			serviceWorkloadRoutes.GET("/describe/:namespace/:name", validateParam("namespace", "name"), describeService) // PARAM: namespace, name
@@ -79,7 +79,7 @@ func InitWorkloadRoutes(router *gin.Engine) {
 		}
 
 		// ingress
-		ingressWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_INGRESS)), Auth(dtos.USER), RequireContextId())
+		ingressWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_INGRESS)), RequireContextId(), Auth(dtos.USER))
 		{
 			ingressWorkloadRoutes.GET("/", allIngresses)                                                                 // PARAM: namespace
 			ingressWorkloadRoutes.GET("/describe/:namespace/:name", validateParam("namespace", "name"), describeIngress) // PARAM: namespace, name
@@ -89,7 +89,7 @@ func InitWorkloadRoutes(router *gin.Engine) {
 		}
 
 		// configmap
-		configmapWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_CONFIG_MAP)), Auth(dtos.USER), RequireContextId())
+		configmapWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_CONFIG_MAP)), RequireContextId(), Auth(dtos.USER))
 		{
 			configmapWorkloadRoutes.GET("/", allConfigmaps)                                                                  // PARAM: namespace
 			configmapWorkloadRoutes.GET("/describe/:namespace/:name", validateParam("namespace", "name"), describeConfigmap) // PARAM: namespace, name
@@ -99,7 +99,7 @@ func InitWorkloadRoutes(router *gin.Engine) {
 		}
 
 		// secret
-		secretWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_SECRET)), Auth(dtos.ADMIN), RequireContextId())
+		secretWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_SECRET)), RequireContextId(), Auth(dtos.ADMIN))
 		{
 			secretWorkloadRoutes.GET("/", allSecrets)                                                                  // PARAM: namespace
 			secretWorkloadRoutes.GET("/describe/:namespace/:name", validateParam("namespace", "name"), describeSecret) // PARAM: namespace, name
@@ -109,14 +109,14 @@ func InitWorkloadRoutes(router *gin.Engine) {
 		}
 
 		// node
-		nodeWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_NODE)), Auth(dtos.USER), RequireContextId())
+		nodeWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_NODE)), RequireContextId(), ClusterScoped(), Auth(dtos.USER))
 		{
 			nodeWorkloadRoutes.GET("/", allNodes)                                          // -
 			nodeWorkloadRoutes.GET("/describe/:name", validateParam("name"), describeNode) // PARAM: namespace
 		}
 
 		// daemon-set
-		daemonSetWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_DAEMON_SET)), Auth(dtos.USER), RequireContextId())
+		daemonSetWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_DAEMON_SET)), RequireContextId(), Auth(dtos.USER))
 		{
 			daemonSetWorkloadRoutes.GET("/", allDaemonSets)                                                                  // PARAM: namespace
 			daemonSetWorkloadRoutes.GET("/describe/:namespace/:name", validateParam("namespace", "name"), describeDaemonSet) // PARAM: namespace, name
@@ -127,7 +127,7 @@ func InitWorkloadRoutes(router *gin.Engine) {
 		}
 
 		// stateful-set
-		statefulSetWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_STATEFUL_SET)), Auth(dtos.USER), RequireContextId())
+		statefulSetWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_STATEFUL_SET)), RequireContextId(), Auth(dtos.USER))
 		{
 			statefulSetWorkloadRoutes.GET("/", allStatefulSets)                                                                  // PARAM: namespace
 			statefulSetWorkloadRoutes.GET("/describe/:namespace/:name", validateParam("namespace", "name"), describeStatefulSet) // PARAM: namespace, name
@@ -137,7 +137,7 @@ func InitWorkloadRoutes(router *gin.Engine) {
 		}
 
 		// job
-		jobWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_JOB)), Auth(dtos.USER), RequireContextId())
+		jobWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_JOB)), RequireContextId(), Auth(dtos.USER))
 		{
 			jobWorkloadRoutes.GET("/", allJobs)                                                                  // PARAM: namespace
 			jobWorkloadRoutes.GET("/describe/:namespace/:name", validateParam("namespace", "name"), describeJob) // PARAM: namespace, name
@@ -147,7 +147,7 @@ func InitWorkloadRoutes(router *gin.Engine) {
 		}
 
 		// cron-job
-		cronJobWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_CRON_JOB)), Auth(dtos.USER), RequireContextId())
+		cronJobWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_CRON_JOB)), RequireContextId(), Auth(dtos.USER))
 		{
 			cronJobWorkloadRoutes.GET("/", allCronJobs)                                                                  // PARAM: namespace
 			cronJobWorkloadRoutes.GET("/describe/:namespace/:name", validateParam("namespace", "name"), describeCronJob) // PARAM: namespace, name
@@ -157,7 +157,7 @@ func InitWorkloadRoutes(router *gin.Engine) {
 		}
 
 		// replicaset
-		replicaSetWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_REPLICA_SET)), Auth(dtos.USER), RequireContextId())
+		replicaSetWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_REPLICA_SET)), RequireContextId(), Auth(dtos.USER))
 		{
 			replicaSetWorkloadRoutes.GET("/", allReplicasets)                                                                  // PARAM: namespace
 			replicaSetWorkloadRoutes.GET("/describe/:namespace/:name", validateParam("namespace", "name"), describeReplicaset) // PARAM: namespace, name
@@ -167,7 +167,7 @@ func InitWorkloadRoutes(router *gin.Engine) {
 		}
 
 		// persistent-volume
-		persistentVolumeWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_PERSISTENT_VOLUME)), Auth(dtos.ADMIN), RequireContextId())
+		persistentVolumeWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_PERSISTENT_VOLUME)), RequireContextId(), ClusterScoped(), Auth(dtos.ADMIN))
 		{
 			persistentVolumeWorkloadRoutes.GET("/", allPersistentVolumes)                                          // PARAM: -
 			persistentVolumeWorkloadRoutes.GET("/describe/:name", validateParam("name"), describePersistentVolume) // PARAM: name
@@ -197,14 +197,14 @@ func InitWorkloadRoutes(router *gin.Engine) {
 		}
 
 		// event
-		eventWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_EVENT)), Auth(dtos.USER), RequireContextId())
+		eventWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_EVENT)), RequireContextId(), Auth(dtos.USER))
 		{
 			eventWorkloadRoutes.GET("/", allEvents)                                                                  // PARAM: namespace
 			eventWorkloadRoutes.GET("/describe/:namespace/:name", validateParam("namespace", "name"), describeEvent) // PARAM: namespace, name
 		}
 
 		// certificate
-		certificateWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_CERTIFICATE)), Auth(dtos.USER), RequireContextId())
+		certificateWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_CERTIFICATE)), RequireContextId(), Auth(dtos.USER))
 		{
 			certificateWorkloadRoutes.GET("/", allCertificates)                                                                  // PARAM: namespace
 			certificateWorkloadRoutes.GET("/describe/:namespace/:name", validateParam("namespace", "name"), describeCertificate) // PARAM: namespace, name
@@ -214,7 +214,7 @@ func InitWorkloadRoutes(router *gin.Engine) {
 		}
 
 		// certificate-request
-		certificateRequestWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_CERTIFICATE_REQUEST)), Auth(dtos.USER), RequireContextId())
+		certificateRequestWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_CERTIFICATE_REQUEST)), RequireContextId(), Auth(dtos.USER))
 		{
 			certificateRequestWorkloadRoutes.GET("/", allCertificateRequests)                                                                  // PARAM: namespace
 			certificateRequestWorkloadRoutes.GET("/describe/:namespace/:name", validateParam("namespace", "name"), describeCertificateRequest) // PARAM: namespace, name
@@ -224,7 +224,7 @@ func InitWorkloadRoutes(router *gin.Engine) {
 		}
 
 		// orders
-		ordersWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_ORDER)), Auth(dtos.USER), RequireContextId())
+		ordersWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_ORDER)), RequireContextId(), Auth(dtos.USER))
 		{
 			ordersWorkloadRoutes.GET("/", allOrders)                                                                  // PARAM: namespace
 			ordersWorkloadRoutes.GET("/describe/:namespace/:name", validateParam("namespace", "name"), describeOrder) // PARAM: namespace, name
@@ -234,7 +234,7 @@ func InitWorkloadRoutes(router *gin.Engine) {
 		}
 
 		// issuer
-		issuerWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_ISSUER)), Auth(dtos.USER), RequireContextId())
+		issuerWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_ISSUER)), RequireContextId(), Auth(dtos.USER))
 		{
 			issuerWorkloadRoutes.GET("/", allIssuers)                                                                  // PARAM: namespace
 			issuerWorkloadRoutes.GET("/describe/:namespace/:name", validateParam("namespace", "name"), describeIssuer) // PARAM: namespace, name
@@ -244,7 +244,7 @@ func InitWorkloadRoutes(router *gin.Engine) {
 		}
 
 		// cluster-issuer
-		clusterIssuerWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_CLUSTER_ISSUER)), Auth(dtos.ADMIN), RequireContextId())
+		clusterIssuerWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_CLUSTER_ISSUER)), RequireContextId(), ClusterScoped(), Auth(dtos.ADMIN))
 		{
 			clusterIssuerWorkloadRoutes.GET("/", allClusterIssuers)                                          // PARAM: -
 			clusterIssuerWorkloadRoutes.GET("/describe/:name", validateParam("name"), describeClusterIssuer) // PARAM: name
@@ -254,7 +254,7 @@ func InitWorkloadRoutes(router *gin.Engine) {
 		}
 
 		// service-account
-		serviceAccountWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_SERVICE_ACCOUNT)), Auth(dtos.ADMIN), RequireContextId())
+		serviceAccountWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_SERVICE_ACCOUNT)), RequireContextId(), Auth(dtos.ADMIN))
 		{
 			serviceAccountWorkloadRoutes.GET("/", allServiceAccounts)                                                                  // PARAM: namespace
 			serviceAccountWorkloadRoutes.GET("/describe/:namespace/:name", validateParam("namespace", "name"), describeServiceAccount) // PARAM: namespace, name
@@ -284,7 +284,7 @@ func InitWorkloadRoutes(router *gin.Engine) {
 		}
 
 		// cluster-role
-		clusterRoleWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_CLUSTER_ROLE)), Auth(dtos.ADMIN), RequireContextId())
+		clusterRoleWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_CLUSTER_ROLE)), RequireContextId(), ClusterScoped(), Auth(dtos.ADMIN))
 		{
 			clusterRoleWorkloadRoutes.GET("/", allClusterRoles)                                          // PARAM: -
 			clusterRoleWorkloadRoutes.GET("/describe/:name", validateParam("name"), describeClusterRole) // PARAM: name
@@ -294,7 +294,7 @@ func InitWorkloadRoutes(router *gin.Engine) {
 		}
 
 		// cluster-role-binding
-		clusterRoleBindingWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_CLUSTER_ROLE_BINDING)), Auth(dtos.ADMIN), RequireContextId())
+		clusterRoleBindingWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_CLUSTER_ROLE_BINDING)), RequireContextId(), ClusterScoped(), Auth(dtos.ADMIN))
 		{
 			clusterRoleBindingWorkloadRoutes.GET("/", allClusterRoleBindings)                                          // PARAM: -
 			clusterRoleBindingWorkloadRoutes.GET("/describe/:name", validateParam("name"), describeClusterRoleBinding) // PARAM: name
@@ -304,7 +304,7 @@ func InitWorkloadRoutes(router *gin.Engine) {
 		}
 
 		// volume-attachment
-		volumeAttachmentWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_VOLUME_ATTACHMENT)), Auth(dtos.ADMIN), RequireContextId())
+		volumeAttachmentWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_VOLUME_ATTACHMENT)), RequireContextId(), ClusterScoped(), Auth(dtos.ADMIN))
 		{
 			volumeAttachmentWorkloadRoutes.GET("/", allVolumeAttachments)                                          // PARAM: -
 			volumeAttachmentWorkloadRoutes.GET("/describe/:name", validateParam("name"), describeVolumeAttachment) // PARAM: name
@@ -324,7 +324,7 @@ func InitWorkloadRoutes(router *gin.Engine) {
 		}
 
 		// storage-class
-		storageClassWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_STORAGE_CLASS)), RequireContextId())
+		storageClassWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_STORAGE_CLASS)), RequireContextId(), ClusterScoped())
 		{
 			storageClassWorkloadRoutes.GET("/", Auth(dtos.USER), allStorageClasses)                                         // PARAM: namespace
 			storageClassWorkloadRoutes.GET("/describe/:name", Auth(dtos.USER), validateParam("name"), describeStorageClass) // PARAM: namespace, name
@@ -334,7 +334,7 @@ func InitWorkloadRoutes(router *gin.Engine) {
 		}
 
 		// crds
-		crdsWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_CUSTOM_RESOURCE_DEFINITION)), Auth(dtos.ADMIN), RequireContextId())
+		crdsWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_CUSTOM_RESOURCE_DEFINITION)), RequireContextId(), ClusterScoped(), Auth(dtos.ADMIN))
 		{
 			crdsWorkloadRoutes.GET("/", allCrds)                                                            // PARAM: -
 			crdsWorkloadRoutes.GET("/describe/:name", validateParam("name"), Auth(dtos.ADMIN), describeCrd) // PARAM: name
@@ -344,7 +344,7 @@ func InitWorkloadRoutes(router *gin.Engine) {
 		}
 
 		// endpoints
-		endpointsWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_ENDPOINT)), Auth(dtos.USER), RequireContextId())
+		endpointsWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_ENDPOINT)), RequireContextId(), Auth(dtos.USER))
 		{
 			endpointsWorkloadRoutes.GET("/", allEndpoints)                                                                  // PARAM: namespace
 			endpointsWorkloadRoutes.GET("/describe/:namespace/:name", validateParam("namespace", "name"), describeEndpoint) // PARAM: namespace, name
@@ -354,7 +354,7 @@ func InitWorkloadRoutes(router *gin.Engine) {
 		}
 
 		// leases
-		leasesWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_LEASE)), Auth(dtos.USER), RequireContextId())
+		leasesWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_LEASE)), RequireContextId(), Auth(dtos.USER))
 		{
 			leasesWorkloadRoutes.GET("/", allLeases)                                                                  // PARAM: namespace
 			leasesWorkloadRoutes.GET("/describe/:namespace/:name", validateParam("namespace", "name"), describeLease) // PARAM: namespace, name
@@ -364,7 +364,7 @@ func InitWorkloadRoutes(router *gin.Engine) {
 		}
 
 		// priority-classes
-		priorityClassesWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_PRIORITY_CLASS)), Auth(dtos.ADMIN), RequireContextId())
+		priorityClassesWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_PRIORITY_CLASS)), RequireContextId(), ClusterScoped(), Auth(dtos.ADMIN))
 		{
 			priorityClassesWorkloadRoutes.GET("/", allPriorityClasses)                                         // PARAM: -
 			priorityClassesWorkloadRoutes.GET("/describe/:name", validateParam("name"), describePriorityClass) // PARAM: name
@@ -374,7 +374,7 @@ func InitWorkloadRoutes(router *gin.Engine) {
 		}
 
 		// volume-snapshots
-		volumeSnapshotsWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_VOLUME_SNAPSHOT)), Auth(dtos.USER), RequireContextId())
+		volumeSnapshotsWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_VOLUME_SNAPSHOT)), RequireContextId(), Auth(dtos.USER))
 		{
 			volumeSnapshotsWorkloadRoutes.GET("/", allVolumeSnapshots)                                                                  // PARAM: namespace
 			volumeSnapshotsWorkloadRoutes.GET("/describe/:namespace/:name", validateParam("namespace", "name"), describeVolumeSnapshot) // PARAM: namespace, name
@@ -384,7 +384,7 @@ func InitWorkloadRoutes(router *gin.Engine) {
 		}
 
 		// resource-quota
-		resourceQuotaWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_RESOURCE_QUOTA)), Auth(dtos.ADMIN), RequireContextId())
+		resourceQuotaWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_RESOURCE_QUOTA)), RequireContextId(), Auth(dtos.ADMIN))
 		{
 			resourceQuotaWorkloadRoutes.GET("/", allResourceQuotas)                                                                  // PARAM: namespace
 			resourceQuotaWorkloadRoutes.GET("/describe/:namespace/:name", validateParam("namespace", "name"), describeResourceQuota) // PARAM: namespace, name
@@ -394,7 +394,7 @@ func InitWorkloadRoutes(router *gin.Engine) {
 		}
 
 		// ingress-classes
-		ingressClassesWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_INGRESS_CLASS)), Auth(dtos.ADMIN), RequireContextId())
+		ingressClassesWorkloadRoutes := workloadRoutes.Group(fmt.Sprintf("/%s", strings.ToLower(kubernetes.RES_INGRESS_CLASS)), RequireContextId(), ClusterScoped(), Auth(dtos.ADMIN))
 		{
 			ingressClassesWorkloadRoutes.GET("/", allIngressClasses)                                         // PARAM: -
 			ingressClassesWorkloadRoutes.GET("/describe/:name", validateParam("name"), describeIngressClass) // PARAM: name
@@ -402,6 +402,17 @@ func InitWorkloadRoutes(router *gin.Engine) {
 			ingressClassesWorkloadRoutes.PATCH("/", patchIngressClass)                                       // BODY: json-object
 			ingressClassesWorkloadRoutes.POST("/", createIngressClass)                                       // BODY: yaml-object
 		}
+
+		// generic (any resource incl. custom resources, group "core" for the core api)
+		genericWorkloadRoutes := workloadRoutes.Group("/generic", RequireContextId())
+		{
+			genericWorkloadRoutes.GET("/", Auth(dtos.READER), allGenericResources)                                      // PARAM: -
+			genericWorkloadRoutes.GET("/:group/:version/:resource", RequireGenericResource(), allGenericWorkloads)      // PARAM: namespace, labelSelector
+			genericWorkloadRoutes.GET("/:group/:version/:resource/:name", RequireGenericResource(), getGenericWorkload) // PARAM: namespace
+			genericWorkloadRoutes.DELETE("/:group/:version/:resource/:name", RequireGenericResource(), deleteGeneric)   // PARAM: namespace
+			genericWorkloadRoutes.PATCH("/:group/:version/:resource/:name", RequireGenericResource(), patchGeneric)     // PARAM: namespace, BODY: json-merge-patch
+			genericWorkloadRoutes.POST("/:group/:version/:resource", RequireGenericResource(), createGeneric)           // BODY: yaml-object
+		}
 	}
 }
 
@@ -3256,3 +3267,119 @@ func createIngressClass(c *gin.Context) {
 	}
 	utils.HttpRespondForWorkloadResult(c, kubernetes.CreateK8sIngressClass(data, services.GetGinContextId(c)))
 }
+
+// ---------------------- GENERIC ----------------------
+
+// @Tags Workloads
+// @Produce json
+// @Success 200 {array} dtos.GenericResource
+// @Router /backend/workload/generic/ [get]
+// @Security Bearer
+// @Param string header string true "X-Context-Id"
+func allGenericResources(c *gin.Context) {
+	resources, err := kubernetes.ListGenericResources(services.GetGinContextId(c))
+	if err != nil {
+		utils.MalformedMessage(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, resources)
+}
+
+// @Tags Workloads
+// @Produce json
+// @Success 200 {object} utils.K8sWorkloadResult
+// @Router /backend/workload/generic/{group}/{version}/{resource} [get]
+// @Param group path string true "api group (core for the core api)"
+// @Param version path string true "api version"
+// @Param resource path string true "resource (plural)"
+// @Param namespace query string false "namespace name"
+// @Param labelSelector query string false "label selector"
+// @Security Bearer
+// @Param string header string true "X-Context-Id"
+func allGenericWorkloads(c *gin.Context) {
+	namespace := c.Query("namespace")
+	labelSelector := c.Query("labelSelector")
+	utils.HttpRespondForWorkloadResult(c, kubernetes.AllK8sGenericWorkloads(getGinGenericResource(c), namespace, labelSelector, services.GetGinContextId(c)))
+}
+
+// @Tags Workloads
+// @Produce json
+// @Success 200 {object} utils.K8sWorkloadResult
+// @Router /backend/workload/generic/{group}/{version}/{resource}/{name} [get]
+// @Param group path string true "api group (core for the core api)"
+// @Param version path string true "api version"
+// @Param resource path string true "resource (plural)"
+// @Param name path string true "name"
+// @Param namespace query string false "namespace name (required for namespaced resources)"
+// @Security Bearer
+// @Param string header string true "X-Context-Id"
+func getGenericWorkload(c *gin.Context) {
+	namespace := c.Query("namespace")
+	name := c.Param("name")
+	utils.HttpRespondForWorkloadResult(c, kubernetes.GetK8sGenericWorkload(getGinGenericResource(c), namespace, name, services.GetGinContextId(c)))
+}
+
+// @Tags Workloads
+// @Produce json
+// @Success 200
+// @Router /backend/workload/generic/{group}/{version}/{resource}/{name} [delete]
+// @Param group path string true "api group (core for the core api)"
+// @Param version path string true "api version"
+// @Param resource path string true "resource (plural)"
+// @Param name path string true "name"
+// @Param namespace query string false "namespace name (required for namespaced resources)"
+// @Security Bearer
+// @Param string header string true "X-Context-Id"
+func deleteGeneric(c *gin.Context) {
+	namespace := c.Query("namespace")
+	name := c.Param("name")
+	err := kubernetes.DeleteK8sGenericWorkloadBy(getGinGenericResource(c), namespace, name, services.GetGinContextId(c))
+	if err != nil {
+		utils.MalformedMessage(c, err.Error())
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// @Tags Workloads
+// @Produce json
+// @Success 200 {object} utils.K8sWorkloadResult
+// @Router /backend/workload/generic/{group}/{version}/{resource}/{name} [patch]
+// @Param group path string true "api group (core for the core api)"
+// @Param version path string true "api version"
+// @Param resource path string true "resource (plural)"
+// @Param name path string true "name"
+// @Param namespace query string false "namespace name (required for namespaced resources)"
+// @Security Bearer
+// @Param string header string true "X-Context-Id"
+func patchGeneric(c *gin.Context) {
+	namespace := c.Query("namespace")
+	name := c.Param("name")
+	patch, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		utils.MalformedMessage(c, err.Error())
+		return
+	}
+	utils.HttpRespondForWorkloadResult(c, kubernetes.PatchK8sGenericWorkload(getGinGenericResource(c), namespace, name, patch, services.GetGinContextId(c)))
+}
+
+// @Tags Workloads
+// @Produce json
+// @Success 200 {object} utils.K8sWorkloadResult
+// @Router /backend/workload/generic/{group}/{version}/{resource} [post]
+// @Param group path string true "api group (core for the core api)"
+// @Param version path string true "api version"
+// @Param resource path string true "resource (plural)"
+// @Param namespace query string false "namespace name (must match the manifest)"
+// @Security Bearer
+// @Param string header string true "X-Context-Id"
+func createGeneric(c *gin.Context) {
+	manifest, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		utils.MalformedMessage(c, err.Error())
+		return
+	}
+	// the namespace RequireGenericResource authorized
+	namespace := c.GetString("namespace")
+	utils.HttpRespondForWorkloadResult(c, kubernetes.CreateK8sGenericWorkload(getGinGenericResource(c), namespace, manifest, services.GetGinContextId(c)))
+}
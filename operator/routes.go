@@ -5,6 +5,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/mogenius/punq/dtos"
+	"github.com/mogenius/punq/kubernetes"
 	"github.com/mogenius/punq/structs"
 	punqVersion "github.com/mogenius/punq/version"
 )
@@ -12,6 +13,8 @@ import (
 func InitGeneralRoutes(router *gin.Engine) {
 	router.GET("/version", versionData)
 	router.GET("/providers", allProviders)
+	router.GET("/client-pool", Auth(dtos.ADMIN), clientPoolStats)
+	router.GET("/informer-cache", Auth(dtos.ADMIN), informerCacheStatus)
 }
 
 // @Tags Misc
@@ -30,3 +33,21 @@ func versionData(c *gin.Context) {
 func allProviders(c *gin.Context) {
 	c.JSON(http.StatusOK, dtos.ALL_PROVIDER)
 }
+
+// @Tags Misc
+// @Produce json
+// @Success 200 {object} dtos.KubeProviderPoolStats
+// @Router /backend/client-pool [get]
+// @Security Bearer
+func clientPoolStats(c *gin.Context) {
+	c.JSON(http.StatusOK, kubernetes.ProviderPoolStats())
+}
+
+// @Tags Misc
+// @Produce json
+// @Success 200 {array} dtos.InformerCacheStatus
+// @Router /backend/informer-cache [get]
+// @Security Bearer
+func informerCacheStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, kubernetes.InformerCacheStatus())
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mogenius/punq/dtos"
+	"github.com/mogenius/punq/logger"
+	"github.com/mogenius/punq/store"
+	"github.com/mogenius/punq/utils"
+)
+
+const (
+	ApiTokenScheme          = "Token"
+	ApiTokenPrefix          = "punq"
+	ApiTokenLastUsedMinutes = 1 // LastUsed is persisted at most once per interval
+)
+
+func hashApiTokenSecret(tokenSecret string) string {
+	hash := sha256.Sum256([]byte(tokenSecret))
+	return hex.EncodeToString(hash[:])
+}
+
+// ListApiTokens returns the tokens of a user (all tokens if userId is empty). Hashes are never returned.
+func ListApiTokens(userId string) []dtos.PunqApiToken {
+	tokens := []dtos.PunqApiToken{}
+
+	values, err := store.Values(utils.APITOKENSSECRET)
+	if err != nil {
+		logger.Log.Errorf("Failed to list '%s': %s", utils.APITOKENSSECRET, err.Error())
+		return tokens
+	}
+
+	for tokenId, tokenRaw := range values {
+		token := dtos.PunqApiToken{}
+		err := json.Unmarshal(tokenRaw, &token)
+		if err != nil {
+			logger.Log.Errorf("Failed to Unmarshal api token '%s'.", tokenId)
+			continue
+		}
+		if userId != "" && token.UserId != userId {
+			continue
+		}
+		token.TokenHash = ""
+		tokens = append(tokens, token)
+	}
+
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].Created < tokens[j].Created
+	})
+	return tokens
+}
+
+func getApiToken(id string) (*dtos.PunqApiToken, error) {
+	tokenRaw, err := store.Value(utils.APITOKENSSECRET, id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, errors.New("api token not found")
+		}
+		return nil, err
+	}
+
+	token := dtos.PunqApiToken{}
+	err = json.Unmarshal(tokenRaw, &token)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to Unmarshal api token '%s'.", id)
+		logger.Log.Error(msg)
+		return nil, errors.New(msg)
+	}
+	return &token, nil
+}
+
+func AddApiToken(userId string, input dtos.PunqApiTokenCreateInput) (*dtos.PunqApiTokenCreated, error) {
+	if input.Name == "" {
+		return nil, errors.New("token name is required")
+	}
+	if _, err := GetUser(userId); err != nil {
+		return nil, err
+	}
+	if input.ExpiresAt != "" {
+		expiresAt, err := time.Parse(time.RFC3339, input.ExpiresAt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expiresAt '%s' (RFC3339 expected)", input.ExpiresAt)
+		}
+		if time.Now().After(expiresAt) {
+			return nil, errors.New("expiresAt lies in the past")
+		}
+	}
+	for _, verb := range input.Verbs {
+		if !utils.ContainsEqual([]string{http.MethodGet, http.MethodPost, http.MethodPatch, http.MethodPut, http.MethodDelete}, strings.ToUpper(verb)) {
+			return nil, fmt.Errorf("invalid verb '%s' (valid: GET, POST, PATCH, PUT, DELETE)", verb)
+		}
+	}
+	for _, userToken := range ListApiTokens(userId) {
+		if userToken.Name == input.Name {
+			return nil, fmt.Errorf("Duplicated token name: '%s'", input.Name)
+		}
+	}
+
+	tokenSecret := utils.NanoIdExtraLong()
+	token := dtos.PunqApiToken{
+		Id:         utils.NanoId(),
+		UserId:     userId,
+		Name:       input.Name,
+		TokenHash:  hashApiTokenSecret(tokenSecret),
+		Contexts:   input.Contexts,
+		Namespaces: input.Namespaces,
+		Verbs:      input.Verbs,
+		ExpiresAt:  input.ExpiresAt,
+		Created:    time.Now().Format(time.RFC3339),
+	}
+
+	rawData, err := json.Marshal(token)
+	if err != nil {
+		errStr := fmt.Sprintf("failed to Marshal api token '%s'", token.Id)
+		logger.Log.Error(errStr)
+		return nil, errors.New(errStr)
+	}
+	err = store.Create(utils.APITOKENSSECRET, token.Id, rawData)
+	if err != nil {
+		return nil, err
+	}
+
+	token.TokenHash = ""
+	return &dtos.PunqApiTokenCreated{
+		PunqApiToken: token,
+		Token:        fmt.Sprintf("%s_%s_%s", ApiTokenPrefix, token.Id, tokenSecret),
+	}, nil
+}
+
+// RevokeApiToken deletes a token. If userId is not empty, the token must belong to that user.
+func RevokeApiToken(id string, userId string) error {
+	if userId != "" {
+		token, err := getApiToken(id)
+		if err != nil || token.UserId != userId {
+			return fmt.Errorf("api token '%s' not found", id)
+		}
+	}
+
+	err := store.Remove(utils.APITOKENSSECRET, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return fmt.Errorf("api token '%s' not found", id)
+	}
+	return err
+}
+
+// RevokeApiTokensForUser is called when a user is deleted.
+func RevokeApiTokensForUser(userId string) {
+	for _, token := range ListApiTokens(userId) {
+		err := RevokeApiToken(token.Id, userId)
+		if err != nil {
+			logger.Log.Errorf("Failed to revoke api token '%s': %s", token.Id, err.Error())
+		}
+	}
+}
+
+// ValidateApiToken checks a plain token ("punq_<id>_<secret>") and returns the token and its user.
+func ValidateApiToken(plainToken string) (*dtos.PunqApiToken, *dtos.PunqUser, error) {
+	parts := strings.Split(plainToken, "_")
+	if len(parts) != 3 || parts[0] != ApiTokenPrefix {
+		return nil, nil, errors.New("malformed api token")
+	}
+
+	token, err := getApiToken(parts[1])
+	if err != nil {
+		return nil, nil, errors.New("invalid api token")
+	}
+	if subtle.ConstantTimeCompare([]byte(token.TokenHash), []byte(hashApiTokenSecret(parts[2]))) != 1 {
+		return nil, nil, errors.New("invalid api token")
+	}
+	if token.IsExpired() {
+		return nil, nil, fmt.Errorf("api token '%s' is expired", token.Name)
+	}
+
+	user, err := GetUser(token.UserId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	touchApiToken(*token)
+	token.TokenHash = ""
+	return token, user, nil
+}
+
+func touchApiToken(token dtos.PunqApiToken) {
+	if token.LastUsed != "" {
+		lastUsed, err := time.Parse(time.RFC3339, token.LastUsed)
+		if err == nil && time.Since(lastUsed) < ApiTokenLastUsedMinutes*time.Minute {
+			return
+		}
+	}
+	lastUsed := time.Now().Format(time.RFC3339)
+	err := store.Modify(utils.APITOKENSSECRET, token.Id, func(current []byte, exists bool) ([]byte, error) {
+		if !exists {
+			// revoked in the meantime
+			return nil, errors.New("api token not found")
+		}
+		stored := dtos.PunqApiToken{}
+		err := json.Unmarshal(current, &stored)
+		if err != nil {
+			return nil, err
+		}
+		stored.LastUsed = lastUsed
+		return json.Marshal(stored)
+	})
+	if err != nil {
+		logger.Log.Errorf("Failed to update last usage of api token '%s': %s", token.Id, err.Error())
+	}
+}
+
+func GetGinContextApiToken(c *gin.Context) *dtos.PunqApiToken {
+	if temp, exists := c.Get("apiToken"); exists {
+		token, ok := temp.(dtos.PunqApiToken)
+		if !ok {
+			utils.MalformedMessage(c, "Type Assertion failed. Expected PunqApiToken but received something different.")
+			return nil
+		}
+		return &token
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mogenius/punq/dtos"
+	"github.com/mogenius/punq/store"
+	"github.com/mogenius/punq/utils"
+)
+
+func TestValidateApiToken(t *testing.T) {
+	user := testUser(t, "api-token@auth.test")
+	created, err := AddApiToken(user.Id, dtos.PunqApiTokenCreateInput{Name: "ci"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { RevokeApiTokensForUser(user.Id) })
+
+	expired := dtos.PunqApiToken{Id: "token-expired", UserId: user.Id, Name: "expired", TokenHash: hashApiTokenSecret("secret"), ExpiresAt: time.Now().Add(-time.Hour).Format(time.RFC3339)}
+	orphaned := dtos.PunqApiToken{Id: "token-orphaned", UserId: "user-deleted", Name: "orphaned", TokenHash: hashApiTokenSecret("secret")}
+	for _, token := range []dtos.PunqApiToken{expired, orphaned} {
+		rawData, _ := json.Marshal(token)
+		if err := store.Set(utils.APITOKENSSECRET, token.Id, rawData); err != nil {
+			t.Fatal(err)
+		}
+		id := token.Id
+		t.Cleanup(func() { store.Remove(utils.APITOKENSSECRET, id) })
+	}
+
+	secret := strings.TrimPrefix(created.Token, ApiTokenPrefix+"_"+created.Id+"_")
+	tests := []struct {
+		name    string
+		token   string
+		wantErr string
+	}{
+		{"valid", created.Token, ""},
+		{"wrong prefix", "ghp_" + created.Id + "_" + secret, "malformed"},
+		{"missing secret", ApiTokenPrefix + "_" + created.Id, "malformed"},
+		{"wrong secret", ApiTokenPrefix + "_" + created.Id + "_wrong", "invalid api token"},
+		{"unknown id", ApiTokenPrefix + "_unknown_" + secret, "invalid api token"},
+		{"expired", ApiTokenPrefix + "_token-expired_secret", "expired"},
+		{"deleted user", ApiTokenPrefix + "_token-orphaned_secret", "not found"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token, tokenUser, err := ValidateApiToken(test.token)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("ValidateApiToken() error = %v, want '%s'", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tokenUser.Id != user.Id || token.Id != created.Id || token.TokenHash != "" {
+				t.Errorf("ValidateApiToken() = %+v, %+v", token, tokenUser)
+			}
+		})
+	}
+}
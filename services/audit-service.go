@@ -0,0 +1,364 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	typedcore "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/mogenius/punq/dtos"
+	"github.com/mogenius/punq/kubernetes"
+	"github.com/mogenius/punq/logger"
+	"github.com/mogenius/punq/utils"
+)
+
+const (
+	AuditSecretPrefix     = "punq-audit-" // secrets per hour: punq-audit-2006010215-000, -001, ... (older logs: per day)
+	AuditSecretDateFormat = "20060102"
+	AuditSecretHourFormat = "2006010215"
+	AuditSecretKey        = "audit.jsonl"
+	AuditSecretMaxBytes   = 512 * 1024 // a secret must stay below 1 MiB including the base64 encoding
+	AuditFlushSeconds     = 5
+	AuditQueueSize        = 1000
+	AuditShutdownTimeout  = 10 * time.Second
+)
+
+type AuditFilter struct {
+	Since     time.Time
+	Until     time.Time
+	UserId    string
+	ContextId string
+	Verb      string
+	Limit     int
+}
+
+var auditQueue chan dtos.PunqAuditEntry
+var auditFlush chan chan struct{}
+
+// InitAuditLog starts the background writer and the retention cleanup (operator only).
+func InitAuditLog() {
+	if !utils.CONFIG.Audit.Enabled {
+		logger.Log.Notice("Audit log is disabled.")
+		return
+	}
+	auditQueue = make(chan dtos.PunqAuditEntry, AuditQueueSize)
+	auditFlush = make(chan chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(AuditFlushSeconds * time.Second)
+		defer ticker.Stop()
+
+		pending := []dtos.PunqAuditEntry{}
+		for {
+			select {
+			case entry := <-auditQueue:
+				pending = append(pending, entry)
+			case <-ticker.C:
+				pending = flushAuditEntries(pending)
+			case done := <-auditFlush:
+				pending = append(pending, drainAuditQueue()...)
+				pending = flushAuditEntries(pending)
+				close(done)
+			}
+		}
+	}()
+
+	go func() {
+		for {
+			pruneAuditLog()
+			time.Sleep(24 * time.Hour)
+		}
+	}()
+}
+
+// FlushAuditLog persists all queued entries, e.g. before the operator shuts down.
+func FlushAuditLog() {
+	if auditFlush == nil {
+		return
+	}
+	done := make(chan struct{})
+	select {
+	case auditFlush <- done:
+	case <-time.After(AuditShutdownTimeout):
+		logger.Log.Errorf("Audit log has not been flushed within %s.", AuditShutdownTimeout)
+		return
+	}
+	select {
+	case <-done:
+	case <-time.After(AuditShutdownTimeout):
+		logger.Log.Errorf("Audit log has not been flushed within %s.", AuditShutdownTimeout)
+	}
+}
+
+func drainAuditQueue() []dtos.PunqAuditEntry {
+	entries := []dtos.PunqAuditEntry{}
+	for {
+		select {
+		case entry := <-auditQueue:
+			entries = append(entries, entry)
+		default:
+			return entries
+		}
+	}
+}
+
+// RecordAudit queues an entry for persistence. Entries are written to the log in any case.
+func RecordAudit(entry dtos.PunqAuditEntry) {
+	entry.Id = utils.NanoId()
+	entry.Timestamp = time.Now().Format(time.RFC3339Nano)
+
+	logger.Log.Infof("AUDIT user=%s context=%s %s %s kind=%s namespace=%s name=%s status=%d result=%s", entry.UserId, entry.ContextId, entry.Verb, entry.Path, entry.Kind, entry.Namespace, entry.Name, entry.Status, entry.Result)
+
+	if auditQueue == nil {
+		return
+	}
+	select {
+	case auditQueue <- entry:
+	default:
+		logger.Log.Errorf("Audit queue is full. Entry '%s' has only been logged.", entry.Id)
+	}
+}
+
+func auditSecretHour(timestamp time.Time) string {
+	return AuditSecretPrefix + timestamp.UTC().Format(AuditSecretHourFormat)
+}
+
+// auditSecretRange returns the period covered by an audit secret (hourly shards or a legacy daily secret).
+func auditSecretRange(name string) (time.Time, time.Time, bool) {
+	key, _, _ := strings.Cut(strings.TrimPrefix(name, AuditSecretPrefix), "-")
+	if start, err := time.Parse(AuditSecretHourFormat, key); err == nil && len(key) == len(AuditSecretHourFormat) {
+		return start, start.Add(time.Hour), true
+	}
+	if start, err := time.Parse(AuditSecretDateFormat, key); err == nil && len(key) == len(AuditSecretDateFormat) {
+		return start, start.AddDate(0, 0, 1), true
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+// flushAuditEntries returns the entries which could not be persisted, they are retried with the next flush.
+func flushAuditEntries(entries []dtos.PunqAuditEntry) []dtos.PunqAuditEntry {
+	if len(entries) == 0 {
+		return entries
+	}
+	provider, err := kubernetes.NewKubeProvider(nil)
+	if err != nil {
+		logger.Log.Errorf("Failed to persist %d audit entries: %s", len(entries), err.Error())
+		return retainAuditEntries(entries)
+	}
+	secretClient := provider.ClientSet.CoreV1().Secrets(utils.CONFIG.Kubernetes.OwnNamespace)
+
+	byHour := map[string][]dtos.PunqAuditEntry{}
+	for _, entry := range entries {
+		timestamp, err := time.Parse(time.RFC3339Nano, entry.Timestamp)
+		if err != nil {
+			timestamp = time.Now()
+		}
+		hour := auditSecretHour(timestamp)
+		byHour[hour] = append(byHour[hour], entry)
+	}
+
+	failed := []dtos.PunqAuditEntry{}
+	for hour, hourEntries := range byHour {
+		err := appendAuditEntries(secretClient, hour, hourEntries)
+		if err != nil {
+			logger.Log.Errorf("Failed to persist %d audit entries into '%s': %s", len(hourEntries), hour, err.Error())
+			failed = append(failed, hourEntries...)
+		}
+	}
+	return retainAuditEntries(failed)
+}
+
+func retainAuditEntries(entries []dtos.PunqAuditEntry) []dtos.PunqAuditEntry {
+	if len(entries) > AuditQueueSize {
+		logger.Log.Errorf("Dropped %d audit entries which could not be persisted (they have been logged).", len(entries)-AuditQueueSize)
+		return entries[len(entries)-AuditQueueSize:]
+	}
+	return entries
+}
+
+// appendAuditEntries writes the entries into the shards of the hour, a new shard is started once a shard reached
+// AuditSecretMaxBytes. Entries are appended in a single writer goroutine only.
+func appendAuditEntries(secretClient typedcore.SecretInterface, hour string, entries []dtos.PunqAuditEntry) error {
+	lines := [][]byte{}
+	for _, entry := range entries {
+		line, err := AuditEntriesToJsonLines([]dtos.PunqAuditEntry{entry})
+		if err != nil {
+			return err
+		}
+		lines = append(lines, line)
+	}
+
+	for shard := 0; len(lines) > 0; shard++ {
+		secretName := fmt.Sprintf("%s-%03d", hour, shard)
+		secret, err := secretClient.Get(context.TODO(), secretName, metav1.GetOptions{})
+		exists := true
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				return err
+			}
+			exists = false
+		}
+
+		data := []byte{}
+		if exists {
+			data = secret.Data[AuditSecretKey]
+		}
+		taken := 0
+		for taken < len(lines) && (len(data)+len(lines[taken]) <= AuditSecretMaxBytes || len(data) == 0) {
+			data = append(data, lines[taken]...)
+			taken++
+		}
+		if taken == 0 {
+			continue
+		}
+
+		if !exists {
+			newSecret := utils.InitSecret()
+			newSecret.ObjectMeta.Name = secretName
+			newSecret.ObjectMeta.Namespace = utils.CONFIG.Kubernetes.OwnNamespace
+			delete(newSecret.StringData, "exampleData") // delete example data
+			newSecret.Data = map[string][]byte{AuditSecretKey: data}
+			_, err = secretClient.Create(context.TODO(), &newSecret, kubernetes.MoCreateOptions())
+		} else {
+			if secret.Data == nil {
+				secret.Data = map[string][]byte{}
+			}
+			secret.Data[AuditSecretKey] = data
+			_, err = secretClient.Update(context.TODO(), secret, metav1.UpdateOptions{})
+		}
+		if err != nil {
+			return err
+		}
+		lines = lines[taken:]
+	}
+	return nil
+}
+
+func auditSecretNames() []string {
+	names := []string{}
+	for _, secret := range kubernetes.AllSecrets(utils.CONFIG.Kubernetes.OwnNamespace, nil) {
+		if strings.HasPrefix(secret.Name, AuditSecretPrefix) {
+			names = append(names, secret.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func pruneAuditLog() {
+	retentionDays := utils.CONFIG.Audit.RetentionDays
+	if retentionDays <= 0 {
+		return
+	}
+	oldest := time.Now().AddDate(0, 0, -retentionDays)
+
+	for _, name := range auditSecretNames() {
+		_, end, ok := auditSecretRange(name)
+		if !ok || end.After(oldest) {
+			continue
+		}
+		err := kubernetes.DeleteK8sSecretBy(utils.CONFIG.Kubernetes.OwnNamespace, name, nil)
+		if err != nil {
+			logger.Log.Errorf("Failed to delete audit log '%s': %s", name, err.Error())
+			continue
+		}
+		logger.Log.Noticef("Deleted audit log '%s' (retention: %d days).", name, retentionDays)
+	}
+}
+
+// ListAuditEntries returns the matching entries, newest first.
+func ListAuditEntries(filter AuditFilter) []dtos.PunqAuditEntry {
+	result := []dtos.PunqAuditEntry{}
+
+	if filter.Until.IsZero() {
+		filter.Until = time.Now()
+	}
+
+	for _, name := range auditSecretNames() {
+		start, end, ok := auditSecretRange(name)
+		if !ok || !end.After(filter.Since) || start.After(filter.Until) {
+			continue
+		}
+		secret, err := kubernetes.GetSecret(utils.CONFIG.Kubernetes.OwnNamespace, name, nil)
+		if err != nil {
+			logger.Log.Errorf("Failed to read audit log '%s': %s", name, err.Error())
+			continue
+		}
+
+		scanner := bufio.NewScanner(bytes.NewReader(secret.Data[AuditSecretKey]))
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			entry := dtos.PunqAuditEntry{}
+			err := json.Unmarshal(scanner.Bytes(), &entry)
+			if err != nil {
+				logger.Log.Errorf("Failed to Unmarshal audit entry in '%s'.", name)
+				continue
+			}
+			if auditEntryMatches(entry, filter) {
+				result = append(result, entry)
+			}
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Timestamp > result[j].Timestamp
+	})
+	if filter.Limit > 0 && len(result) > filter.Limit {
+		result = result[:filter.Limit]
+	}
+	return result
+}
+
+func auditEntryMatches(entry dtos.PunqAuditEntry, filter AuditFilter) bool {
+	timestamp, err := time.Parse(time.RFC3339Nano, entry.Timestamp)
+	if err != nil || timestamp.Before(filter.Since) || timestamp.After(filter.Until) {
+		return false
+	}
+	if filter.UserId != "" && entry.UserId != filter.UserId {
+		return false
+	}
+	if filter.ContextId != "" && entry.ContextId != filter.ContextId {
+		return false
+	}
+	if filter.Verb != "" && !strings.EqualFold(entry.Verb, filter.Verb) {
+		return false
+	}
+	return true
+}
+
+// AuditEntriesToJsonLines exports entries as JSON lines.
+func AuditEntriesToJsonLines(entries []dtos.PunqAuditEntry) ([]byte, error) {
+	lines := bytes.Buffer{}
+	for _, entry := range entries {
+		rawData, err := json.Marshal(entry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to Marshal audit entry '%s'", entry.Id)
+		}
+		lines.Write(rawData)
+		lines.WriteString("\n")
+	}
+	return lines.Bytes(), nil
+}
+
+// ParseAuditSince accepts a duration relative to now (e.g. 24h) or an RFC3339 timestamp.
+func ParseAuditSince(value string) (time.Time, error) {
+	if value == "" {
+		return time.Now().Add(-24 * time.Hour), nil
+	}
+	if duration, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-duration), nil
+	}
+	since, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid since '%s' (duration like 24h or RFC3339 expected)", value)
+	}
+	return since, nil
+}
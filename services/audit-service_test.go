@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mogenius/punq/dtos"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestAppendAuditEntriesShardsBySize(t *testing.T) {
+	secretClient := fake.NewSimpleClientset().CoreV1().Secrets("punq")
+	hour := auditSecretHour(time.Date(2026, 10, 18, 12, 30, 0, 0, time.UTC))
+
+	// each entry is roughly 10 KiB, so 150 entries need three shards
+	entries := []dtos.PunqAuditEntry{}
+	for i := 0; i < 150; i++ {
+		entries = append(entries, dtos.PunqAuditEntry{Id: "id", Path: strings.Repeat("x", 10*1024), Timestamp: "2026-10-18T12:30:00Z"})
+	}
+	for _, batch := range [][]dtos.PunqAuditEntry{entries[:100], entries[100:]} {
+		if err := appendAuditEntries(secretClient, hour, batch); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	secrets, err := secretClient.List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secrets.Items) != 3 {
+		t.Fatalf("got %d shards, want 3", len(secrets.Items))
+	}
+	lines := 0
+	for _, secret := range secrets.Items {
+		if !strings.HasPrefix(secret.Name, "punq-audit-2026101812-") {
+			t.Errorf("unexpected shard name '%s'", secret.Name)
+		}
+		if len(secret.Data[AuditSecretKey]) > AuditSecretMaxBytes {
+			t.Errorf("shard '%s' has %d bytes", secret.Name, len(secret.Data[AuditSecretKey]))
+		}
+		lines += strings.Count(string(secret.Data[AuditSecretKey]), "\n")
+	}
+	if lines != len(entries) {
+		t.Errorf("persisted %d entries, want %d", lines, len(entries))
+	}
+}
+
+func TestAuditSecretRange(t *testing.T) {
+	tests := []struct {
+		name      string
+		wantStart time.Time
+		wantEnd   time.Time
+		wantOk    bool
+	}{
+		{"punq-audit-2026101812-000", time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC), time.Date(2026, 10, 18, 13, 0, 0, 0, time.UTC), true},
+		{"punq-audit-2026101823-012", time.Date(2026, 10, 18, 23, 0, 0, 0, time.UTC), time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), true},
+		{"punq-audit-20261018", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), true},
+		{"punq-audit-config", time.Time{}, time.Time{}, false},
+	}
+	for _, test := range tests {
+		start, end, ok := auditSecretRange(test.name)
+		if ok != test.wantOk || !start.Equal(test.wantStart) || !end.Equal(test.wantEnd) {
+			t.Errorf("auditSecretRange(%s) = %s, %s, %t", test.name, start, end, ok)
+		}
+	}
+}
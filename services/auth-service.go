@@ -1,7 +1,6 @@
 package services
 
 import (
-	"context"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
@@ -12,23 +11,27 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/mogenius/punq/dtos"
-	"github.com/mogenius/punq/kubernetes"
 	"github.com/mogenius/punq/logger"
+	"github.com/mogenius/punq/store"
 	"github.com/mogenius/punq/utils"
-	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
-	SecKeyPair    = "keyPair"
-	TokenExpHours = 24 * 7 // 1 week
+	SecKeyPair            = "keyPair" // single key of installations from before key rotation
+	SecKeyPairs           = "keyPairs"
+	TokenExpHours         = 24 * 7 // refresh token lifetime: 1 week
+	AccessTokenExpMinutes = 15
+	TokenTypeAccess       = "access"
+	TokenTypeRefresh      = "refresh"
 )
 
-var KeyPairInstance *KeyPair
-
 type KeyPair struct {
+	Id               string `json:"kid"`
+	Created          string `json:"createdAt"`
+	RetiredAt        string `json:"retiredAt,omitempty"` // set when the key stops signing, kept for verification
 	PrivateKeyString string `json:"privateKey" validate:"required"`
 	PublicKeyString  string `json:"publicKey" validate:"required"`
 
@@ -39,7 +42,11 @@ type KeyPair struct {
 type keyPairAlias KeyPair
 
 type PunqClaims struct {
-	UserID string `json:"userId"`
+	UserID      string           `json:"userId"`
+	AccessLevel dtos.AccessLevel `json:"accessLevel"`
+	TokenType   string           `json:"typ"`
+	SessionId   string           `json:"sid,omitempty"`
+	Generation  int64            `json:"gen,omitempty"` // PunqUser.TokenGeneration at issue time
 	jwt.RegisteredClaims
 }
 
@@ -80,7 +87,10 @@ func (keyPair *KeyPair) UnmarshalJSON(data []byte) error {
 }
 
 func generateAuthKeyPair() (*KeyPair, error) {
-	keyPair := KeyPair{}
+	keyPair := KeyPair{
+		Id:      utils.NanoId(),
+		Created: time.Now().Format(time.RFC3339),
+	}
 
 	// generate private key
 	privateKey, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
@@ -128,21 +138,15 @@ func InitAuthService() {
 	CreateKeyPair()
 }
 
+// CreateKeyPair generates the first signing key unless a key exists already.
 func CreateKeyPair() (*KeyPair, error) {
-	provider, err := kubernetes.NewKubeProvider(nil)
-	if provider == nil || err != nil {
-		msg := "Failed to load provider."
-		logger.Log.Error(msg)
+	keyPairs, err := storedKeyPairs()
+	if err != nil {
 		return nil, err
 	}
-
-	secretClient := provider.ClientSet.CoreV1().Secrets(utils.CONFIG.Kubernetes.OwnNamespace)
-	existingSecret, getErr := secretClient.Get(context.TODO(), utils.JWTSECRET, metav1.GetOptions{})
-
-	secret := utils.InitSecret()
-	secret.ObjectMeta.Name = utils.JWTSECRET
-	secret.ObjectMeta.Namespace = utils.CONFIG.Kubernetes.OwnNamespace
-	delete(secret.StringData, "exampleData") // delete example data
+	if len(keyPairs) > 0 {
+		return activeKeyPair(keyPairs)
+	}
 
 	keyPair, err := generateAuthKeyPair()
 	if err != nil {
@@ -150,116 +154,195 @@ func CreateKeyPair() (*KeyPair, error) {
 		logger.Log.Error(msg)
 		return nil, errors.New(msg)
 	}
-	rawKeyPair, err := json.Marshal(keyPair)
+	rawKeyPairs, err := json.Marshal([]*KeyPair{keyPair})
 	if err != nil {
 		msg := fmt.Sprintf("failed marshaling %v", err)
 		logger.Log.Error(msg)
 		return nil, errors.New(msg)
 	}
-	secret.StringData[SecKeyPair] = string(rawKeyPair)
-
-	// if not exist
-	if existingSecret == nil || getErr != nil {
-		fmt.Println("Creating new punq-auth secret ...")
-		_, err := secretClient.Create(context.TODO(), &secret, kubernetes.MoCreateOptions())
-		if err != nil {
-			return nil, err
-		}
-		fmt.Println("Created new punq-auth secret. ✅")
 
-		return keyPair, nil
+	fmt.Println("Creating new punq-auth key ...")
+	err = store.Create(utils.JWTSECRET, SecKeyPairs, rawKeyPairs)
+	if errors.Is(err, store.ErrConflict) {
+		// created by another process in the meantime
+		return GetKeyPair()
 	}
-
-	// get from secret
-	return GetKeyPair()
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("Created new punq-auth key. ✅")
+	return keyPair, nil
 }
 
-func RemoveKeyPair() {
-	provider, err := kubernetes.NewKubeProvider(nil)
-	if provider == nil {
-		logger.Log.Fatal(err.Error())
+// GetKeyPair returns the active signing key.
+func GetKeyPair() (*KeyPair, error) {
+	keyRing, err := GetKeyRing()
+	if err != nil {
+		return nil, err
 	}
+	return activeKeyPair(keyRing)
+}
 
-	secretClient := provider.ClientSet.CoreV1().Secrets(utils.CONFIG.Kubernetes.OwnNamespace)
+// GenerateToken starts a new session and issues a short-lived access token and a refresh token for it.
+func GenerateToken(user *dtos.PunqUser, clientIp string, userAgent string) (*dtos.PunqToken, error) {
+	return generateSessionToken(user, newSession(user.Id, clientIp, userAgent))
+}
 
-	fmt.Printf("Deleting %s/%s secret ...\n", utils.CONFIG.Kubernetes.OwnNamespace, utils.JWTSECRET)
-	deletePolicy := metav1.DeletePropagationForeground
-	err = secretClient.Delete(context.TODO(), utils.JWTSECRET, metav1.DeleteOptions{PropagationPolicy: &deletePolicy})
+// generateSessionToken issues a token pair for an existing session and extends the session accordingly.
+func generateSessionToken(user *dtos.PunqUser, session *dtos.PunqSession) (*dtos.PunqToken, error) {
+	accessExpiresAt := time.Now().Add(time.Minute * time.Duration(AccessTokenExpMinutes))
+	accessToken, err := signToken(user, session.Id, TokenTypeAccess, accessExpiresAt)
 	if err != nil {
-		if !apierrors.IsNotFound(err) {
-			logger.Log.Error(err)
-			return
-		}
+		return nil, err
 	}
-	fmt.Printf("Deleted %s/%s secret. ✅\n", utils.CONFIG.Kubernetes.OwnNamespace, utils.JWTSECRET)
-}
 
-func GetKeyPair() (*KeyPair, error) {
-	secret := kubernetes.SecretFor(utils.CONFIG.Kubernetes.OwnNamespace, utils.JWTSECRET, nil)
-	if secret == nil {
-		logger.Log.Warningf("Failed to get '%s/%s' secret.", utils.CONFIG.Kubernetes.OwnNamespace, utils.JWTSECRET)
-		return CreateKeyPair()
+	refreshExpiresAt := time.Now().Add(time.Hour * time.Duration(TokenExpHours))
+	refreshToken, err := signToken(user, session.Id, TokenTypeRefresh, refreshExpiresAt)
+	if err != nil {
+		return nil, err
 	}
 
-	if secret.Data[SecKeyPair] != nil {
-		keyPair := KeyPair{}
-		err := json.Unmarshal([]byte(secret.Data[SecKeyPair]), &keyPair)
-		if err != nil {
-			msg := fmt.Sprintf("failed to Unmarshal user '%s' %v.", SecKeyPair, err)
-			logger.Log.Error(msg)
-			return nil, err
-		}
-		return &keyPair, nil
+	isNew := session.ExpiresAt == ""
+	session.ExpiresAt = refreshExpiresAt.Format(time.RFC3339)
+	err = writeSession(*session, isNew)
+	if err != nil {
+		return nil, err
 	}
 
-	return CreateKeyPair()
+	return dtos.CreateToken(accessToken, refreshToken, accessExpiresAt), nil
 }
 
-func GenerateToken(user *dtos.PunqUser) (*dtos.PunqToken, error) {
-	if KeyPairInstance == nil {
-		keyPair, err := GetKeyPair()
-		if err != nil {
-			return nil, err
-		}
-		KeyPairInstance = keyPair
+func signToken(user *dtos.PunqUser, sessionId string, tokenType string, expiresAt time.Time) (string, error) {
+	keyPair, err := signingKeyPair()
+	if err != nil {
+		return "", err
 	}
 
-	claims := jwt.MapClaims{}
-	claims["accessLevel"] = user.AccessLevel
-	claims["userId"] = user.Id
-	claims["exp"] = time.Now().Add(time.Hour * time.Duration(TokenExpHours)).Unix()
+	claims := PunqClaims{
+		UserID:      user.Id,
+		AccessLevel: user.AccessLevel,
+		TokenType:   tokenType,
+		SessionId:   sessionId,
+		Generation:  user.TokenGeneration,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        utils.NanoId(),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
 	token := jwt.NewWithClaims(jwt.SigningMethodES512, claims)
+	token.Header["kid"] = keyPair.Id
 
 	// sign JWT-Token with private key
-	tokenString, err := token.SignedString(KeyPairInstance.PrivateKey)
+	tokenString, err := token.SignedString(keyPair.PrivateKey)
 	if err != nil {
 		logger.Log.Errorf("sign JWT-Token with private key failed %s", err)
-		return nil, err
+		return "", err
 	}
-	return dtos.CreateToken(tokenString), nil
+	return tokenString, nil
 }
 
+// ValidationToken validates an access token. Revoked tokens, tokens of signed out sessions and tokens issued
+// before the last password or access-level change of the user are rejected.
 func ValidationToken(tokenString string) (*PunqClaims, error) {
-	if KeyPairInstance == nil {
-		keyPair, err := GetKeyPair()
+	claims, err := parseToken(tokenString, TokenTypeAccess)
+	if err != nil {
+		return nil, err
+	}
+	if !IsSessionActive(claims.SessionId) {
+		return nil, errors.New("session has been signed out")
+	}
+
+	_, err = userForClaims(claims)
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// RefreshToken exchanges a refresh token for a new token pair of the same session. The used refresh token is
+// revoked (rotation). Refresh tokens from before session tracking start a new session.
+func RefreshToken(refreshTokenString string, clientIp string, userAgent string) (*dtos.PunqToken, error) {
+	claims, err := parseToken(refreshTokenString, TokenTypeRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := userForClaims(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	session := newSession(user.Id, clientIp, userAgent)
+	if claims.SessionId != "" {
+		existing, exists := cachedSession(claims.SessionId)
+		if !exists || existing.IsExpired() {
+			return nil, errors.New("session has been signed out")
+		}
+		session = &existing
+	}
+
+	// concurrent refreshes with the same token: only the first one consumes it
+	err = consumeToken(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return generateSessionToken(user, session)
+}
+
+// Logout ends the session of the given access token and revokes the token and (if provided) the matching refresh
+// token.
+func Logout(accessClaims *PunqClaims, refreshTokenString string) error {
+	if refreshTokenString != "" {
+		refreshClaims, err := parseToken(refreshTokenString, TokenTypeRefresh)
+		if err == nil && refreshClaims.UserID == accessClaims.UserID {
+			err = RevokeToken(refreshClaims)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if accessClaims.SessionId != "" {
+		err := DeleteSession(accessClaims.SessionId, accessClaims.UserID)
 		if err != nil {
-			return nil, err
+			logger.Log.Errorf("Failed to delete session '%s': %s", accessClaims.SessionId, err.Error())
 		}
-		KeyPairInstance = keyPair
 	}
+	return RevokeToken(accessClaims)
+}
 
-	ecdsaPublicKey, ok := KeyPairInstance.PublicKey.(*ecdsa.PublicKey)
-	if !ok {
-		msg := fmt.Sprintf("Invalid public key")
-		logger.Log.Error(msg)
-		return nil, errors.New(msg)
+func userForClaims(claims *PunqClaims) (*dtos.PunqUser, error) {
+	user, err := GetUser(claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	// iat has whole seconds only, the generation rejects tokens issued in the second of the change as well
+	if claims.IssuedAt == nil || claims.IssuedAt.Unix() < user.TokensValidAfter || claims.Generation != user.TokenGeneration {
+		return nil, errors.New("token has been invalidated")
 	}
+	return user, nil
+}
 
+func parseToken(tokenString string, tokenType string) (*PunqClaims, error) {
 	// Validation
 	token, err := jwt.ParseWithClaims(tokenString, &PunqClaims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodECDSA); !ok {
 			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
 		}
+		// tokens from before key rotation carry no kid
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			kid = LegacyKeyId
+		}
+		keyPair, err := verificationKeyPair(kid)
+		if err != nil {
+			return nil, err
+		}
+		ecdsaPublicKey, ok := keyPair.PublicKey.(*ecdsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("Invalid public key '%s'", kid)
+		}
 		return ecdsaPublicKey, nil
 	})
 	if err != nil {
@@ -278,5 +361,23 @@ func ValidationToken(tokenString string) (*PunqClaims, error) {
 		logger.Log.Error(msg)
 		return nil, errors.New(msg)
 	}
+	if claims.TokenType != tokenType {
+		return nil, fmt.Errorf("invalid token type '%s' (expected '%s')", claims.TokenType, tokenType)
+	}
+	if IsTokenRevoked(claims.ID) {
+		return nil, ErrTokenRevoked
+	}
 	return claims, nil
 }
+
+func GetGinContextClaims(c *gin.Context) *PunqClaims {
+	if temp, exists := c.Get("claims"); exists {
+		claims, ok := temp.(PunqClaims)
+		if !ok {
+			utils.MalformedMessage(c, "Type Assertion failed. Expected PunqClaims but received something different.")
+			return nil
+		}
+		return &claims
+	}
+	return nil
+}
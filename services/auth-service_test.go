@@ -0,0 +1,84 @@
+package services
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/mogenius/punq/dtos"
+)
+
+func testUser(t *testing.T, email string) *dtos.PunqUser {
+	t.Helper()
+	user, err := AddUser(dtos.PunqUserCreateInput{Email: email, Password: "secret", DisplayName: email, AccessLevel: dtos.USER})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { DeleteUser(user.Id) })
+	return user
+}
+
+func TestTokensOfTheSameSecondAreInvalidatedOnPasswordChange(t *testing.T) {
+	InitAuthService()
+	user := testUser(t, "password-change@auth.test")
+
+	token, err := GenerateToken(user, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ValidationToken(token.Token); err != nil {
+		t.Fatalf("fresh token has been rejected: %s", err.Error())
+	}
+
+	// the change happens within the second the token has been issued in
+	update := *user
+	update.Password = "changed"
+	updated, err := UpdateUser(update)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ValidationToken(token.Token); err == nil {
+		t.Error("token issued before the password change is still valid")
+	}
+
+	fresh, err := GenerateToken(updated, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ValidationToken(fresh.Token); err != nil {
+		t.Errorf("token issued after the password change has been rejected: %s", err.Error())
+	}
+}
+
+func TestConcurrentRefreshConsumesTheTokenOnce(t *testing.T) {
+	InitAuthService()
+	user := testUser(t, "refresh@auth.test")
+
+	token, err := GenerateToken(user, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const attempts = 8
+	results := make(chan error, attempts)
+	wg := sync.WaitGroup{}
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := RefreshToken(token.RefreshToken, "127.0.0.1", "test")
+			results <- err
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	succeeded := 0
+	for err := range results {
+		if err == nil {
+			succeeded++
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("%d refreshes succeeded with the same refresh token, want 1", succeeded)
+	}
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mogenius/punq/dtos"
+	"github.com/mogenius/punq/kubernetes"
+	"github.com/mogenius/punq/logger"
+	"github.com/mogenius/punq/store"
+	"github.com/mogenius/punq/utils"
+)
+
+const (
+	ContextHealthHistorySize  = 60
+	CredentialWarningInterval = 24 * time.Hour
+)
+
+// context id -> probes (oldest first, kept in memory of this process only)
+var contextHealth = map[string][]dtos.PunqContextProbe{}
+var contextHealthLock sync.Mutex
+
+// context id -> last credential warning (repeated once a day)
+var credentialWarnings = map[string]time.Time{}
+
+// InitContextHealthMonitor probes all contexts every utils.CONFIG.Kubernetes.HealthCheckSeconds and persists
+// changes of Reachable and Provider.
+func InitContextHealthMonitor() {
+	interval := utils.CONFIG.Kubernetes.HealthCheckSeconds
+	if interval <= 0 {
+		logger.Log.Notice("Context health monitor is disabled.")
+		return
+	}
+	logger.Log.Infof("Context health monitor enabled (every %d seconds).", interval)
+
+	go func() {
+		for {
+			ProbeContexts()
+			time.Sleep(time.Duration(interval) * time.Second)
+		}
+	}()
+}
+
+// pruneContextHealth forgets the probes and credential warnings of deleted contexts.
+func pruneContextHealth(contexts []dtos.PunqContext) {
+	ids := map[string]bool{}
+	for _, ctx := range contexts {
+		ids[ctx.Id] = true
+	}
+
+	contextHealthLock.Lock()
+	defer contextHealthLock.Unlock()
+	for id := range contextHealth {
+		if !ids[id] {
+			delete(contextHealth, id)
+		}
+	}
+	for id := range credentialWarnings {
+		if !ids[id] {
+			delete(credentialWarnings, id)
+		}
+	}
+}
+
+// ProbeContexts checks all contexts of the registry in parallel (see InitContextRegistrySync).
+func ProbeContexts() {
+	var wg sync.WaitGroup
+	for _, ctx := range kubernetes.ContextList() {
+		wg.Add(1)
+		go func(ctx dtos.PunqContext) {
+			defer wg.Done()
+			probeContext(ctx)
+		}(ctx)
+	}
+	wg.Wait()
+}
+
+func probeContext(ctx dtos.PunqContext) {
+	probe := kubernetes.ProbeContext(ctx)
+	warnExpiringCredentials(ctx)
+
+	contextHealthLock.Lock()
+	history := append(contextHealth[ctx.Id], probe)
+	if len(history) > ContextHealthHistorySize {
+		history = history[len(history)-ContextHealthHistorySize:]
+	}
+	contextHealth[ctx.Id] = history
+	contextHealthLock.Unlock()
+
+	if !probe.Reachable {
+		logger.Log.Warningf("Context '%s' is not reachable: %s", ctx.Name, probe.Error)
+	}
+
+	provider := ctx.Provider
+	if probe.Provider != "" {
+		provider = probe.Provider
+	}
+	if probe.Reachable != ctx.Reachable || provider != ctx.Provider {
+		err := updateContextStatus(ctx.Id, probe.Reachable, provider)
+		if err != nil {
+			logger.Log.Errorf("Failed to update status of context '%s': %s", ctx.Name, err.Error())
+		}
+	}
+}
+
+func warnExpiringCredentials(ctx dtos.PunqContext) {
+	credentials := dtos.ParseContextCredentials(ctx.Context)
+	if !credentials.Expired && !credentials.ExpiresSoon {
+		return
+	}
+
+	contextHealthLock.Lock()
+	lastWarning, warned := credentialWarnings[ctx.Id]
+	if warned && time.Since(lastWarning) < CredentialWarningInterval {
+		contextHealthLock.Unlock()
+		return
+	}
+	credentialWarnings[ctx.Id] = time.Now()
+	contextHealthLock.Unlock()
+
+	for _, warning := range credentials.Warnings {
+		logger.Log.Warningf("Context '%s': %s", ctx.Name, warning)
+	}
+}
+
+// updateContextStatus only touches Reachable and Provider so concurrent edits of the context are kept.
+func updateContextStatus(id string, reachable bool, provider string) error {
+	return store.Modify(utils.CONTEXTSSECRET, id, func(current []byte, exists bool) ([]byte, error) {
+		if !exists {
+			return nil, errors.New("context not found")
+		}
+		ctx, err := unmarshalContext(id, current)
+		if err != nil {
+			return nil, err
+		}
+		ctx.Reachable = reachable
+		ctx.Provider = provider
+		return marshalContext(*ctx)
+	})
+}
+
+// ListContextHealth returns the probe history of the given contexts.
+func ListContextHealth(contexts []dtos.PunqContext) []dtos.PunqContextHealth {
+	contextHealthLock.Lock()
+	defer contextHealthLock.Unlock()
+
+	result := []dtos.PunqContextHealth{}
+	for _, ctx := range contexts {
+		health := dtos.PunqContextHealth{
+			ContextId: ctx.Id,
+			Name:      ctx.Name,
+			Reachable: ctx.Reachable,
+			History:   append([]dtos.PunqContextProbe{}, contextHealth[ctx.Id]...),
+		}
+		if len(health.History) > 0 {
+			lastProbe := health.History[len(health.History)-1]
+			health.LastProbe = &lastProbe
+			health.Reachable = lastProbe.Reachable
+		}
+		result = append(result, health)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
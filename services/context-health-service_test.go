@@ -0,0 +1,38 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mogenius/punq/dtos"
+)
+
+func TestPruneContextHealth(t *testing.T) {
+	contextHealthLock.Lock()
+	contextHealth["ctx-kept"] = []dtos.PunqContextProbe{{Reachable: true}}
+	contextHealth["ctx-deleted"] = []dtos.PunqContextProbe{{Reachable: false}}
+	credentialWarnings["ctx-kept"] = time.Now()
+	credentialWarnings["ctx-deleted"] = time.Now()
+	contextHealthLock.Unlock()
+	t.Cleanup(func() { pruneContextHealth(nil) })
+
+	pruneContextHealth([]dtos.PunqContext{{Id: "ctx-kept"}})
+
+	contextHealthLock.Lock()
+	defer contextHealthLock.Unlock()
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"ctx-kept", true},
+		{"ctx-deleted", false},
+	}
+	for _, test := range tests {
+		if _, exists := contextHealth[test.id]; exists != test.want {
+			t.Errorf("probes of '%s' kept = %v, want %v", test.id, exists, test.want)
+		}
+		if _, exists := credentialWarnings[test.id]; exists != test.want {
+			t.Errorf("credential warning of '%s' kept = %v, want %v", test.id, exists, test.want)
+		}
+	}
+}
@@ -4,24 +4,99 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/mogenius/punq/dtos"
 	"github.com/mogenius/punq/kubernetes"
 	"github.com/mogenius/punq/logger"
+	"github.com/mogenius/punq/store"
 	"github.com/mogenius/punq/utils"
+	"k8s.io/client-go/tools/clientcmd"
 )
 
+const ContextRegistrySyncSeconds = 30
+
 func ListContexts() []dtos.PunqContext {
-	return kubernetes.ListAllContexts()
+	contexts, err := listContexts()
+	if err != nil {
+		logger.Log.Errorf("Failed to list '%s': %s", utils.CONTEXTSSECRET, err.Error())
+	}
+	return contexts
+}
+
+func listContexts() ([]dtos.PunqContext, error) {
+	contexts := []dtos.PunqContext{}
+
+	values, err := store.Values(utils.CONTEXTSSECRET)
+	if err != nil {
+		return contexts, err
+	}
+
+	for ctxId, contextRaw := range values {
+		ctx, err := unmarshalContext(ctxId, contextRaw)
+		if err != nil {
+			logger.Log.Error(err.Error())
+			continue
+		}
+		contexts = append(contexts, *ctx)
+	}
+
+	sort.Slice(contexts, func(i, j int) bool {
+		return contexts[i].Name < contexts[j].Name
+	})
+	return contexts, nil
+}
+
+// SyncContextRegistry loads the contexts of the store into the registry of this process, so changes of other
+// processes (cli, replicas) are picked up. The registry is kept if the store cannot be read.
+func SyncContextRegistry() []dtos.PunqContext {
+	contexts, err := listContexts()
+	if err != nil {
+		logger.Log.Errorf("Failed to sync contexts: %s", err.Error())
+		return kubernetes.ContextList()
+	}
+	kubernetes.ContextSync(contexts)
+	pruneContextHealth(contexts)
+	return contexts
+}
+
+// InitContextRegistrySync syncs the context registry with the store every ContextRegistrySyncSeconds (operator only,
+// independent of the health monitor).
+func InitContextRegistrySync() {
+	go func() {
+		for {
+			time.Sleep(ContextRegistrySyncSeconds * time.Second)
+			SyncContextRegistry()
+		}
+	}()
+}
+
+// CreateOwnContext stores the current context of the default kubeconfig as own-context unless it exists already.
+func CreateOwnContext() {
+	if _, err := store.Value(utils.CONTEXTSSECRET, utils.CONTEXTOWN); err == nil {
+		return
+	}
+
+	ownContext := kubernetes.CurrentOwnContext()
+	rawData, err := marshalContext(ownContext)
+	if err != nil {
+		logger.Log.Errorf("Error marshaling %s", err)
+		return
+	}
+
+	fmt.Printf("Creating %s ...\n", utils.CONTEXTOWN)
+	err = store.Create(utils.CONTEXTSSECRET, utils.CONTEXTOWN, rawData)
+	if err != nil && !errors.Is(err, store.ErrConflict) {
+		logger.Log.Fatalf("Error creating %s. Aborting: %s.", utils.CONTEXTOWN, err.Error())
+	}
+	fmt.Printf("Created %s. ✅\n", utils.CONTEXTOWN)
 }
 
 func AddContext(ctx dtos.PunqContext) (*dtos.PunqContext, error) {
-	secret := kubernetes.SecretFor(utils.CONFIG.Kubernetes.OwnNamespace, utils.CONTEXTSSECRET, nil)
-	if secret == nil {
-		msg := fmt.Sprintf("failed to get '%s/%s' secret", utils.CONFIG.Kubernetes.OwnNamespace, utils.CONTEXTSSECRET)
-		logger.Log.Error(msg)
-		return nil, errors.New(msg)
+	if err := ctx.ValidateLabels(); err != nil {
+		return nil, err
 	}
 
 	// check if context already exists
@@ -32,130 +107,294 @@ func AddContext(ctx dtos.PunqContext) (*dtos.PunqContext, error) {
 		}
 	}
 
-	rawData, err := json.Marshal(ctx)
+	rawData, err := marshalContext(ctx)
 	if err != nil {
-		msg := fmt.Sprintf("failed to Marshal context '%s'", ctx.Id)
-		logger.Log.Error(msg)
-		return nil, errors.New(msg)
+		logger.Log.Error(err.Error())
+		return nil, err
 	}
-	secret.Data[ctx.Id] = rawData
 
-	workloadResult := kubernetes.UpdateK8sSecret(*secret, nil)
-	if workloadResult.Error != nil {
+	err = store.Create(utils.CONTEXTSSECRET, ctx.Id, rawData)
+	if err != nil {
 		return nil, err
 	}
 
-	// Update LocalContextArray
-	kubernetes.ContextAddOne(ctx)
+	kubernetes.ContextUpsert(ctx)
 
 	return &ctx, nil
 }
 
-func UpdateContext(ctx dtos.PunqContext) (interface{}, error) {
-	secret := kubernetes.SecretFor(utils.CONFIG.Kubernetes.OwnNamespace, utils.CONTEXTSSECRET, nil)
-	if secret == nil {
-		msg := fmt.Sprintf("failed to get '%s/%s' secret", utils.CONFIG.Kubernetes.OwnNamespace, utils.CONTEXTSSECRET)
-		logger.Log.Error(msg)
-		return nil, errors.New(msg)
+// AddServiceAccountContext uses the kubeconfig of ctx only to mint a ServiceAccount of this context bound to
+// clusterRole in the cluster. The stored context authenticates with the token of this ServiceAccount.
+func AddServiceAccountContext(ctx dtos.PunqContext, clusterRole string) (*dtos.PunqContext, error) {
+	for _, aCtx := range ListContexts() {
+		if aCtx.Name == ctx.Name && aCtx.RemoteServiceAccount != "" {
+			return nil, fmt.Errorf("context '%s' already exists", ctx.Name)
+		}
 	}
 
-	rawData, err := json.Marshal(ctx)
+	minted, err := kubernetes.MintServiceAccountContext(ctx, clusterRole)
 	if err != nil {
-		msg := fmt.Sprintf("failed to Marshal context '%s'", ctx.Id)
-		logger.Log.Error(msg)
-		return nil, errors.New(msg)
+		return nil, err
+	}
+	result, err := AddContext(*minted)
+	if err != nil {
+		if revokeErr := kubernetes.RevokeServiceAccountContext(*minted, ctx.Context); revokeErr != nil {
+			return nil, fmt.Errorf("%s (revoking %s failed: %s)", err.Error(), minted.RemoteServiceAccount, revokeErr.Error())
+		}
+		return nil, err
 	}
-	secret.Data[ctx.Id] = rawData
+	return result, nil
+}
 
-	workloadResult := kubernetes.UpdateK8sSecret(*secret, nil)
-	if workloadResult.Result != nil {
-		return workloadResult.Result, nil
+// UpdateContext replaces the settings of a stored context. The kubeconfig is kept if ctx has none, the probe
+// results (Reachable, Provider) are always kept.
+func UpdateContext(ctx dtos.PunqContext) (*dtos.PunqContext, error) {
+	if err := ctx.ValidateLabels(); err != nil {
+		return nil, err
 	}
+	return ModifyContext(ctx.Id, func(current *dtos.PunqContext) error {
+		// the api never returns the kubeconfig (see WithoutKubeconfig), updates without one keep the stored kubeconfig
+		if ctx.Context == "" {
+			ctx.Context = current.Context
+			ctx.ContextHash = current.ContextHash
+		}
+		ctx.Reachable = current.Reachable
+		ctx.Provider = current.Provider
+		*current = ctx
+		return nil
+	})
+}
 
-	// Update LocalContextArray
-	kubernetes.ContextAddMany(ListContexts())
+// ModifyContext applies a change to the current state of a context (retried on concurrent modifications), so
+// edits of other admins or processes in the meantime are kept.
+func ModifyContext(id string, modify func(ctx *dtos.PunqContext) error) (*dtos.PunqContext, error) {
+	result := dtos.PunqContext{}
+	err := store.Modify(utils.CONTEXTSSECRET, id, func(current []byte, exists bool) ([]byte, error) {
+		if !exists {
+			return nil, errors.New("context not found")
+		}
+		ctx, err := unmarshalContext(id, current)
+		if err != nil {
+			return nil, err
+		}
 
-	return nil, errors.New(fmt.Sprintf("%v", workloadResult.Error))
-}
+		err = modify(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if ctx.Id != id {
+			return nil, fmt.Errorf("the id of context '%s' cannot be changed", id)
+		}
+		result = *ctx
 
-func DeleteContext(id string) (interface{}, error) {
-	secret := kubernetes.SecretFor(utils.CONFIG.Kubernetes.OwnNamespace, utils.CONTEXTSSECRET, nil)
-	if secret == nil {
-		msg := fmt.Sprintf("failed to get '%s/%s' secret", utils.CONFIG.Kubernetes.OwnNamespace, utils.CONTEXTSSECRET)
-		return nil, errors.New(msg)
+		rawData, err := marshalContext(*ctx)
+		if err != nil {
+			logger.Log.Error(err.Error())
+			return nil, err
+		}
+		return rawData, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	kubernetes.ContextUpsert(result)
+	return &result, nil
+}
+
+// DeleteContext removes the context from punq. The ServiceAccount of a minted context is revoked first with
+// adminKubeconfig (a kubeconfig of a cluster admin), the context is kept if that fails. keepServiceAccount deletes
+// the context only, e.g. if the cluster does not exist anymore.
+func DeleteContext(id string, adminKubeconfig string, keepServiceAccount bool) (interface{}, error) {
 	if id == utils.CONTEXTOWN {
 		return nil, errors.New("own-context cannot be deleted")
 	}
+	ctx, err := GetContext(id)
+	if err != nil {
+		return nil, err
+	}
 
-	if secret.Data[id] != nil {
-		delete(secret.Data, id)
-	} else {
+	if ctx.RemoteServiceAccount != "" && !keepServiceAccount {
+		if adminKubeconfig == "" {
+			return nil, fmt.Errorf("context '%s' uses the serviceaccount %s, the kubeconfig of a cluster admin is required to revoke it", ctx.Name, ctx.RemoteServiceAccount)
+		}
+		err := kubernetes.RevokeServiceAccountContext(*ctx, adminKubeconfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to revoke serviceaccount %s of context '%s': %s", ctx.RemoteServiceAccount, ctx.Name, err.Error())
+		}
+	}
+
+	err = store.Remove(utils.CONTEXTSSECRET, id)
+	if errors.Is(err, store.ErrNotFound) {
 		msg := fmt.Sprintf("Context '%s' not found.", id)
 		return nil, errors.New(msg)
 	}
+	if err != nil {
+		return nil, err
+	}
 
-	workloadResult := kubernetes.UpdateK8sSecret(*secret, nil)
-	if workloadResult.Error == nil && workloadResult.Result != nil {
-		// success
-		workloadResult.Result = fmt.Sprintf("Context %s successfully deleted.", id)
-
-		// Update LocalContextArray
-		kubernetes.ContextAddMany(ListContexts())
+	kubernetes.ContextRemove(id)
 
-		return workloadResult.Result, nil
+	if ctx.RemoteServiceAccount != "" && keepServiceAccount {
+		return fmt.Sprintf("Context %s successfully deleted. The serviceaccount %s has been kept in the cluster.", id, ctx.RemoteServiceAccount), nil
 	}
+	return fmt.Sprintf("Context %s successfully deleted.", id), nil
+}
 
-	return nil, fmt.Errorf("%v", workloadResult.Error)
+// RequiresDeleteConfirmation checks the context against utils.CONFIG.Kubernetes.ConfirmDeleteSelector.
+func RequiresDeleteConfirmation(ctx dtos.PunqContext) bool {
+	selector := utils.CONFIG.Kubernetes.ConfirmDeleteSelector
+	if selector == "" {
+		return false
+	}
+	matches, err := ctx.MatchesSelector(selector)
+	if err != nil {
+		// a broken rule must not allow unconfirmed deletes
+		logger.Log.Errorf("confirm_delete_selector: %s", err.Error())
+		return true
+	}
+	return matches
 }
 
-func GetContext(id string) (*dtos.PunqContext, error) {
-	secret := kubernetes.SecretFor(utils.CONFIG.Kubernetes.OwnNamespace, utils.CONTEXTSSECRET, nil)
-	if secret == nil {
-		msg := fmt.Sprintf("Failed to get '%s/%s' secret.", utils.CONFIG.Kubernetes.OwnNamespace, utils.CONTEXTSSECRET)
-		logger.Log.Error(msg)
-		return nil, errors.New(msg)
+// CheckDeleteConfirmation fails if deletes in the context must be confirmed and confirmation is neither the name
+// nor the id of the context.
+func CheckDeleteConfirmation(contextId string, confirmation string) error {
+	ctx, err := GetContext(contextId)
+	if err != nil || !RequiresDeleteConfirmation(*ctx) {
+		return nil
+	}
+	if confirmation == ctx.Name || confirmation == ctx.Id {
+		return nil
 	}
+	return fmt.Errorf("deletes in context '%s' (%s) must be confirmed with the context name", ctx.Name, ctx.LabelsString())
+}
 
-	for ctxId, ctxRaw := range secret.Data {
-		ctx := dtos.PunqContext{}
-		err := json.Unmarshal(ctxRaw, &ctx)
-		if err != nil {
-			logger.Log.Error("Failed to Unmarshal context '%s'.", ctxId)
+// MergedKubeconfig builds one kubeconfig of the contexts (already filtered for the caller). Contexts with
+// DisableKubeconfigExport are left out. The kubeconfig hands out the stored credentials of punq, so for a user
+// (nil for the cli) only contexts without impersonation in which the user is a cluster-wide ADMIN are exported.
+func MergedKubeconfig(user *dtos.PunqUser, contexts []dtos.PunqContext) ([]byte, []dtos.PunqContext, error) {
+	exported := []dtos.PunqContext{}
+	for _, ctx := range contexts {
+		if ctx.DisableKubeconfigExport {
+			continue
 		}
-		if ctx.Id == id {
-			return &ctx, nil
+		if user != nil && !CanExportKubeconfig(user, ctx) {
+			continue
 		}
+		exported = append(exported, ctx)
+	}
+
+	config, err := dtos.MergeContextsToConfig(exported)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	msg := fmt.Sprintf("context not found")
-	logger.Log.Error(msg)
-	return nil, errors.New(msg)
+	data, err := clientcmd.Write(*config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, exported, nil
 }
 
-func GetOwnContext() (*dtos.PunqContext, error) {
-	secret := kubernetes.SecretFor(utils.CONFIG.Kubernetes.OwnNamespace, utils.CONTEXTSSECRET, nil)
-	if secret == nil {
-		err := fmt.Errorf("failed to get '%s/%s' secret", utils.CONFIG.Kubernetes.OwnNamespace, utils.CONTEXTSSECRET)
-		logger.Log.Error(err)
-		return nil, err
+// CanExportKubeconfig reports whether the user may receive the kubeconfig of the context. Impersonation cannot be
+// enforced outside of punq, and namespace bindings must not unlock the credentials of the whole cluster.
+func CanExportKubeconfig(user *dtos.PunqUser, ctx dtos.PunqContext) bool {
+	if ctx.Impersonate {
+		return false
 	}
+	return EffectiveAccessLevel(user, &ctx.Id, "") >= dtos.ADMIN
+}
 
-	for ctxId, contextRaw := range secret.Data {
-		if ctxId == utils.CONTEXTOWN {
-			ownContext := dtos.PunqContext{}
-			err := json.Unmarshal([]byte(contextRaw), &ownContext)
-			if err != nil {
-				logger.Log.Error("Failed to Unmarshal context '%s'.", ctxId)
-			}
-			return &ownContext, nil
+func GetContext(id string) (*dtos.PunqContext, error) {
+	contextRaw, err := store.Value(utils.CONTEXTSSECRET, id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			msg := "context not found"
+			logger.Log.Error(msg)
+			return nil, errors.New(msg)
 		}
+		return nil, err
+	}
+
+	ctx, err := unmarshalContext(id, contextRaw)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return nil, err
+	}
+	return ctx, nil
+}
+
+func GetOwnContext() (*dtos.PunqContext, error) {
+	ownContext, err := GetContext(utils.CONTEXTOWN)
+	if err != nil {
+		return nil, fmt.Errorf("%s not found", utils.CONTEXTOWN)
+	}
+	return ownContext, nil
+}
+
+// WithCredentials adds the parsed credentials (type, expiry) to each context.
+func WithCredentials(contexts []dtos.PunqContext) []dtos.PunqContext {
+	for index := range contexts {
+		credentials := dtos.ParseContextCredentials(contexts[index].Context)
+		contexts[index].Credentials = &credentials
+	}
+	return contexts
+}
+
+// WithoutKubeconfig removes the kubeconfig (the credentials punq uses for the cluster) of a context returned by the
+// api. Otherwise every reader could bypass impersonation and role bindings with kubectl.
+func WithoutKubeconfig(ctx dtos.PunqContext) dtos.PunqContext {
+	ctx.Context = ""
+	ctx.EncryptedContext = ""
+	return ctx
+}
+
+// WithoutKubeconfigs applies WithoutKubeconfig to all contexts.
+func WithoutKubeconfigs(contexts []dtos.PunqContext) []dtos.PunqContext {
+	result := make([]dtos.PunqContext, 0, len(contexts))
+	for _, ctx := range contexts {
+		result = append(result, WithoutKubeconfig(ctx))
+	}
+	return result
+}
+
+// marshalContext returns the stored form of a context (with an encrypted kubeconfig if encryption is enabled).
+func marshalContext(ctx dtos.PunqContext) ([]byte, error) {
+	ctx.Credentials = nil
+	err := EncryptContext(&ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt context '%s': %s", ctx.Id, err.Error())
+	}
+	rawData, err := json.Marshal(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to Marshal context '%s'", ctx.Id)
+	}
+	return rawData, nil
+}
+
+func unmarshalContext(id string, contextRaw []byte) (*dtos.PunqContext, error) {
+	ctx := dtos.PunqContext{}
+	err := json.Unmarshal(contextRaw, &ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to Unmarshal context '%s'.", id)
+	}
+	if ctx.EncryptedContext != "" && ctx.Id != id {
+		return nil, fmt.Errorf("context '%s' is stored as '%s'", ctx.Id, id)
+	}
+	err = DecryptContext(&ctx)
+	if err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("%s not found", utils.CONTEXTOWN)
+	return &ctx, nil
 }
 
 func GetGinContextId(c *gin.Context) *string {
+	// the same pointer is handed out for the whole request (impersonation is registered for it)
+	if temp, exists := c.Get("contextId"); exists {
+		if contextId, ok := temp.(*string); ok {
+			return contextId
+		}
+	}
 	if contextId := c.GetHeader("X-Context-Id"); contextId != "" {
 		return &contextId
 	}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/mogenius/punq/dtos"
+	"github.com/mogenius/punq/kubernetes"
+	"github.com/mogenius/punq/store"
+	"github.com/mogenius/punq/utils"
+)
+
+func TestCanExportKubeconfig(t *testing.T) {
+	storeRoleBinding(t, dtos.PunqRoleBinding{Id: "export-1", UserId: "user-cluster-admin", Role: dtos.ADMIN, ContextId: "ctx-export", Namespace: "*"})
+	storeRoleBinding(t, dtos.PunqRoleBinding{Id: "export-2", UserId: "user-ns-admin", Role: dtos.ADMIN, ContextId: "ctx-export", Namespace: "team-a"})
+	storeRoleBinding(t, dtos.PunqRoleBinding{Id: "export-3", UserId: "user-reader", Role: dtos.READER, ContextId: "ctx-export", Namespace: "*"})
+
+	ctx := dtos.PunqContext{Id: "ctx-export"}
+	impersonated := dtos.PunqContext{Id: "ctx-export", Impersonate: true}
+	tests := []struct {
+		name string
+		user dtos.PunqUser
+		ctx  dtos.PunqContext
+		want bool
+	}{
+		{"cluster-wide admin", dtos.PunqUser{Id: "user-cluster-admin", AccessLevel: dtos.READER}, ctx, true},
+		{"cluster-wide admin with impersonation", dtos.PunqUser{Id: "user-cluster-admin", AccessLevel: dtos.READER}, impersonated, false},
+		{"namespace admin", dtos.PunqUser{Id: "user-ns-admin", AccessLevel: dtos.READER}, ctx, false},
+		{"cluster-wide reader", dtos.PunqUser{Id: "user-reader", AccessLevel: dtos.ADMIN}, ctx, false},
+		{"no binding", dtos.PunqUser{Id: "user-other", AccessLevel: dtos.ADMIN}, ctx, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := CanExportKubeconfig(&test.user, test.ctx); got != test.want {
+				t.Errorf("CanExportKubeconfig() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestUpdateContextKeepsKubeconfig(t *testing.T) {
+	ctx := dtos.PunqContext{Id: "ctx-update", Name: "update", Context: "apiVersion: v1\nkind: Config\n", ContextHash: "hash"}
+	rawData, err := marshalContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(utils.CONTEXTSSECRET, ctx.Id, rawData); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		store.Remove(utils.CONTEXTSSECRET, ctx.Id)
+		kubernetes.ContextRemove(ctx.Id)
+	})
+
+	// a client sends back what the api returned
+	received := WithoutKubeconfig(ctx)
+	if received.Context != "" {
+		t.Fatal("WithoutKubeconfig kept the kubeconfig")
+	}
+	received.Name = "renamed"
+	if _, err := UpdateContext(received); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := GetContext(ctx.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Name != "renamed" || stored.Context != ctx.Context || stored.ContextHash != ctx.ContextHash {
+		t.Errorf("stored context = %+v", stored)
+	}
+}
+
+func storeContext(t *testing.T, ctx dtos.PunqContext) {
+	t.Helper()
+	rawData, err := marshalContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(utils.CONTEXTSSECRET, ctx.Id, rawData); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		store.Remove(utils.CONTEXTSSECRET, ctx.Id)
+		kubernetes.ContextRemove(ctx.Id)
+	})
+}
+
+func TestUpdateContextKeepsProbeResults(t *testing.T) {
+	ctx := dtos.PunqContext{Id: "ctx-probe", Name: "probe", Context: "apiVersion: v1\nkind: Config\n"}
+	storeContext(t, ctx)
+	stale, err := GetContext(ctx.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the health monitor probes the context while an admin edits it
+	if err := updateContextStatus(ctx.Id, true, "EKS"); err != nil {
+		t.Fatal(err)
+	}
+	stale.Name = "renamed"
+	if _, err := UpdateContext(*stale); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := GetContext(ctx.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Name != "renamed" || !stored.Reachable || stored.Provider != "EKS" {
+		t.Errorf("stored context = %+v", stored)
+	}
+
+	if _, err := UpdateContext(dtos.PunqContext{Id: "ctx-unknown", Name: "unknown"}); err == nil {
+		t.Error("UpdateContext() created an unknown context")
+	}
+}
+
+func TestDeleteGroupKeepsContextEdits(t *testing.T) {
+	group, err := AddGroup(dtos.PunqGroupCreateInput{Name: "delete-context-edits"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := dtos.PunqContext{Id: "ctx-group", Name: "group", Context: "apiVersion: v1\nkind: Config\n", Groups: []string{group.Id, "group-other"}}
+	storeContext(t, ctx)
+
+	// other settings of the context must survive the removal of the group
+	if _, err := ModifyContext(ctx.Id, func(ctx *dtos.PunqContext) error {
+		ctx.Users = []string{"user-added"}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := DeleteGroup(group.Id); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := GetContext(ctx.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored.Groups) != 1 || stored.Groups[0] != "group-other" {
+		t.Errorf("Groups = %v, want [group-other]", stored.Groups)
+	}
+	if len(stored.Users) != 1 || stored.Users[0] != "user-added" {
+		t.Errorf("Users = %v, the concurrent edit has been reverted", stored.Users)
+	}
+}
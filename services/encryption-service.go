@@ -0,0 +1,457 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mogenius/punq/dtos"
+	"github.com/mogenius/punq/logger"
+	"github.com/mogenius/punq/store"
+	"github.com/mogenius/punq/utils"
+)
+
+const (
+	SecDataKeys            = "dataKeys"
+	EncryptedContextFormat = "v2" // v2 binds the ciphertext to the context id, v1 (no additional data) is still read
+	DataKeysReloadSeconds  = 60
+	dataKeySize            = 32 // AES-256
+	dataKeysUnknownIdGap   = 5 * time.Second
+)
+
+// retired data keys are kept at least this long, other processes may still encrypt with them until they reloaded
+var dataKeysRetention = DataKeysReloadSeconds*time.Second + dataKeysUnknownIdGap
+
+// A DataKey encrypts kubeconfigs. Only its wrapped form (encrypted with the master key) is stored.
+type DataKey struct {
+	Id          string `json:"id"`
+	WrappedKey  string `json:"wrappedKey"`
+	MasterKeyId string `json:"masterKeyId"`
+	Created     string `json:"created"`
+	RetiredAt   string `json:"retiredAt,omitempty"`
+}
+
+type masterKey struct {
+	id  string
+	key []byte
+}
+
+// data key id -> unwrapped data key (memory only)
+var dataKeys = map[string][]byte{}
+var activeDataKeyId string
+var dataKeysLoaded time.Time
+var dataKeysLock sync.Mutex
+
+func EncryptionEnabled() bool {
+	return utils.CONFIG.Encryption.MasterKey != "" || utils.CONFIG.Encryption.MasterKeyFile != ""
+}
+
+func parseMasterKey(encoded string, source string) (*masterKey, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("master key of %s is not base64 encoded", source)
+	}
+	if len(key) != dataKeySize {
+		return nil, fmt.Errorf("master key of %s must be %d bytes (got %d)", source, dataKeySize, len(key))
+	}
+	hash := sha256.Sum256(key)
+	return &masterKey{id: hex.EncodeToString(hash[:])[:16], key: key}, nil
+}
+
+func readMasterKeyFile(path string) (*masterKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read master key file: %s", err.Error())
+	}
+	return parseMasterKey(string(data), path)
+}
+
+// masterKeys returns the current master key followed by the previous ones.
+func masterKeys() ([]*masterKey, error) {
+	if !EncryptionEnabled() {
+		return nil, errors.New("encryption is not configured (encryption.master_key_file)")
+	}
+
+	var current *masterKey
+	var err error
+	if utils.CONFIG.Encryption.MasterKeyFile != "" {
+		current, err = readMasterKeyFile(utils.CONFIG.Encryption.MasterKeyFile)
+	} else {
+		current, err = parseMasterKey(utils.CONFIG.Encryption.MasterKey, "encryption_master_key")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*masterKey{current}
+	for _, path := range utils.CONFIG.Encryption.PreviousMasterKeyFiles {
+		previous, err := readMasterKeyFile(path)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, previous)
+	}
+	return result, nil
+}
+
+func encryptData(key []byte, plaintext []byte, additionalData []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func decryptData(key []byte, sealed []byte, additionalData []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	return gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], additionalData)
+}
+
+func wrapDataKey(master *masterKey, key []byte) (string, error) {
+	wrapped, err := encryptData(master.key, key, nil)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(wrapped), nil
+}
+
+func unwrapDataKey(masters []*masterKey, dataKey DataKey) ([]byte, error) {
+	for _, master := range masters {
+		if master.id != dataKey.MasterKeyId {
+			continue
+		}
+		wrapped, err := base64.StdEncoding.DecodeString(dataKey.WrappedKey)
+		if err != nil {
+			return nil, err
+		}
+		return decryptData(master.key, wrapped, nil)
+	}
+	return nil, fmt.Errorf("master key '%s' of data key '%s' is not configured", dataKey.MasterKeyId, dataKey.Id)
+}
+
+func storedDataKeys() ([]DataKey, error) {
+	result := []DataKey{}
+	data, err := store.Value(utils.KEYSSECRET, SecDataKeys)
+	if errors.Is(err, store.ErrNotFound) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to Unmarshal '%s': %s", SecDataKeys, err.Error())
+	}
+	return result, nil
+}
+
+// loadDataKeys unwraps the stored data keys (periodically or immediately if force is set) so rotations of
+// other processes are picked up. A data key is created if none exists. Must be called with dataKeysLock held.
+func loadDataKeys(force bool) error {
+	if len(dataKeys) > 0 && time.Since(dataKeysLoaded) < DataKeysReloadSeconds*time.Second && !(force && time.Since(dataKeysLoaded) > dataKeysUnknownIdGap) {
+		return nil
+	}
+
+	masters, err := masterKeys()
+	if err != nil {
+		return err
+	}
+	stored, err := storedDataKeys()
+	if err != nil {
+		return err
+	}
+	if len(stored) == 0 {
+		stored, err = createFirstDataKey(masters[0])
+		if err != nil {
+			return err
+		}
+	}
+
+	loaded := map[string][]byte{}
+	activeId := ""
+	for _, dataKey := range stored {
+		key, err := unwrapDataKey(masters, dataKey)
+		if err != nil {
+			logger.Log.Errorf("Failed to unwrap data key '%s': %s", dataKey.Id, err.Error())
+			continue
+		}
+		loaded[dataKey.Id] = key
+		if dataKey.RetiredAt == "" {
+			activeId = dataKey.Id
+		}
+	}
+	if activeId == "" {
+		return errors.New("no usable data key found (check the master key)")
+	}
+
+	dataKeys = loaded
+	activeDataKeyId = activeId
+	dataKeysLoaded = time.Now()
+	return nil
+}
+
+func newDataKey(master *masterKey) (*DataKey, error) {
+	key := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	wrapped, err := wrapDataKey(master, key)
+	if err != nil {
+		return nil, err
+	}
+	return &DataKey{Id: utils.NanoId(), WrappedKey: wrapped, MasterKeyId: master.id, Created: time.Now().Format(time.RFC3339)}, nil
+}
+
+func createFirstDataKey(master *masterKey) ([]DataKey, error) {
+	dataKey, err := newDataKey(master)
+	if err != nil {
+		return nil, err
+	}
+	rawData, err := json.Marshal([]DataKey{*dataKey})
+	if err != nil {
+		return nil, err
+	}
+	err = store.Create(utils.KEYSSECRET, SecDataKeys, rawData)
+	if errors.Is(err, store.ErrConflict) {
+		// created by another process in the meantime
+		return storedDataKeys()
+	}
+	if err != nil {
+		return nil, err
+	}
+	logger.Log.Noticef("Created data key '%s'.", dataKey.Id)
+	return []DataKey{*dataKey}, nil
+}
+
+// addDataKey stores a new active data key. All existing data keys are retired and re-wrapped with the master key.
+func addDataKey(master *masterKey) ([]DataKey, error) {
+	masters, err := masterKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	added, err := newDataKey(master)
+	if err != nil {
+		return nil, err
+	}
+	now := added.Created
+
+	result := []DataKey{}
+	err = store.Modify(utils.KEYSSECRET, SecDataKeys, func(current []byte, exists bool) ([]byte, error) {
+		result = []DataKey{}
+		if exists {
+			err := json.Unmarshal(current, &result)
+			if err != nil {
+				return nil, fmt.Errorf("failed to Unmarshal '%s': %s", SecDataKeys, err.Error())
+			}
+		}
+		for index := range result {
+			if result[index].RetiredAt == "" {
+				result[index].RetiredAt = now
+			}
+			if result[index].MasterKeyId == master.id {
+				continue
+			}
+			plainKey, err := unwrapDataKey(masters, result[index])
+			if err != nil {
+				return nil, err
+			}
+			rewrapped, err := wrapDataKey(master, plainKey)
+			if err != nil {
+				return nil, err
+			}
+			result[index].WrappedKey = rewrapped
+			result[index].MasterKeyId = master.id
+		}
+		result = append(result, *added)
+		return json.Marshal(result)
+	})
+	if err != nil {
+		return nil, err
+	}
+	logger.Log.Noticef("Created data key '%s' (%d data keys).", added.Id, len(result))
+	return result, nil
+}
+
+// EncryptContext replaces the kubeconfig of a context by its encrypted form (no-op if encryption is disabled).
+func EncryptContext(ctx *dtos.PunqContext) error {
+	if !EncryptionEnabled() || ctx.Context == "" {
+		return nil
+	}
+
+	dataKeysLock.Lock()
+	err := loadDataKeys(false)
+	keyId := activeDataKeyId
+	key := dataKeys[keyId]
+	dataKeysLock.Unlock()
+	if err != nil {
+		return err
+	}
+
+	sealed, err := encryptData(key, []byte(ctx.Context), []byte(ctx.Id))
+	if err != nil {
+		return err
+	}
+	ctx.EncryptedContext = fmt.Sprintf("%s:%s:%s", EncryptedContextFormat, keyId, base64.StdEncoding.EncodeToString(sealed))
+	ctx.Context = ""
+	return nil
+}
+
+// DecryptContext restores the kubeconfig of a stored context. Unencrypted contexts are returned as they are.
+// The kubeconfig of another context (copied into this one) fails to decrypt.
+func DecryptContext(ctx *dtos.PunqContext) error {
+	if ctx.EncryptedContext == "" {
+		return nil
+	}
+
+	parts := strings.SplitN(ctx.EncryptedContext, ":", 3)
+	if len(parts) != 3 || (parts[0] != EncryptedContextFormat && parts[0] != "v1") {
+		return fmt.Errorf("unknown encryption format of context '%s'", ctx.Id)
+	}
+	var additionalData []byte
+	if parts[0] == EncryptedContextFormat {
+		additionalData = []byte(ctx.Id)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return fmt.Errorf("malformed encrypted context '%s'", ctx.Id)
+	}
+
+	var key []byte
+	for _, force := range []bool{false, true} {
+		dataKeysLock.Lock()
+		err = loadDataKeys(force)
+		key = dataKeys[parts[1]]
+		dataKeysLock.Unlock()
+		if err != nil {
+			return err
+		}
+		if key != nil {
+			break
+		}
+	}
+	if key == nil {
+		return fmt.Errorf("unknown data key '%s' of context '%s'", parts[1], ctx.Id)
+	}
+
+	plaintext, err := decryptData(key, sealed, additionalData)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt context '%s': %s", ctx.Id, err.Error())
+	}
+	ctx.Context = string(plaintext)
+	ctx.EncryptedContext = ""
+	return nil
+}
+
+// RotateEncryptionKey creates a new data key, re-wraps all data keys with the current master key, re-encrypts all
+// contexts and finally drops the data keys no longer in use. Other processes pick up the new key within
+// DataKeysReloadSeconds, so the retired keys are dropped after dataKeysRetention only (contexts they encrypted in
+// the meantime keep their key). It returns the number of re-encrypted contexts.
+func RotateEncryptionKey() (int, error) {
+	masters, err := masterKeys()
+	if err != nil {
+		return 0, err
+	}
+
+	contexts := ListContexts()
+
+	_, err = addDataKey(masters[0])
+	if err != nil {
+		return 0, err
+	}
+	dataKeysLock.Lock()
+	dataKeys = map[string][]byte{}
+	dataKeysLock.Unlock()
+
+	// each context is rewritten from its current stored state, edits in the meantime are kept
+	count := 0
+	for _, ctx := range contexts {
+		_, err := ModifyContext(ctx.Id, func(ctx *dtos.PunqContext) error {
+			return nil
+		})
+		if err != nil {
+			return count, fmt.Errorf("failed to re-encrypt context '%s': %s", ctx.Id, err.Error())
+		}
+		count++
+	}
+
+	fmt.Printf("Waiting %s until all punq processes use the new data key ...\n", dataKeysRetention)
+	time.Sleep(dataKeysRetention)
+	err = dropUnusedDataKeys()
+	if err != nil {
+		logger.Log.Errorf("Failed to drop unused data keys: %s", err.Error())
+	}
+	return count, nil
+}
+
+func dropUnusedDataKeys() error {
+	values, err := store.Values(utils.CONTEXTSSECRET)
+	if err != nil {
+		return err
+	}
+	used := map[string]bool{}
+	for _, contextRaw := range values {
+		ctx := dtos.PunqContext{}
+		if json.Unmarshal(contextRaw, &ctx) != nil {
+			continue
+		}
+		parts := strings.SplitN(ctx.EncryptedContext, ":", 3)
+		if len(parts) == 3 {
+			used[parts[1]] = true
+		}
+	}
+
+	return store.Modify(utils.KEYSSECRET, SecDataKeys, func(current []byte, exists bool) ([]byte, error) {
+		stored := []DataKey{}
+		if exists {
+			err := json.Unmarshal(current, &stored)
+			if err != nil {
+				return nil, err
+			}
+		}
+		result := []DataKey{}
+		for _, dataKey := range stored {
+			if dataKey.RetiredAt == "" || used[dataKey.Id] || !dataKeyRetentionExpired(dataKey) {
+				result = append(result, dataKey)
+			}
+		}
+		return json.Marshal(result)
+	})
+}
+
+func dataKeyRetentionExpired(dataKey DataKey) bool {
+	retiredAt, err := time.Parse(time.RFC3339, dataKey.RetiredAt)
+	if err != nil {
+		return false
+	}
+	return time.Since(retiredAt) >= dataKeysRetention
+}
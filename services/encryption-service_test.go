@@ -0,0 +1,190 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mogenius/punq/dtos"
+	"github.com/mogenius/punq/kubernetes"
+	"github.com/mogenius/punq/store"
+	"github.com/mogenius/punq/utils"
+)
+
+const testKubeconfig = "apiVersion: v1\nkind: Config\n"
+
+func enableTestEncryption(t *testing.T, retention time.Duration) {
+	t.Helper()
+	utils.CONFIG.Encryption.MasterKey = base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", dataKeySize)))
+	previousRetention := dataKeysRetention
+	dataKeysRetention = retention
+	resetDataKeys := func() {
+		dataKeysLock.Lock()
+		dataKeys = map[string][]byte{}
+		dataKeysLock.Unlock()
+		store.Remove(utils.KEYSSECRET, SecDataKeys)
+	}
+	resetDataKeys()
+	t.Cleanup(func() {
+		utils.CONFIG.Encryption.MasterKey = ""
+		dataKeysRetention = previousRetention
+		resetDataKeys()
+	})
+}
+
+func encryptedKeyId(t *testing.T, ctx dtos.PunqContext) string {
+	t.Helper()
+	parts := strings.SplitN(ctx.EncryptedContext, ":", 3)
+	if len(parts) != 3 {
+		t.Fatalf("malformed encrypted context '%s'", ctx.EncryptedContext)
+	}
+	return parts[1]
+}
+
+func TestEncryptDecryptContext(t *testing.T) {
+	enableTestEncryption(t, time.Minute)
+
+	encrypt := func(id string) dtos.PunqContext {
+		ctx := dtos.PunqContext{Id: id, Context: testKubeconfig}
+		if err := EncryptContext(&ctx); err != nil {
+			t.Fatal(err)
+		}
+		return ctx
+	}
+	legacy := func() dtos.PunqContext {
+		ctx := encrypt("ctx-legacy")
+		sealed, err := encryptData(dataKeys[activeDataKeyId], []byte(testKubeconfig), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx.EncryptedContext = "v1:" + activeDataKeyId + ":" + base64.StdEncoding.EncodeToString(sealed)
+		return ctx
+	}
+	copied := func() dtos.PunqContext {
+		ctx := encrypt("ctx-source")
+		ctx.Id = "ctx-target"
+		return ctx
+	}
+	tampered := func() dtos.PunqContext {
+		ctx := encrypt("ctx-tampered")
+		ctx.EncryptedContext = strings.Replace(ctx.EncryptedContext, "v2:", "v1:", 1)
+		return ctx
+	}
+
+	tests := []struct {
+		name    string
+		ctx     dtos.PunqContext
+		wantErr bool
+	}{
+		{"round trip", encrypt("ctx-plain"), false},
+		{"legacy v1 format", legacy(), false},
+		{"ciphertext of another context", copied(), true},
+		{"v2 ciphertext declared as v1", tampered(), true},
+		{"unknown format", dtos.PunqContext{Id: "ctx-x", EncryptedContext: "v9:key:data"}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.ctx.Context != "" || test.ctx.EncryptedContext == "" {
+				t.Fatal("context has not been encrypted")
+			}
+			err := DecryptContext(&test.ctx)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("DecryptContext() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if !test.wantErr && test.ctx.Context != testKubeconfig {
+				t.Errorf("decrypted '%s', want '%s'", test.ctx.Context, testKubeconfig)
+			}
+		})
+	}
+}
+
+func TestRotateEncryptionKey(t *testing.T) {
+	enableTestEncryption(t, 0)
+
+	ctx := dtos.PunqContext{Id: "ctx-rotate", Name: "rotate", Context: testKubeconfig, ContextHash: "rotate"}
+	rawData, err := marshalContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(utils.CONTEXTSSECRET, ctx.Id, rawData); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		store.Remove(utils.CONTEXTSSECRET, ctx.Id)
+		kubernetes.ContextRemove(ctx.Id)
+	})
+	before := loadStoredContext(t, ctx.Id)
+
+	if _, err := RotateEncryptionKey(); err != nil {
+		t.Fatal(err)
+	}
+
+	after := loadStoredContext(t, ctx.Id)
+	if encryptedKeyId(t, after) == encryptedKeyId(t, before) {
+		t.Error("context has not been re-encrypted with the new data key")
+	}
+	stored, err := storedDataKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 1 || stored[0].Id != encryptedKeyId(t, after) {
+		t.Errorf("unused retired data key has not been dropped: %+v", stored)
+	}
+	decrypted, err := GetContext(ctx.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decrypted.Context != testKubeconfig {
+		t.Errorf("decrypted '%s' after rotation", decrypted.Context)
+	}
+}
+
+func TestDropUnusedDataKeysKeepsRecentlyRetiredKeys(t *testing.T) {
+	enableTestEncryption(t, DataKeysReloadSeconds*time.Second)
+
+	now := time.Now()
+	keys := []DataKey{
+		{Id: "active"},
+		{Id: "retired-just-now", RetiredAt: now.Format(time.RFC3339)},
+		{Id: "retired-within-reload", RetiredAt: now.Add(-30 * time.Second).Format(time.RFC3339)},
+		{Id: "retired-long-ago", RetiredAt: now.Add(-time.Hour).Format(time.RFC3339)},
+	}
+	rawData, err := json.Marshal(keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(utils.KEYSSECRET, SecDataKeys, rawData); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := dropUnusedDataKeys(); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := storedDataKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept := []string{}
+	for _, dataKey := range stored {
+		kept = append(kept, dataKey.Id)
+	}
+	if strings.Join(kept, ",") != "active,retired-just-now,retired-within-reload" {
+		t.Errorf("kept data keys %v", kept)
+	}
+}
+
+// loadStoredContext returns the context as stored (still encrypted).
+func loadStoredContext(t *testing.T, id string) dtos.PunqContext {
+	t.Helper()
+	contextRaw, err := store.Value(utils.CONTEXTSSECRET, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := dtos.PunqContext{}
+	if err := json.Unmarshal(contextRaw, &ctx); err != nil {
+		t.Fatal(err)
+	}
+	return ctx
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/mogenius/punq/dtos"
+	"github.com/mogenius/punq/logger"
+	"github.com/mogenius/punq/store"
+	"github.com/mogenius/punq/utils"
+)
+
+func ListGroups() []dtos.PunqGroup {
+	groups := []dtos.PunqGroup{}
+
+	values, err := store.Values(utils.GROUPSSECRET)
+	if err != nil {
+		logger.Log.Errorf("Failed to list '%s': %s", utils.GROUPSSECRET, err.Error())
+		return groups
+	}
+
+	for groupId, groupRaw := range values {
+		group := dtos.PunqGroup{}
+		err := json.Unmarshal(groupRaw, &group)
+		if err != nil {
+			logger.Log.Errorf("Failed to Unmarshal group '%s'.", groupId)
+			continue
+		}
+		groups = append(groups, group)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Name < groups[j].Name
+	})
+	return groups
+}
+
+// GroupIdsForUser returns the ids of all groups the user is a member of.
+func GroupIdsForUser(userId string) []string {
+	result := []string{}
+	for _, group := range ListGroups() {
+		if group.HasMember(userId) {
+			result = append(result, group.Id)
+		}
+	}
+	return result
+}
+
+func GetGroup(id string) (*dtos.PunqGroup, error) {
+	groupRaw, err := store.Value(utils.GROUPSSECRET, id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, errors.New("group not found")
+		}
+		return nil, err
+	}
+
+	group := dtos.PunqGroup{}
+	err = json.Unmarshal(groupRaw, &group)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to Unmarshal group '%s'.", id)
+		logger.Log.Error(msg)
+		return nil, errors.New(msg)
+	}
+	return &group, nil
+}
+
+func AddGroup(input dtos.PunqGroupCreateInput) (*dtos.PunqGroup, error) {
+	if input.Name == "" {
+		return nil, errors.New("group name is required")
+	}
+	for _, group := range ListGroups() {
+		if group.Name == input.Name {
+			return nil, fmt.Errorf("Duplicated group name: '%s'", input.Name)
+		}
+	}
+
+	group := dtos.PunqGroup{
+		Id:          utils.NanoId(),
+		Name:        input.Name,
+		Description: input.Description,
+		UserIds:     []string{},
+		Created:     time.Now().Format(time.RFC3339),
+	}
+	for _, userId := range input.UserIds {
+		if _, err := GetUser(userId); err != nil {
+			return nil, fmt.Errorf("user '%s' not found", userId)
+		}
+		group.AddMember(userId)
+	}
+
+	rawData, err := json.Marshal(group)
+	if err != nil {
+		errStr := fmt.Sprintf("failed to Marshal group '%s'", group.Id)
+		logger.Log.Error(errStr)
+		return nil, errors.New(errStr)
+	}
+	err = store.Create(utils.GROUPSSECRET, group.Id, rawData)
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+func UpdateGroup(input dtos.PunqGroup) (*dtos.PunqGroup, error) {
+	if input.Name != "" {
+		for _, aGroup := range ListGroups() {
+			if aGroup.Name == input.Name && aGroup.Id != input.Id {
+				return nil, fmt.Errorf("Duplicated group name: '%s'", input.Name)
+			}
+		}
+	}
+	for _, userId := range input.UserIds {
+		if _, err := GetUser(userId); err != nil {
+			return nil, fmt.Errorf("user '%s' not found", userId)
+		}
+	}
+
+	return modifyGroup(input.Id, func(group *dtos.PunqGroup) error {
+		if input.Name != "" {
+			group.Name = input.Name
+		}
+		group.Description = input.Description
+		if input.UserIds != nil {
+			group.UserIds = []string{}
+			for _, userId := range input.UserIds {
+				group.AddMember(userId)
+			}
+		}
+		return nil
+	})
+}
+
+func AddGroupMember(groupId string, userId string) (*dtos.PunqGroup, error) {
+	if _, err := GetUser(userId); err != nil {
+		return nil, err
+	}
+
+	return modifyGroup(groupId, func(group *dtos.PunqGroup) error {
+		group.AddMember(userId)
+		return nil
+	})
+}
+
+func RemoveGroupMember(groupId string, userId string) (*dtos.PunqGroup, error) {
+	return modifyGroup(groupId, func(group *dtos.PunqGroup) error {
+		group.RemoveMember(userId)
+		return nil
+	})
+}
+
+// RemoveUserFromAllGroups is called when a user is deleted.
+func RemoveUserFromAllGroups(userId string) {
+	for _, group := range ListGroups() {
+		if group.HasMember(userId) {
+			_, err := RemoveGroupMember(group.Id, userId)
+			if err != nil {
+				logger.Log.Errorf("Failed to remove user '%s' from group '%s': %s", userId, group.Id, err.Error())
+			}
+		}
+	}
+}
+
+func DeleteGroup(id string) error {
+	err := store.Remove(utils.GROUPSSECRET, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return fmt.Errorf("group '%s' not found", id)
+	}
+	if err != nil {
+		return err
+	}
+
+	DeleteRoleBindingsForGroup(id)
+	for _, ctx := range ListContexts() {
+		if utils.ContainsEqual(ctx.Groups, id) {
+			_, err := ModifyContext(ctx.Id, func(ctx *dtos.PunqContext) error {
+				ctx.RemoveGroupAccess(id)
+				return nil
+			})
+			if err != nil {
+				logger.Log.Errorf("Failed to remove group '%s' from context '%s': %s", id, ctx.Id, err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// modifyGroup applies a change to the current state of a group (retried on concurrent modifications).
+func modifyGroup(id string, modify func(group *dtos.PunqGroup) error) (*dtos.PunqGroup, error) {
+	result := dtos.PunqGroup{}
+	err := store.Modify(utils.GROUPSSECRET, id, func(current []byte, exists bool) ([]byte, error) {
+		if !exists {
+			return nil, errors.New("group not found")
+		}
+		group := dtos.PunqGroup{}
+		err := json.Unmarshal(current, &group)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to Unmarshal group '%s'.", id)
+		}
+
+		err = modify(&group)
+		if err != nil {
+			return nil, err
+		}
+		result = group
+
+		rawData, err := json.Marshal(group)
+		if err != nil {
+			errStr := fmt.Sprintf("failed to Marshal group '%s'", group.Id)
+			logger.Log.Error(errStr)
+			return nil, errors.New(errStr)
+		}
+		return rawData, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/mogenius/punq/dtos"
+)
+
+func TestUpdateGroupValidatesMembers(t *testing.T) {
+	member := testUser(t, "member@group.test")
+	group, err := AddGroup(dtos.PunqGroupCreateInput{Name: "update-members"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { DeleteGroup(group.Id) })
+
+	tests := []struct {
+		name    string
+		userIds []string
+		wantErr bool
+	}{
+		{"existing user", []string{member.Id}, false},
+		{"unknown user", []string{member.Id, "unknown-user"}, true},
+		{"no members", []string{}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			before, err := GetGroup(group.Id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			updated, err := UpdateGroup(dtos.PunqGroup{Id: group.Id, UserIds: test.userIds})
+			if (err != nil) != test.wantErr {
+				t.Fatalf("UpdateGroup() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if test.wantErr {
+				after, err := GetGroup(group.Id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(after.UserIds) != len(before.UserIds) {
+					t.Errorf("members changed to %v by a rejected update", after.UserIds)
+				}
+				return
+			}
+			if len(updated.UserIds) != len(test.userIds) {
+				t.Errorf("members %v, want %v", updated.UserIds, test.userIds)
+			}
+		})
+	}
+}
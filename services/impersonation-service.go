@@ -0,0 +1,61 @@
+package services
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/client-go/rest"
+
+	"github.com/mogenius/punq/dtos"
+	"github.com/mogenius/punq/kubernetes"
+)
+
+const (
+	ImpersonationPrefix        = "punq:"
+	ImpersonationAuthenticated = ImpersonationPrefix + "authenticated"
+)
+
+// ImpersonationConfigForUser derives the cluster identity of a punq user: the email as user name and the groups
+// "punq:authenticated", "punq:role:<accesslevel>" and "punq:group:<name>" for every punq group of the user.
+func ImpersonationConfigForUser(user *dtos.PunqUser) rest.ImpersonationConfig {
+	groups := []string{
+		ImpersonationAuthenticated,
+		ImpersonationPrefix + "role:" + strings.ToLower(user.AccessLevel.String()),
+	}
+	for _, group := range ListGroups() {
+		if group.HasMember(user.Id) {
+			groups = append(groups, ImpersonationPrefix+"group:"+group.Name)
+		}
+	}
+	return rest.ImpersonationConfig{
+		UserName: user.Email,
+		Groups:   groups,
+	}
+}
+
+// StartImpersonation registers the identity of the user for the given context id pointer if the context opted in.
+// The returned function releases the registration and must be called when the request is done.
+func StartImpersonation(user *dtos.PunqUser, contextId *string) func() {
+	if user == nil || contextId == nil {
+		return func() {}
+	}
+	ctx := kubernetes.ContextForId(*contextId)
+	if ctx == nil || !ctx.Impersonate {
+		return func() {}
+	}
+
+	kubernetes.RegisterImpersonation(contextId, ImpersonationConfigForUser(user))
+	return func() {
+		kubernetes.ReleaseIdentity(contextId)
+	}
+}
+
+// StartGinImpersonation pins the context id of the request (see GetGinContextId) and impersonates its user.
+func StartGinImpersonation(c *gin.Context, user *dtos.PunqUser) func() {
+	contextId := GetGinContextId(c)
+	if contextId == nil {
+		return func() {}
+	}
+	c.Set("contextId", contextId)
+	return StartImpersonation(user, contextId)
+}
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mogenius/punq/logger"
+	"github.com/mogenius/punq/store"
+	"github.com/mogenius/punq/utils"
+)
+
+const (
+	LegacyKeyId                = "legacy" // kid assumed for tokens without kid header
+	KeyRingReloadSeconds       = 60
+	KeyRotationCheckInterval   = time.Hour
+	RetiredKeyRetentionHours   = TokenExpHours // longest token lifetime signed by a retired key
+	keyRingUnknownKidReloadGap = 5 * time.Second
+)
+
+// signing keys ordered from oldest to newest; the newest key without RetiredAt signs new tokens
+var keyRing []*KeyPair
+var keyRingLoaded time.Time
+var keyRingLock sync.Mutex
+
+// GetKeyRing reads all keys from the store. The single key of older installations is returned as LegacyKeyId.
+func GetKeyRing() ([]*KeyPair, error) {
+	keyPairs, err := storedKeyPairs()
+	if err != nil {
+		return nil, err
+	}
+	if len(keyPairs) == 0 {
+		// no key (yet)
+		return rotateKeyPair()
+	}
+	return keyPairs, nil
+}
+
+func storedKeyPairs() ([]*KeyPair, error) {
+	data, err := store.Value(utils.JWTSECRET, SecKeyPairs)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return nil, err
+	}
+	if data != nil {
+		return parseKeyPairs(data)
+	}
+
+	legacyData, err := store.Value(utils.JWTSECRET, SecKeyPair)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return nil, err
+	}
+	return parseLegacyKeyPair(legacyData)
+}
+
+func parseKeyPairs(data []byte) ([]*KeyPair, error) {
+	keyPairs := []*KeyPair{}
+	err := json.Unmarshal(data, &keyPairs)
+	if err != nil {
+		msg := fmt.Sprintf("failed to Unmarshal '%s' %v.", SecKeyPairs, err)
+		logger.Log.Error(msg)
+		return nil, errors.New(msg)
+	}
+	return keyPairs, nil
+}
+
+func parseLegacyKeyPair(data []byte) ([]*KeyPair, error) {
+	if data == nil {
+		return []*KeyPair{}, nil
+	}
+	keyPair := KeyPair{}
+	err := json.Unmarshal(data, &keyPair)
+	if err != nil {
+		msg := fmt.Sprintf("failed to Unmarshal '%s' %v.", SecKeyPair, err)
+		logger.Log.Error(msg)
+		return nil, errors.New(msg)
+	}
+	keyPair.Id = LegacyKeyId
+	return []*KeyPair{&keyPair}, nil
+}
+
+func activeKeyPair(keyPairs []*KeyPair) (*KeyPair, error) {
+	for i := len(keyPairs) - 1; i >= 0; i-- {
+		if keyPairs[i].RetiredAt == "" {
+			return keyPairs[i], nil
+		}
+	}
+	return nil, errors.New("no active jwt signing key found")
+}
+
+// loadKeyRing refreshes the cached keys periodically (or immediately if force is set) so rotations of other
+// processes (cli or replicas) are picked up.
+func loadKeyRing(force bool) ([]*KeyPair, error) {
+	keyRingLock.Lock()
+	defer keyRingLock.Unlock()
+
+	if keyRing == nil || time.Since(keyRingLoaded) > KeyRingReloadSeconds*time.Second || (force && time.Since(keyRingLoaded) > keyRingUnknownKidReloadGap) {
+		keyPairs, err := GetKeyRing()
+		if err != nil {
+			if keyRing != nil {
+				logger.Log.Errorf("Failed to reload jwt keys (using cached keys): %s", err.Error())
+				return keyRing, nil
+			}
+			return nil, err
+		}
+		keyRing = keyPairs
+		keyRingLoaded = time.Now()
+	}
+	return keyRing, nil
+}
+
+func signingKeyPair() (*KeyPair, error) {
+	keyPairs, err := loadKeyRing(false)
+	if err != nil {
+		return nil, err
+	}
+	return activeKeyPair(keyPairs)
+}
+
+func verificationKeyPair(kid string) (*KeyPair, error) {
+	for _, force := range []bool{false, true} {
+		keyPairs, err := loadKeyRing(force)
+		if err != nil {
+			return nil, err
+		}
+		for _, keyPair := range keyPairs {
+			if keyPair.Id == kid {
+				return keyPair, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("unknown signing key '%s'", kid)
+}
+
+// RotateKeyPair generates a new signing key. Previous keys are retired but kept for verification until all
+// tokens they signed are expired.
+func RotateKeyPair() ([]*KeyPair, error) {
+	keyPairs, err := rotateKeyPair()
+	if err != nil {
+		return nil, err
+	}
+
+	keyRingLock.Lock()
+	keyRing = nil
+	keyRingLock.Unlock()
+	return keyPairs, nil
+}
+
+func rotateKeyPair() ([]*KeyPair, error) {
+	newKeyPair, err := generateAuthKeyPair()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*KeyPair{}
+	err = store.Modify(utils.JWTSECRET, SecKeyPairs, func(data []byte, exists bool) ([]byte, error) {
+		keyPairs, err := parseKeyPairs(data)
+		if !exists {
+			legacyData, legacyErr := store.Value(utils.JWTSECRET, SecKeyPair)
+			if legacyErr != nil && !errors.Is(legacyErr, store.ErrNotFound) {
+				return nil, legacyErr
+			}
+			keyPairs, err = parseLegacyKeyPair(legacyData)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		result = []*KeyPair{}
+		for _, keyPair := range keyPairs {
+			if keyPair.RetiredAt == "" {
+				keyPair.RetiredAt = now.Format(time.RFC3339)
+			}
+			retiredAt, err := time.Parse(time.RFC3339, keyPair.RetiredAt)
+			if err == nil && now.Sub(retiredAt) > RetiredKeyRetentionHours*time.Hour {
+				// every token signed by this key is expired
+				continue
+			}
+			result = append(result, keyPair)
+		}
+		result = append(result, newKeyPair)
+		return json.Marshal(result)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// migrated into SecKeyPairs as LegacyKeyId
+	err = store.Remove(utils.JWTSECRET, SecKeyPair)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		logger.Log.Errorf("Failed to remove '%s': %s", SecKeyPair, err.Error())
+	}
+	logger.Log.Noticef("Rotated jwt signing key. New kid: '%s' (%d verification keys).", newKeyPair.Id, len(result))
+	return result, nil
+}
+
+// InitJwtKeyRotation rotates the signing key automatically if utils.CONFIG.Auth.JwtKeyRotationDays is set.
+func InitJwtKeyRotation() {
+	days := utils.CONFIG.Auth.JwtKeyRotationDays
+	if days <= 0 {
+		return
+	}
+	logger.Log.Infof("Automatic jwt key rotation enabled (every %d days).", days)
+
+	go func() {
+		for {
+			rotateKeyPairIfDue(time.Duration(days) * 24 * time.Hour)
+			time.Sleep(KeyRotationCheckInterval)
+		}
+	}()
+}
+
+func rotateKeyPairIfDue(maxAge time.Duration) {
+	keyPair, err := signingKeyPair()
+	if err != nil {
+		logger.Log.Errorf("Jwt key rotation check failed: %s", err.Error())
+		return
+	}
+
+	// keys of older installations have no creation date and are rotated right away
+	created, err := time.Parse(time.RFC3339, keyPair.Created)
+	if err == nil && time.Since(created) < maxAge {
+		return
+	}
+
+	_, err = RotateKeyPair()
+	if err != nil {
+		logger.Log.Errorf("Jwt key rotation failed: %s", err.Error())
+	}
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/mogenius/punq/store"
+	"github.com/mogenius/punq/utils"
+)
+
+func tokenKid(t *testing.T, tokenString string) string {
+	t.Helper()
+	token, _, err := jwt.NewParser().ParseUnverified(tokenString, &PunqClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kid, _ := token.Header["kid"].(string)
+	return kid
+}
+
+func tokenType(t *testing.T, tokenString string) string {
+	t.Helper()
+	claims := PunqClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(tokenString, &claims); err != nil {
+		t.Fatal(err)
+	}
+	return claims.TokenType
+}
+
+// ageRetiredKey moves RetiredAt of a key back, as if the retention had passed.
+func ageRetiredKey(t *testing.T, kid string, age time.Duration) {
+	t.Helper()
+	err := store.Modify(utils.JWTSECRET, SecKeyPairs, func(data []byte, exists bool) ([]byte, error) {
+		keyPairs, err := parseKeyPairs(data)
+		if err != nil {
+			return nil, err
+		}
+		for _, keyPair := range keyPairs {
+			if keyPair.Id == kid {
+				keyPair.RetiredAt = time.Now().Add(-age).Format(time.RFC3339)
+			}
+		}
+		return json.Marshal(keyPairs)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRotatedKeysVerifyByKid(t *testing.T) {
+	InitAuthService()
+	user := testUser(t, "rotation@jwt.test")
+
+	oldToken, err := GenerateToken(user, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldKid := tokenKid(t, oldToken.Token)
+
+	if _, err := RotateKeyPair(); err != nil {
+		t.Fatal(err)
+	}
+	newToken, err := GenerateToken(user, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newKid := tokenKid(t, newToken.Token)
+	if newKid == oldKid {
+		t.Fatalf("token signed with kid '%s' after the rotation", oldKid)
+	}
+
+	// both keys verify by kid
+	for _, token := range []string{oldToken.Token, oldToken.RefreshToken, newToken.Token} {
+		if _, err := parseToken(token, tokenType(t, token)); err != nil {
+			t.Errorf("token of kid '%s' rejected: %s", tokenKid(t, token), err.Error())
+		}
+	}
+
+	// the next rotation drops the retired key once its retention has passed
+	ageRetiredKey(t, oldKid, RetiredKeyRetentionHours*time.Hour+time.Hour)
+	if _, err := RotateKeyPair(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ValidationToken(oldToken.Token); err == nil || !strings.Contains(err.Error(), "unknown signing key") {
+		t.Errorf("token of dropped key '%s': error = %v", oldKid, err)
+	}
+	if _, err := ValidationToken(newToken.Token); err != nil {
+		t.Errorf("token of recently retired key '%s' rejected: %s", newKid, err.Error())
+	}
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/mogenius/punq/dtos"
+	"github.com/mogenius/punq/logger"
+)
+
+const (
+	LoginMaxAccountFailures = 5  // consecutive failures before an account is locked
+	LoginMaxIpFailures      = 20 // failures per ip before the ip is locked
+	LoginLockoutBaseSeconds = 30 // first lockout, doubled for every further failure
+	LoginLockoutMaxSeconds  = 60 * 60
+	LoginIpCounterTTL       = 24 * time.Hour
+)
+
+// same message for unknown users, wrong passwords and lockouts to prevent account enumeration
+var ErrLoginFailed = errors.New("username or password is incorrect")
+
+type loginAttempts struct {
+	Failures    int
+	LockedUntil time.Time
+	LastFailure time.Time
+}
+
+var ipLoginAttempts = map[string]*loginAttempts{}
+var ipLoginAttemptsLock sync.Mutex
+
+// used to spend the same time on unknown users as on existing ones
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("punq-dummy-password"), bcrypt.DefaultCost)
+
+func lockoutDuration(failures int, threshold int) time.Duration {
+	if failures < threshold {
+		return 0
+	}
+	seconds := float64(LoginLockoutBaseSeconds) * math.Pow(2, float64(failures-threshold))
+	if seconds > LoginLockoutMaxSeconds {
+		seconds = LoginLockoutMaxSeconds
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// Login checks the credentials and applies per-account and per-ip lockouts with exponential backoff.
+func Login(email string, password string, clientIp string) (*dtos.PunqUser, error) {
+	if isIpLocked(clientIp) {
+		logger.Log.Warningf("Login attempt for '%s' from locked ip '%s' rejected.", email, clientIp)
+		return nil, ErrLoginFailed
+	}
+
+	user, _ := GetUserByEmail(email)
+	if user == nil {
+		_ = bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		registerIpFailure(clientIp)
+		return nil, ErrLoginFailed
+	}
+
+	if user.IsLocked() {
+		registerIpFailure(clientIp)
+		logger.Log.Warningf("Login attempt for locked user '%s' from ip '%s' rejected (locked until %s).", user.Id, clientIp, user.LockedUntil)
+		return nil, ErrLoginFailed
+	}
+
+	valid, _ := user.PasswordCheck(password)
+	if !valid {
+		registerIpFailure(clientIp)
+		registerAccountFailure(user, clientIp)
+		return nil, ErrLoginFailed
+	}
+
+	if user.FailedLogins > 0 || user.LockedUntil != "" {
+		_, err := modifyUser(user.Id, func(current *dtos.PunqUser) error {
+			current.FailedLogins = 0
+			current.LockedUntil = ""
+			return nil
+		})
+		if err != nil {
+			logger.Log.Errorf("Failed to reset login failures of user '%s': %s", user.Id, err.Error())
+		}
+		user.FailedLogins = 0
+		user.LockedUntil = ""
+	}
+	return user, nil
+}
+
+// registerAccountFailure counts on the stored user, parallel guesses must not get lost.
+func registerAccountFailure(user *dtos.PunqUser, clientIp string) {
+	var lockout time.Duration
+	updated, err := modifyUser(user.Id, func(current *dtos.PunqUser) error {
+		current.FailedLogins++
+		lockout = lockoutDuration(current.FailedLogins, LoginMaxAccountFailures)
+		if lockout > 0 {
+			current.LockedUntil = time.Now().Add(lockout).Format(time.RFC3339)
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Log.Errorf("Failed to store login failure of user '%s': %s", user.Id, err.Error())
+		return
+	}
+	if lockout > 0 {
+		logger.Log.Warningf("User '%s' locked for %s after %d failed logins (last from ip '%s').", user.Id, lockout, updated.FailedLogins, clientIp)
+	}
+}
+
+func isIpLocked(clientIp string) bool {
+	ipLoginAttemptsLock.Lock()
+	defer ipLoginAttemptsLock.Unlock()
+
+	attempts, exists := ipLoginAttempts[clientIp]
+	return exists && time.Now().Before(attempts.LockedUntil)
+}
+
+func registerIpFailure(clientIp string) {
+	ipLoginAttemptsLock.Lock()
+	defer ipLoginAttemptsLock.Unlock()
+
+	// forget stale counters
+	for ip, attempts := range ipLoginAttempts {
+		if time.Since(attempts.LastFailure) > LoginIpCounterTTL {
+			delete(ipLoginAttempts, ip)
+		}
+	}
+
+	attempts, exists := ipLoginAttempts[clientIp]
+	if !exists {
+		attempts = &loginAttempts{}
+		ipLoginAttempts[clientIp] = attempts
+	}
+	attempts.Failures++
+	attempts.LastFailure = time.Now()
+
+	lockout := lockoutDuration(attempts.Failures, LoginMaxIpFailures)
+	if lockout > 0 {
+		attempts.LockedUntil = time.Now().Add(lockout)
+		logger.Log.Warningf("Ip '%s' locked for %s after %d failed logins.", clientIp, lockout, attempts.Failures)
+	}
+}
+
+// UnlockUser clears the failed login counter and lockout of a user.
+func UnlockUser(id string) (*dtos.PunqUser, error) {
+	user, err := modifyUser(id, func(user *dtos.PunqUser) error {
+		user.FailedLogins = 0
+		user.LockedUntil = ""
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	logger.Log.Noticef("User '%s' unlocked.", user.Id)
+	return user, nil
+}
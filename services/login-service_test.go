@@ -0,0 +1,161 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mogenius/punq/dtos"
+	"github.com/mogenius/punq/store"
+)
+
+func TestLockoutDuration(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{LoginMaxAccountFailures - 1, 0},
+		{LoginMaxAccountFailures, LoginLockoutBaseSeconds * time.Second},
+		{LoginMaxAccountFailures + 1, 2 * LoginLockoutBaseSeconds * time.Second},
+		{LoginMaxAccountFailures + 2, 4 * LoginLockoutBaseSeconds * time.Second},
+		{LoginMaxAccountFailures + 20, LoginLockoutMaxSeconds * time.Second},
+	}
+
+	for _, test := range tests {
+		if got := lockoutDuration(test.failures, LoginMaxAccountFailures); got != test.want {
+			t.Errorf("lockoutDuration(%d) = %s, want %s", test.failures, got, test.want)
+		}
+	}
+}
+
+func forgetIp(t *testing.T, clientIp string) {
+	t.Cleanup(func() {
+		ipLoginAttemptsLock.Lock()
+		delete(ipLoginAttempts, clientIp)
+		ipLoginAttemptsLock.Unlock()
+	})
+}
+
+func TestIpLockout(t *testing.T) {
+	clientIp := "198.51.100.10"
+	forgetIp(t, clientIp)
+
+	for i := 1; i < LoginMaxIpFailures; i++ {
+		registerIpFailure(clientIp)
+	}
+	if isIpLocked(clientIp) {
+		t.Fatalf("ip locked after %d failures", LoginMaxIpFailures-1)
+	}
+	registerIpFailure(clientIp)
+	if !isIpLocked(clientIp) {
+		t.Fatalf("ip not locked after %d failures", LoginMaxIpFailures)
+	}
+	if isIpLocked("198.51.100.11") {
+		t.Error("lockout applies to other ips")
+	}
+}
+
+func TestLoginErrorsAreUniform(t *testing.T) {
+	user := testUser(t, "uniform@login.test")
+	locked := testUser(t, "locked@login.test")
+	if _, err := modifyUser(locked.Id, func(user *dtos.PunqUser) error {
+		user.LockedUntil = time.Now().Add(time.Hour).Format(time.RFC3339)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	lockedIp := "198.51.100.20"
+	forgetIp(t, lockedIp)
+	for i := 0; i < LoginMaxIpFailures; i++ {
+		registerIpFailure(lockedIp)
+	}
+
+	tests := []struct {
+		name     string
+		email    string
+		password string
+		clientIp string
+	}{
+		{"unknown user", "unknown@login.test", "secret", "198.51.100.21"},
+		{"wrong password", user.Email, "wrong", "198.51.100.22"},
+		{"locked user", locked.Email, "secret", "198.51.100.23"},
+		{"locked ip", user.Email, "secret", lockedIp},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			forgetIp(t, test.clientIp)
+			_, err := Login(test.email, test.password, test.clientIp)
+			if !errors.Is(err, ErrLoginFailed) {
+				t.Errorf("Login() error = %v, want %v", err, ErrLoginFailed)
+			}
+		})
+	}
+}
+
+func TestConcurrentAccountFailures(t *testing.T) {
+	user := testUser(t, "concurrent@login.test")
+	stale := *user
+
+	// an admin edit between reading the user and counting the failures must survive
+	update := *user
+	update.DisplayName = "renamed"
+	if _, err := UpdateUser(update); err != nil {
+		t.Fatal(err)
+	}
+
+	// every conflict means another failure has been stored, so ModifyRetries parallel failures never give up
+	failures := store.ModifyRetries
+	wg := sync.WaitGroup{}
+	for i := 0; i < failures; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			staleCopy := stale
+			registerAccountFailure(&staleCopy, "198.51.100.30")
+		}()
+	}
+	wg.Wait()
+
+	stored, err := GetUser(user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.FailedLogins != failures {
+		t.Errorf("FailedLogins = %d, want %d", stored.FailedLogins, failures)
+	}
+	if !stored.IsLocked() {
+		t.Error("user is not locked")
+	}
+	if stored.DisplayName != "renamed" {
+		t.Errorf("DisplayName = %s, the concurrent update has been reverted", stored.DisplayName)
+	}
+}
+
+func TestUnlockUser(t *testing.T) {
+	user := testUser(t, "unlock@login.test")
+	clientIp := "198.51.100.40"
+	forgetIp(t, clientIp)
+
+	for i := 0; i < LoginMaxAccountFailures; i++ {
+		if _, err := Login(user.Email, "wrong", clientIp); err == nil {
+			t.Fatal("Login() with wrong password succeeded")
+		}
+	}
+	if _, err := Login(user.Email, "secret", clientIp); err == nil {
+		t.Fatal("locked user logged in")
+	}
+
+	unlocked, err := UnlockUser(user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unlocked.FailedLogins != 0 || unlocked.LockedUntil != "" {
+		t.Errorf("UnlockUser() = %+v", unlocked)
+	}
+	if _, err := Login(user.Email, "secret", clientIp); err != nil {
+		t.Errorf("Login() after unlock failed: %s", err.Error())
+	}
+}
@@ -0,0 +1,23 @@
+package services
+
+import (
+	"os"
+	"testing"
+
+	"github.com/mogenius/punq/store"
+	"github.com/mogenius/punq/utils"
+)
+
+// TestMain runs all tests against a file store in a temporary directory.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "punq-services-test")
+	if err != nil {
+		panic(err)
+	}
+	utils.CONFIG.Store.Type = store.TypeFile
+	utils.CONFIG.Store.FilePath = dir
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
@@ -0,0 +1,333 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mogenius/punq/dtos"
+	"github.com/mogenius/punq/logger"
+	"github.com/mogenius/punq/utils"
+)
+
+const (
+	TokenTypeMfa       = "mfa"
+	MfaTokenExpMinutes = 5
+	TotpIssuer         = "punq"
+	TotpDigits         = 6
+	TotpPeriodSeconds  = 30
+	TotpSkewSteps      = 1 // accepted clock drift in steps (before and after)
+	TotpSecretBytes    = 20
+	RecoveryCodeCount  = 10
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// totpCode computes the RFC 6238 code (HMAC-SHA1, RFC 4226 truncation) for a time step.
+func totpCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < TotpDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", TotpDigits, value%mod), nil
+}
+
+// validateTotp returns the matched time step. Steps up to lastStep are rejected to prevent replays.
+func validateTotp(secret string, code string, lastStep int64) (int64, bool) {
+	return validateTotpAt(secret, code, lastStep, time.Now())
+}
+
+func validateTotpAt(secret string, code string, lastStep int64, now time.Time) (int64, bool) {
+	code = strings.ReplaceAll(code, " ", "")
+	if len(code) != TotpDigits {
+		return 0, false
+	}
+
+	current := now.Unix() / TotpPeriodSeconds
+	for step := current - TotpSkewSteps; step <= current+TotpSkewSteps; step++ {
+		if step <= lastStep {
+			continue
+		}
+		expected, err := totpCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+func hashRecoveryCode(code string) string {
+	hash := sha256.Sum256([]byte(strings.ToLower(strings.ReplaceAll(code, "-", ""))))
+	return hex.EncodeToString(hash[:])
+}
+
+func generateRecoveryCodes() ([]string, []string) {
+	codes := []string{}
+	hashes := []string{}
+	for i := 0; i < RecoveryCodeCount; i++ {
+		raw := utils.NanoIdSmallLowerCase()
+		code := fmt.Sprintf("%s-%s", raw[:5], raw[5:])
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes
+}
+
+var errInvalidSecondFactor = errors.New("invalid code")
+
+// checkSecondFactor accepts a TOTP code or (once) a recovery code. The check and the consumption of the step/code
+// happen on the stored user in one store.Modify, so concurrent requests cannot use the same code twice.
+func checkSecondFactor(user *dtos.PunqUser, code string) bool {
+	usedRecoveryCode := false
+	updated, err := modifyUser(user.Id, func(current *dtos.PunqUser) error {
+		usedRecoveryCode = false
+		if !current.TotpEnabled {
+			return errInvalidSecondFactor
+		}
+		if step, ok := validateTotp(current.TotpSecret, code, current.TotpLastStep); ok {
+			current.TotpLastStep = step
+			return nil
+		}
+
+		hash := hashRecoveryCode(code)
+		for index, recoveryHash := range current.TotpRecoveryCodes {
+			if subtle.ConstantTimeCompare([]byte(recoveryHash), []byte(hash)) == 1 {
+				current.TotpRecoveryCodes = append(current.TotpRecoveryCodes[:index], current.TotpRecoveryCodes[index+1:]...)
+				usedRecoveryCode = true
+				return nil
+			}
+		}
+		return errInvalidSecondFactor
+	})
+	if errors.Is(err, errInvalidSecondFactor) {
+		return false
+	}
+	if err != nil {
+		logger.Log.Errorf("Failed to store the second factor of user '%s': %s", user.Id, err.Error())
+		return false
+	}
+
+	*user = *updated
+	if usedRecoveryCode {
+		logger.Log.Noticef("User '%s' used a recovery code (%d left).", user.Id, len(user.TotpRecoveryCodes))
+	}
+	return true
+}
+
+// MfaChallenge issues a short-lived token that can only be exchanged for a token pair by VerifyMfa.
+func MfaChallenge(user *dtos.PunqUser) (*dtos.PunqMfaChallenge, error) {
+	expiresAt := time.Now().Add(time.Minute * time.Duration(MfaTokenExpMinutes))
+	mfaToken, err := signToken(user, "", TokenTypeMfa, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return &dtos.PunqMfaChallenge{
+		MfaRequired:        true,
+		EnrollmentRequired: !user.TotpEnabled,
+		MfaToken:           mfaToken,
+		ExpiresAt:          expiresAt.Format(time.RFC3339),
+	}, nil
+}
+
+func userForMfaToken(mfaToken string) (*PunqClaims, *dtos.PunqUser, error) {
+	claims, err := parseToken(mfaToken, TokenTypeMfa)
+	if err != nil {
+		return nil, nil, err
+	}
+	user, err := userForClaims(claims)
+	if err != nil {
+		return nil, nil, err
+	}
+	return claims, user, nil
+}
+
+// EnrollTotpWithMfaToken starts the enrollment of users that are forced to use a second factor but have none yet.
+func EnrollTotpWithMfaToken(mfaToken string) (*dtos.PunqTotpEnrollment, error) {
+	_, user, err := userForMfaToken(mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	return BeginTotpEnrollment(user)
+}
+
+// VerifyMfa completes a two-step login. If the user is enrolling, the code confirms the pending secret and the
+// recovery codes are returned once.
+func VerifyMfa(mfaToken string, code string, clientIp string, userAgent string) (*dtos.PunqMfaVerifyResult, error) {
+	claims, user, err := userForMfaToken(mfaToken)
+	if err != nil {
+		return nil, err
+	}
+
+	result := dtos.PunqMfaVerifyResult{}
+	if user.TotpEnabled {
+		if !checkSecondFactor(user, code) {
+			// one guess per challenge, a new one requires the (rate limited) password login again
+			_ = RevokeToken(claims)
+			logger.Log.Warningf("Invalid second factor for user '%s'.", user.Id)
+			return nil, errInvalidSecondFactor
+		}
+	} else {
+		recoveryCodes, err := ConfirmTotpEnrollment(user, code)
+		if err != nil {
+			_ = RevokeToken(claims)
+			return nil, err
+		}
+		result.RecoveryCodes = recoveryCodes
+	}
+
+	// mfa tokens are single use
+	err = RevokeToken(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := GenerateToken(user, clientIp, userAgent)
+	if err != nil {
+		return nil, err
+	}
+	result.PunqToken = *token
+	return &result, nil
+}
+
+// BeginTotpEnrollment stores a new pending secret. It becomes active with ConfirmTotpEnrollment.
+func BeginTotpEnrollment(user *dtos.PunqUser) (*dtos.PunqTotpEnrollment, error) {
+	key := make([]byte, TotpSecretBytes)
+	_, err := rand.Read(key)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := modifyUser(user.Id, func(current *dtos.PunqUser) error {
+		if current.TotpEnabled {
+			return errors.New("two-factor authentication is already enabled")
+		}
+		current.TotpSecret = totpEncoding.EncodeToString(key)
+		current.TotpLastStep = 0
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	*user = *updated
+
+	label := url.PathEscape(fmt.Sprintf("%s:%s", TotpIssuer, user.Email))
+	params := url.Values{}
+	params.Set("secret", user.TotpSecret)
+	params.Set("issuer", TotpIssuer)
+	params.Set("digits", fmt.Sprint(TotpDigits))
+	params.Set("period", fmt.Sprint(TotpPeriodSeconds))
+
+	return &dtos.PunqTotpEnrollment{
+		Secret: user.TotpSecret,
+		Url:    fmt.Sprintf("otpauth://totp/%s?%s", label, params.Encode()),
+	}, nil
+}
+
+// ConfirmTotpEnrollment enables the pending secret and returns freshly generated recovery codes.
+func ConfirmTotpEnrollment(user *dtos.PunqUser, code string) ([]string, error) {
+	codes, hashes := generateRecoveryCodes()
+	updated, err := modifyUser(user.Id, func(current *dtos.PunqUser) error {
+		if current.TotpEnabled {
+			return errors.New("two-factor authentication is already enabled")
+		}
+		if current.TotpSecret == "" {
+			return errors.New("no pending two-factor enrollment")
+		}
+
+		step, ok := validateTotp(current.TotpSecret, code, 0)
+		if !ok {
+			return errInvalidSecondFactor
+		}
+		current.TotpEnabled = true
+		current.TotpLastStep = step
+		current.TotpRecoveryCodes = hashes
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	*user = *updated
+	logger.Log.Noticef("User '%s' enabled two-factor authentication.", user.Id)
+	return codes, nil
+}
+
+// DisableTotp lets users remove their own second factor (not possible if an admin requires it).
+func DisableTotp(user *dtos.PunqUser, code string) error {
+	if !user.TotpEnabled {
+		return errors.New("two-factor authentication is not enabled")
+	}
+	if user.MfaRequired {
+		return errors.New("two-factor authentication is required for this user")
+	}
+	if !checkSecondFactor(user, code) {
+		return errInvalidSecondFactor
+	}
+
+	updated, err := modifyUser(user.Id, func(current *dtos.PunqUser) error {
+		if current.MfaRequired {
+			return errors.New("two-factor authentication is required for this user")
+		}
+		clearTotp(current)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	*user = *updated
+	logger.Log.Noticef("User '%s' disabled two-factor authentication.", user.Id)
+	return nil
+}
+
+// ResetTotp removes the second factor of a user (e.g. lost device). The user has to enroll again if required.
+func ResetTotp(userId string) (*dtos.PunqUser, error) {
+	user, err := modifyUser(userId, func(user *dtos.PunqUser) error {
+		clearTotp(user)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	logger.Log.Noticef("Two-factor authentication of user '%s' has been reset.", user.Id)
+	return user, nil
+}
+
+// SetMfaRequired enforces (or stops enforcing) a second factor for a user.
+func SetMfaRequired(userId string, required bool) (*dtos.PunqUser, error) {
+	return modifyUser(userId, func(user *dtos.PunqUser) error {
+		user.MfaRequired = required
+		return nil
+	})
+}
+
+func clearTotp(user *dtos.PunqUser) {
+	user.TotpSecret = ""
+	user.TotpEnabled = false
+	user.TotpLastStep = 0
+	user.TotpRecoveryCodes = nil
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mogenius/punq/dtos"
+)
+
+// RFC 6238 appendix B (SHA1 secret "12345678901234567890"), the last six of the eight digits
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestValidateTotpRfc6238(t *testing.T) {
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, test := range tests {
+		now := time.Unix(test.unix, 0)
+		step, ok := validateTotpAt(rfc6238Secret, test.code, 0, now)
+		if !ok || step != test.unix/TotpPeriodSeconds {
+			t.Errorf("validateTotpAt(%s, %d) = %d, %v", test.code, test.unix, step, ok)
+		}
+		// accepted drift of one step
+		if _, ok := validateTotpAt(rfc6238Secret, test.code, 0, now.Add(TotpPeriodSeconds*time.Second)); !ok {
+			t.Errorf("code %s rejected one step later", test.code)
+		}
+		if _, ok := validateTotpAt(rfc6238Secret, test.code, 0, now.Add(2*TotpPeriodSeconds*time.Second)); ok {
+			t.Errorf("code %s accepted two steps later", test.code)
+		}
+		// replay of the same (or an older) step
+		if _, ok := validateTotpAt(rfc6238Secret, test.code, step, now); ok {
+			t.Errorf("code %s accepted again", test.code)
+		}
+	}
+
+	if _, ok := validateTotpAt(rfc6238Secret, "28708", 0, time.Unix(59, 0)); ok {
+		t.Error("short code accepted")
+	}
+	if _, ok := validateTotpAt(rfc6238Secret, "287 082", 0, time.Unix(59, 0)); !ok {
+		t.Error("code with blank rejected")
+	}
+}
+
+func totpUser(t *testing.T, email string, recoveryHashes []string) *dtos.PunqUser {
+	t.Helper()
+	user := testUser(t, email)
+	updated, err := modifyUser(user.Id, func(user *dtos.PunqUser) error {
+		user.TotpSecret = rfc6238Secret
+		user.TotpEnabled = true
+		user.TotpRecoveryCodes = recoveryHashes
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return updated
+}
+
+// acceptedConcurrently runs checkSecondFactor in parallel with stale copies of the user.
+func acceptedConcurrently(user *dtos.PunqUser, code string, parallel int) int {
+	accepted := 0
+	lock := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for i := 0; i < parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			staleCopy := *user
+			if checkSecondFactor(&staleCopy, code) {
+				lock.Lock()
+				accepted++
+				lock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return accepted
+}
+
+func TestTotpStepIsSingleUse(t *testing.T) {
+	user := totpUser(t, "totp-replay@mfa.test", nil)
+	code, err := totpCode(rfc6238Secret, time.Now().Unix()/TotpPeriodSeconds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if accepted := acceptedConcurrently(user, code, 3); accepted != 1 {
+		t.Errorf("code accepted %d times", accepted)
+	}
+	if checkSecondFactor(user, code) {
+		t.Error("code accepted again")
+	}
+}
+
+func TestRecoveryCodesAreSingleUse(t *testing.T) {
+	codes, hashes := generateRecoveryCodes()
+	user := totpUser(t, "recovery@mfa.test", hashes)
+
+	if accepted := acceptedConcurrently(user, codes[0], 3); accepted != 1 {
+		t.Errorf("recovery code accepted %d times", accepted)
+	}
+	stored, err := GetUser(user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored.TotpRecoveryCodes) != RecoveryCodeCount-1 {
+		t.Errorf("%d recovery codes left, want %d", len(stored.TotpRecoveryCodes), RecoveryCodeCount-1)
+	}
+	if checkSecondFactor(stored, codes[0]) {
+		t.Error("recovery code accepted again")
+	}
+	if !checkSecondFactor(stored, codes[1]) {
+		t.Error("unused recovery code rejected")
+	}
+	if checkSecondFactor(stored, "aaaaa-bbbbb") {
+		t.Error("unknown recovery code accepted")
+	}
+}
+
+func TestMfaRequiredForcesEnrollment(t *testing.T) {
+	InitAuthService()
+	user := testUser(t, "required@mfa.test")
+	required, err := SetMfaRequired(user.Id, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	challenge, err := MfaChallenge(required)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !challenge.MfaRequired || !challenge.EnrollmentRequired {
+		t.Errorf("MfaChallenge() = %+v", challenge)
+	}
+
+	// the challenge cannot be used as session token and a wrong code ends it
+	if _, err := ValidationToken(challenge.MfaToken); err == nil {
+		t.Error("mfa token accepted as session token")
+	}
+	if _, err := EnrollTotpWithMfaToken(challenge.MfaToken); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyMfa(challenge.MfaToken, "000000", "127.0.0.1", "test"); err == nil {
+		t.Fatal("wrong code accepted")
+	}
+	if _, err := VerifyMfa(challenge.MfaToken, "000000", "127.0.0.1", "test"); err == nil {
+		t.Error("mfa token usable after a wrong code")
+	}
+}
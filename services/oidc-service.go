@@ -0,0 +1,368 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/mogenius/punq/dtos"
+	"github.com/mogenius/punq/logger"
+	"github.com/mogenius/punq/utils"
+	"golang.org/x/oauth2"
+)
+
+const (
+	OidcStateTTL        = 10 * time.Minute
+	OidcDiscoveryTTL    = 1 * time.Hour
+	OidcDiscoverySuffix = "/.well-known/openid-configuration"
+)
+
+type OidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksUri               string `json:"jwks_uri"`
+}
+
+type oidcJwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type oidcPendingLogin struct {
+	Nonce   string
+	Expires time.Time
+}
+
+var oidcLock sync.Mutex
+var oidcDiscovery *OidcDiscovery
+var oidcDiscoveryFetched time.Time
+var oidcPendingLogins = map[string]oidcPendingLogin{}
+
+var oidcHttpClient = &http.Client{Timeout: 10 * time.Second}
+
+func OidcEnabled() bool {
+	return utils.CONFIG.Oidc.Enabled && utils.CONFIG.Oidc.Issuer != "" && utils.CONFIG.Oidc.ClientId != ""
+}
+
+func oidcGetJson(url string, target interface{}) error {
+	resp, err := oidcHttpClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(target)
+}
+
+func GetOidcDiscovery() (*OidcDiscovery, error) {
+	oidcLock.Lock()
+	defer oidcLock.Unlock()
+
+	if oidcDiscovery != nil && time.Since(oidcDiscoveryFetched) < OidcDiscoveryTTL {
+		return oidcDiscovery, nil
+	}
+
+	issuer := strings.TrimSuffix(utils.CONFIG.Oidc.Issuer, "/")
+	discovery := OidcDiscovery{}
+	err := oidcGetJson(issuer+OidcDiscoverySuffix, &discovery)
+	if err != nil {
+		logger.Log.Errorf("OIDC discovery for '%s' failed: %s", issuer, err.Error())
+		return nil, err
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("OIDC issuer mismatch (configured: '%s', discovered: '%s')", issuer, discovery.Issuer)
+	}
+
+	oidcDiscovery = &discovery
+	oidcDiscoveryFetched = time.Now()
+	return oidcDiscovery, nil
+}
+
+func oidcOauthConfig(discovery *OidcDiscovery) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     utils.CONFIG.Oidc.ClientId,
+		ClientSecret: utils.CONFIG.Oidc.ClientSecret,
+		RedirectURL:  utils.CONFIG.Oidc.RedirectUrl,
+		Scopes:       utils.CONFIG.Oidc.Scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  discovery.AuthorizationEndpoint,
+			TokenURL: discovery.TokenEndpoint,
+		},
+	}
+}
+
+// OidcAuthCodeUrl returns the URL of the provider login page and remembers the state/nonce pair for the callback.
+func OidcAuthCodeUrl() (string, error) {
+	if !OidcEnabled() {
+		return "", errors.New("OIDC login is not enabled")
+	}
+
+	discovery, err := GetOidcDiscovery()
+	if err != nil {
+		return "", err
+	}
+
+	state := utils.NanoIdExtraLong()
+	nonce := utils.NanoIdExtraLong()
+
+	oidcLock.Lock()
+	for key, pending := range oidcPendingLogins {
+		if time.Now().After(pending.Expires) {
+			delete(oidcPendingLogins, key)
+		}
+	}
+	oidcPendingLogins[state] = oidcPendingLogin{Nonce: nonce, Expires: time.Now().Add(OidcStateTTL)}
+	oidcLock.Unlock()
+
+	return oidcOauthConfig(discovery).AuthCodeURL(state, oauth2.SetAuthURLParam("nonce", nonce)), nil
+}
+
+// OidcLogin exchanges the authorization code, verifies the id_token and returns the linked (or newly created) punq user.
+func OidcLogin(state string, code string) (*dtos.PunqUser, error) {
+	if !OidcEnabled() {
+		return nil, errors.New("OIDC login is not enabled")
+	}
+
+	oidcLock.Lock()
+	pending, exists := oidcPendingLogins[state]
+	delete(oidcPendingLogins, state)
+	oidcLock.Unlock()
+	if !exists || time.Now().After(pending.Expires) {
+		return nil, errors.New("invalid or expired OIDC state")
+	}
+
+	discovery, err := GetOidcDiscovery()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, oidcHttpClient)
+	oauthToken, err := oidcOauthConfig(discovery).Exchange(ctx, code)
+	if err != nil {
+		logger.Log.Errorf("OIDC code exchange failed: %s", err.Error())
+		return nil, err
+	}
+
+	rawIdToken, ok := oauthToken.Extra("id_token").(string)
+	if !ok || rawIdToken == "" {
+		return nil, errors.New("OIDC token response contains no id_token")
+	}
+
+	claims, err := oidcVerifyIdToken(discovery, rawIdToken, pending.Nonce)
+	if err != nil {
+		logger.Log.Errorf("OIDC id_token verification failed: %s", err.Error())
+		return nil, err
+	}
+
+	return oidcUserFromClaims(discovery.Issuer, claims)
+}
+
+func oidcVerifyIdToken(discovery *OidcDiscovery, rawIdToken string, nonce string) (jwt.MapClaims, error) {
+	keys, err := oidcFetchKeys(discovery.JwksUri)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(rawIdToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if key, ok := keys[kid]; ok {
+			return key, nil
+		}
+		// providers with a single key sometimes omit the kid
+		if kid == "" && len(keys) == 1 {
+			for _, key := range keys {
+				return key, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown signing key '%s'", kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(discovery.Issuer),
+		jwt.WithAudience(utils.CONFIG.Oidc.ClientId),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if tokenNonce, _ := claims["nonce"].(string); tokenNonce != nonce {
+		return nil, errors.New("OIDC nonce mismatch")
+	}
+	return claims, nil
+}
+
+func oidcFetchKeys(jwksUri string) (map[string]interface{}, error) {
+	jwks := struct {
+		Keys []oidcJwk `json:"keys"`
+	}{}
+	err := oidcGetJson(jwksUri, &jwks)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{}
+	for _, key := range jwks.Keys {
+		publicKey, err := key.publicKey()
+		if err != nil {
+			logger.Log.Warningf("Skipping OIDC key '%s': %s", key.Kid, err.Error())
+			continue
+		}
+		result[key.Kid] = publicKey
+	}
+	if len(result) == 0 {
+		return nil, errors.New("OIDC provider published no usable keys")
+	}
+	return result, nil
+}
+
+func (key *oidcJwk) publicKey() (interface{}, error) {
+	decode := func(value string) (*big.Int, error) {
+		data, err := base64.RawURLEncoding.DecodeString(value)
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Int).SetBytes(data), nil
+	}
+
+	switch key.Kty {
+	case "RSA":
+		n, err := decode(key.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(key.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch key.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve '%s'", key.Crv)
+		}
+		x, err := decode(key.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(key.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type '%s'", key.Kty)
+	}
+}
+
+// OidcAccessLevelFromClaims maps the configured claim onto an AccessLevel. The highest matching level wins.
+func OidcAccessLevelFromClaims(claims jwt.MapClaims) dtos.AccessLevel {
+	values := []string{}
+	switch claim := claims[utils.CONFIG.Oidc.AccessLevelClaim].(type) {
+	case string:
+		values = append(values, claim)
+	case []interface{}:
+		for _, entry := range claim {
+			if str, ok := entry.(string); ok {
+				values = append(values, str)
+			}
+		}
+	}
+
+	level := dtos.AccessLevelFromString(utils.CONFIG.Oidc.DefaultAccessLevel)
+	for _, value := range values {
+		if utils.ContainsEqual(utils.CONFIG.Oidc.AdminValues, value) {
+			return dtos.ADMIN
+		}
+		if utils.ContainsEqual(utils.CONFIG.Oidc.UserValues, value) && level < dtos.USER {
+			level = dtos.USER
+		}
+	}
+	return level
+}
+
+func oidcUserFromClaims(issuer string, claims jwt.MapClaims) (*dtos.PunqUser, error) {
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, errors.New("OIDC id_token contains no subject")
+	}
+	email, _ := claims["email"].(string)
+	displayName, _ := claims["name"].(string)
+	if displayName == "" {
+		displayName = email
+	}
+	accessLevel := OidcAccessLevelFromClaims(claims)
+
+	// 1. already linked
+	for _, user := range ListUsers() {
+		if user.OidcIssuer == issuer && user.OidcSubject == subject {
+			if user.AccessLevel != accessLevel {
+				user.AccessLevel = accessLevel
+				return UpdateUser(user)
+			}
+			return &user, nil
+		}
+	}
+
+	if email == "" {
+		return nil, errors.New("OIDC id_token contains no email")
+	}
+	verified, exists := claims["email_verified"].(bool)
+	if exists && !verified {
+		return nil, fmt.Errorf("OIDC email '%s' is not verified", email)
+	}
+
+	// 2. link existing user with the same email (only if the provider vouches for the email)
+	existingUser, _ := GetUserByEmail(email)
+	if existingUser != nil {
+		if !verified {
+			return nil, fmt.Errorf("OIDC email '%s' is not verified, it cannot be linked to an existing user", email)
+		}
+		existingUser.OidcIssuer = issuer
+		existingUser.OidcSubject = subject
+		logger.Log.Noticef("Linked user '%s' to OIDC subject '%s'.", existingUser.Id, subject)
+		return UpdateUser(*existingUser)
+	}
+
+	// 3. create new user (password login stays unusable until an admin sets one)
+	newUser, err := AddUser(dtos.PunqUserCreateInput{
+		Email:       email,
+		Password:    utils.NanoIdExtraLong(),
+		DisplayName: displayName,
+		AccessLevel: accessLevel,
+		OidcIssuer:  issuer,
+		OidcSubject: subject,
+	})
+	if err != nil {
+		return nil, err
+	}
+	logger.Log.Noticef("Created user '%s' for OIDC subject '%s'.", newUser.Id, subject)
+	return newUser, nil
+}
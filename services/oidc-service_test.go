@@ -0,0 +1,201 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/mogenius/punq/dtos"
+	"github.com/mogenius/punq/utils"
+)
+
+// testOidcIssuer is a stand-in OIDC provider: discovery, jwks and a token endpoint which returns an id_token with
+// the claims of the test.
+type testOidcIssuer struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	claims jwt.MapClaims
+}
+
+func newTestOidcIssuer(t *testing.T) *testOidcIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer := &testOidcIssuer{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(OidcDiscoverySuffix, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(OidcDiscovery{
+			Issuer:                issuer.server.URL,
+			AuthorizationEndpoint: issuer.server.URL + "/authorize",
+			TokenEndpoint:         issuer.server.URL + "/token",
+			JwksUri:               issuer.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []oidcJwk{{
+				Kid: "test",
+				Kty: "RSA",
+				N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, issuer.claims)
+		token.Header["kid"] = "test"
+		idToken, err := token.SignedString(key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"id_token":     idToken,
+		})
+	})
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+
+	utils.CONFIG.Oidc.Enabled = true
+	utils.CONFIG.Oidc.Issuer = issuer.server.URL
+	utils.CONFIG.Oidc.ClientId = "punq"
+	utils.CONFIG.Oidc.RedirectUrl = "http://punq.local/auth/oidc/callback"
+	utils.CONFIG.Oidc.AccessLevelClaim = "groups"
+	utils.CONFIG.Oidc.AdminValues = []string{"admins"}
+	utils.CONFIG.Oidc.DefaultAccessLevel = "READER"
+	oidcLock.Lock()
+	oidcDiscovery = nil
+	oidcLock.Unlock()
+	t.Cleanup(func() {
+		utils.CONFIG.Oidc.Enabled = false
+		oidcLock.Lock()
+		oidcDiscovery = nil
+		oidcLock.Unlock()
+	})
+	return issuer
+}
+
+// login runs the authorization-code flow with the given claims (nonce, iss, aud and exp are set unless present).
+func (issuer *testOidcIssuer) login(t *testing.T, claims jwt.MapClaims) (*dtos.PunqUser, error) {
+	t.Helper()
+	authUrl, err := OidcAuthCodeUrl()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := url.Parse(authUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := parsed.Query()
+
+	defaults := jwt.MapClaims{
+		"iss":   issuer.server.URL,
+		"aud":   "punq",
+		"exp":   time.Now().Add(time.Minute).Unix(),
+		"nonce": query.Get("nonce"),
+	}
+	for key, value := range defaults {
+		if _, exists := claims[key]; !exists {
+			claims[key] = value
+		}
+	}
+	issuer.claims = claims
+	return OidcLogin(query.Get("state"), "code")
+}
+
+func TestOidcLoginCreatesUser(t *testing.T) {
+	issuer := newTestOidcIssuer(t)
+
+	user, err := issuer.login(t, jwt.MapClaims{"sub": "sub-new", "email": "new@oidc.test", "email_verified": true, "groups": []string{"admins"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { DeleteUser(user.Id) })
+	if user.OidcSubject != "sub-new" || user.OidcIssuer != issuer.server.URL || user.AccessLevel != dtos.ADMIN {
+		t.Errorf("unexpected user %+v", user)
+	}
+
+	// the second login finds the linked user
+	again, err := issuer.login(t, jwt.MapClaims{"sub": "sub-new", "email": "new@oidc.test", "groups": []string{"admins"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Id != user.Id {
+		t.Errorf("second login returned user '%s', want '%s'", again.Id, user.Id)
+	}
+}
+
+func TestOidcLoginLinksOnlyVerifiedEmail(t *testing.T) {
+	issuer := newTestOidcIssuer(t)
+
+	existing, err := AddUser(dtos.PunqUserCreateInput{Email: "existing@oidc.test", Password: "secret", DisplayName: "existing", AccessLevel: dtos.READER})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { DeleteUser(existing.Id) })
+
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+	}{
+		{"email_verified missing", jwt.MapClaims{"sub": "attacker", "email": "existing@oidc.test"}},
+		{"email_verified false", jwt.MapClaims{"sub": "attacker", "email": "existing@oidc.test", "email_verified": false}},
+		{"email_verified not a bool", jwt.MapClaims{"sub": "attacker", "email": "existing@oidc.test", "email_verified": "true"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := issuer.login(t, test.claims); err == nil {
+				t.Fatal("unverified email has been linked to the existing user")
+			}
+		})
+	}
+
+	linked, err := issuer.login(t, jwt.MapClaims{"sub": "owner", "email": "existing@oidc.test", "email_verified": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if linked.Id != existing.Id || linked.OidcSubject != "owner" {
+		t.Errorf("verified email was not linked: %+v", linked)
+	}
+}
+
+func TestOidcLoginRejectsInvalidIdToken(t *testing.T) {
+	issuer := newTestOidcIssuer(t)
+
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+	}{
+		{"nonce mismatch", jwt.MapClaims{"sub": "s", "email": "x@oidc.test", "email_verified": true, "nonce": "other"}},
+		{"wrong audience", jwt.MapClaims{"sub": "s", "email": "x@oidc.test", "email_verified": true, "aud": "other-client"}},
+		{"wrong issuer", jwt.MapClaims{"sub": "s", "email": "x@oidc.test", "email_verified": true, "iss": "https://evil.test"}},
+		{"expired", jwt.MapClaims{"sub": "s", "email": "x@oidc.test", "email_verified": true, "exp": time.Now().Add(-time.Minute).Unix()}},
+		{"no subject", jwt.MapClaims{"email": "x@oidc.test", "email_verified": true}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if user, err := issuer.login(t, test.claims); err == nil {
+				DeleteUser(user.Id)
+				t.Fatal("invalid id_token has been accepted")
+			}
+		})
+	}
+
+	if _, err := OidcLogin("unknown-state", "code"); err == nil {
+		t.Error("unknown state has been accepted")
+	}
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/mogenius/punq/logger"
+	"github.com/mogenius/punq/store"
+	"github.com/mogenius/punq/utils"
+)
+
+const (
+	SecRevokedTokens          = "revokedTokens"
+	RevokedTokensReloadSecond = 30
+)
+
+var ErrTokenRevoked = errors.New("token has been revoked")
+
+// jti -> unix expiry of the revoked token (entries are dropped once the token would be expired anyway)
+var revokedTokens = map[string]int64{}
+var revokedTokensLoaded time.Time
+var revokedTokensLock sync.Mutex
+
+func IsTokenRevoked(jti string) bool {
+	if jti == "" {
+		return false
+	}
+
+	revokedTokensLock.Lock()
+	defer revokedTokensLock.Unlock()
+
+	if time.Since(revokedTokensLoaded) > RevokedTokensReloadSecond*time.Second {
+		loaded, err := loadRevokedTokens()
+		if err == nil {
+			revokedTokens = loaded
+			revokedTokensLoaded = time.Now()
+		}
+	}
+
+	_, revoked := revokedTokens[jti]
+	return revoked
+}
+
+func RevokeToken(claims *PunqClaims) error {
+	return revokeToken(claims, false)
+}
+
+// consumeToken revokes a token which must not have been revoked before. The check and the revocation are a single
+// store.Modify, so a token is consumed once even by concurrent requests (e.g. refresh token rotation).
+func consumeToken(claims *PunqClaims) error {
+	return revokeToken(claims, true)
+}
+
+func revokeToken(claims *PunqClaims, requireUnused bool) error {
+	if claims.ID == "" {
+		return errors.New("token has no id and cannot be revoked")
+	}
+
+	expiresAt := time.Now().Add(time.Hour * time.Duration(TokenExpHours)).Unix()
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Unix()
+	}
+
+	revokedTokensLock.Lock()
+	defer revokedTokensLock.Unlock()
+
+	current := map[string]int64{}
+	err := store.Modify(utils.JWTSECRET, SecRevokedTokens, func(data []byte, exists bool) ([]byte, error) {
+		current = parseRevokedTokens(data)
+		if _, revoked := current[claims.ID]; revoked && requireUnused {
+			return nil, ErrTokenRevoked
+		}
+		now := time.Now().Unix()
+		for jti, exp := range current {
+			if exp < now {
+				delete(current, jti)
+			}
+		}
+		current[claims.ID] = expiresAt
+		return json.Marshal(current)
+	})
+	if errors.Is(err, ErrTokenRevoked) {
+		return err
+	}
+	if err != nil {
+		logger.Log.Errorf("Failed to persist revoked token '%s': %s", claims.ID, err.Error())
+		return err
+	}
+
+	revokedTokens = current
+	revokedTokensLoaded = time.Now()
+	return nil
+}
+
+func loadRevokedTokens() (map[string]int64, error) {
+	data, err := store.Value(utils.JWTSECRET, SecRevokedTokens)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return nil, err
+	}
+	return parseRevokedTokens(data), nil
+}
+
+func parseRevokedTokens(data []byte) map[string]int64 {
+	result := map[string]int64{}
+	if len(data) == 0 {
+		return result
+	}
+	err := json.Unmarshal(data, &result)
+	if err != nil {
+		logger.Log.Errorf("Failed to Unmarshal '%s': %s", SecRevokedTokens, err.Error())
+	}
+	return result
+}
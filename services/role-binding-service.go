@@ -0,0 +1,219 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/mogenius/punq/dtos"
+	"github.com/mogenius/punq/kubernetes"
+	"github.com/mogenius/punq/logger"
+	"github.com/mogenius/punq/store"
+	"github.com/mogenius/punq/utils"
+)
+
+func ListRoleBindings() []dtos.PunqRoleBinding {
+	bindings := []dtos.PunqRoleBinding{}
+
+	values, err := store.Values(utils.ROLEBINDINGSSECRET)
+	if err != nil {
+		logger.Log.Errorf("Failed to list '%s': %s", utils.ROLEBINDINGSSECRET, err.Error())
+		return bindings
+	}
+
+	for bindingId, bindingRaw := range values {
+		binding := dtos.PunqRoleBinding{}
+		err := json.Unmarshal(bindingRaw, &binding)
+		if err != nil {
+			logger.Log.Errorf("Failed to Unmarshal role binding '%s'.", bindingId)
+			continue
+		}
+		bindings = append(bindings, binding)
+	}
+
+	sort.Slice(bindings, func(i, j int) bool {
+		return bindings[i].Created < bindings[j].Created
+	})
+	return bindings
+}
+
+// ListRoleBindingsForUser returns the bindings of the user including the bindings of all groups the user is a member of.
+func ListRoleBindingsForUser(userId string) []dtos.PunqRoleBinding {
+	result := []dtos.PunqRoleBinding{}
+	groupIds := GroupIdsForUser(userId)
+	for _, binding := range ListRoleBindings() {
+		if binding.AppliesTo(userId, groupIds) {
+			result = append(result, binding)
+		}
+	}
+	return result
+}
+
+func ListRoleBindingsForGroup(groupId string) []dtos.PunqRoleBinding {
+	result := []dtos.PunqRoleBinding{}
+	for _, binding := range ListRoleBindings() {
+		if binding.GroupId == groupId {
+			result = append(result, binding)
+		}
+	}
+	return result
+}
+
+func AddRoleBinding(input dtos.PunqRoleBindingCreateInput) (*dtos.PunqRoleBinding, error) {
+	if (input.UserId == "") == (input.GroupId == "") {
+		return nil, errors.New("exactly one of userId or groupId is required")
+	}
+	if input.ContextId == "" {
+		return nil, errors.New("contextId is required")
+	}
+	if input.Role < dtos.READER || input.Role > dtos.ADMIN {
+		return nil, fmt.Errorf("invalid role '%d'", input.Role)
+	}
+	if input.Namespace == "" {
+		input.Namespace = dtos.RoleBindingWildcard
+	}
+	if input.UserId != "" {
+		if _, err := GetUser(input.UserId); err != nil {
+			return nil, err
+		}
+	} else {
+		if _, err := GetGroup(input.GroupId); err != nil {
+			return nil, err
+		}
+	}
+
+	binding := dtos.PunqRoleBinding{
+		Id:        utils.NanoId(),
+		UserId:    input.UserId,
+		GroupId:   input.GroupId,
+		Role:      input.Role,
+		ContextId: input.ContextId,
+		Namespace: input.Namespace,
+		Created:   time.Now().Format(time.RFC3339),
+	}
+
+	rawData, err := json.Marshal(binding)
+	if err != nil {
+		errStr := fmt.Sprintf("failed to Marshal role binding '%s'", binding.Id)
+		logger.Log.Error(errStr)
+		return nil, errors.New(errStr)
+	}
+
+	err = store.Create(utils.ROLEBINDINGSSECRET, binding.Id, rawData)
+	if err != nil {
+		return nil, err
+	}
+	return &binding, nil
+}
+
+func DeleteRoleBinding(id string) error {
+	err := store.Remove(utils.ROLEBINDINGSSECRET, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return fmt.Errorf("role binding '%s' not found", id)
+	}
+	return err
+}
+
+// DeleteRoleBindingsForUser removes all direct bindings of a (deleted) user.
+func DeleteRoleBindingsForUser(userId string) {
+	for _, binding := range ListRoleBindings() {
+		if binding.UserId != userId {
+			continue
+		}
+		err := DeleteRoleBinding(binding.Id)
+		if err != nil {
+			logger.Log.Errorf("Failed to delete role binding '%s': %s", binding.Id, err.Error())
+		}
+	}
+}
+
+// DeleteRoleBindingsForGroup removes all bindings of a (deleted) group.
+func DeleteRoleBindingsForGroup(groupId string) {
+	for _, binding := range ListRoleBindingsForGroup(groupId) {
+		err := DeleteRoleBinding(binding.Id)
+		if err != nil {
+			logger.Log.Errorf("Failed to delete role binding '%s': %s", binding.Id, err.Error())
+		}
+	}
+}
+
+// legacyContextAccess evaluates the pre-binding fields PunqContext.AccessLevel, PunqContext.Users and PunqContext.Groups.
+func legacyContextAccess(user *dtos.PunqUser, groupIds []string, contextId string) bool {
+	ctx := kubernetes.ContextForId(contextId)
+	if ctx == nil {
+		return false
+	}
+	if ctx.AccessLevel >= user.AccessLevel {
+		// USER HAS AN ACCESSLEVEL THAT IS ALLOWED
+		return true
+	}
+	if utils.ContainsEqual(ctx.Users, user.Id) {
+		// USERID IS EXPLICITLY ALLOWED
+		return true
+	}
+	for _, groupId := range groupIds {
+		if utils.ContainsEqual(ctx.Groups, groupId) {
+			// USER IS IN A GROUP THAT IS ALLOWED
+			return true
+		}
+	}
+	return false
+}
+
+func contextBindings(bindings []dtos.PunqRoleBinding, contextId string) []dtos.PunqRoleBinding {
+	result := []dtos.PunqRoleBinding{}
+	for _, binding := range bindings {
+		if binding.MatchesContext(contextId) {
+			result = append(result, binding)
+		}
+	}
+	return result
+}
+
+// HasContextAccess reports whether the user may see the context at all (in any namespace).
+func HasContextAccess(user *dtos.PunqUser, contextId string) bool {
+	if len(contextBindings(ListRoleBindingsForUser(user.Id), contextId)) > 0 {
+		return true
+	}
+	return legacyContextAccess(user, GroupIdsForUser(user.Id), contextId)
+}
+
+// FilterAccessibleContexts returns all contexts the user may see.
+func FilterAccessibleContexts(user *dtos.PunqUser, contexts []dtos.PunqContext) []dtos.PunqContext {
+	result := []dtos.PunqContext{}
+	bindings := ListRoleBindingsForUser(user.Id)
+	groupIds := GroupIdsForUser(user.Id)
+	for _, ctx := range contexts {
+		if len(contextBindings(bindings, ctx.Id)) > 0 || legacyContextAccess(user, groupIds, ctx.Id) {
+			result = append(result, ctx)
+		}
+	}
+	return result
+}
+
+// EffectiveAccessLevel returns the role of the user for a context/namespace. Without a context the global
+// AccessLevel applies. If the user has bindings for the context, the highest matching binding wins;
+// otherwise the legacy context fields decide about access and the global AccessLevel is used.
+func EffectiveAccessLevel(user *dtos.PunqUser, contextId *string, namespace string) dtos.AccessLevel {
+	if contextId == nil || *contextId == "" {
+		return user.AccessLevel
+	}
+
+	bindings := contextBindings(ListRoleBindingsForUser(user.Id), *contextId)
+	if len(bindings) == 0 {
+		if legacyContextAccess(user, GroupIdsForUser(user.Id), *contextId) {
+			return user.AccessLevel
+		}
+		return dtos.UNKNOWNACCESS
+	}
+
+	level := dtos.UNKNOWNACCESS
+	for _, binding := range bindings {
+		if binding.MatchesNamespace(namespace) && binding.Role > level {
+			level = binding.Role
+		}
+	}
+	return level
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mogenius/punq/dtos"
+	"github.com/mogenius/punq/store"
+	"github.com/mogenius/punq/utils"
+)
+
+func storeRoleBinding(t *testing.T, binding dtos.PunqRoleBinding) {
+	t.Helper()
+	rawData, err := json.Marshal(binding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(utils.ROLEBINDINGSSECRET, binding.Id, rawData); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		store.Remove(utils.ROLEBINDINGSSECRET, binding.Id)
+	})
+}
+
+func TestEffectiveAccessLevel(t *testing.T) {
+	group := dtos.PunqGroup{Id: "group-ops", Name: "ops", UserIds: []string{"user-ops"}}
+	rawGroup, _ := json.Marshal(group)
+	if err := store.Set(utils.GROUPSSECRET, group.Id, rawGroup); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		store.Remove(utils.GROUPSSECRET, group.Id)
+	})
+
+	storeRoleBinding(t, dtos.PunqRoleBinding{Id: "b1", UserId: "user-team", Role: dtos.READER, ContextId: "ctx-a", Namespace: "*"})
+	storeRoleBinding(t, dtos.PunqRoleBinding{Id: "b2", UserId: "user-team", Role: dtos.ADMIN, ContextId: "ctx-a", Namespace: "team-*"})
+	storeRoleBinding(t, dtos.PunqRoleBinding{Id: "b3", GroupId: "group-ops", Role: dtos.ADMIN, ContextId: "*", Namespace: "*"})
+	storeRoleBinding(t, dtos.PunqRoleBinding{Id: "b4", UserId: "user-ns", Role: dtos.ADMIN, ContextId: "ctx-a", Namespace: "team-a"})
+
+	ctxA := "ctx-a"
+	ctxB := "ctx-b"
+	tests := []struct {
+		name      string
+		user      dtos.PunqUser
+		contextId *string
+		namespace string
+		want      dtos.AccessLevel
+	}{
+		{"global route uses AccessLevel", dtos.PunqUser{Id: "user-team", AccessLevel: dtos.USER}, nil, "", dtos.USER},
+		{"highest matching binding wins", dtos.PunqUser{Id: "user-team", AccessLevel: dtos.USER}, &ctxA, "team-a", dtos.ADMIN},
+		{"wildcard binding outside the pattern", dtos.PunqUser{Id: "user-team", AccessLevel: dtos.USER}, &ctxA, "kube-system", dtos.READER},
+		{"cluster-wide request only matches wildcard", dtos.PunqUser{Id: "user-team", AccessLevel: dtos.USER}, &ctxA, "", dtos.READER},
+		{"namespace binding does not grant cluster-wide", dtos.PunqUser{Id: "user-ns", AccessLevel: dtos.READER}, &ctxA, "", dtos.UNKNOWNACCESS},
+		{"namespace binding grants its namespace", dtos.PunqUser{Id: "user-ns", AccessLevel: dtos.READER}, &ctxA, "team-a", dtos.ADMIN},
+		{"no binding and no legacy access", dtos.PunqUser{Id: "user-ns", AccessLevel: dtos.READER}, &ctxB, "team-a", dtos.UNKNOWNACCESS},
+		{"group binding for all contexts", dtos.PunqUser{Id: "user-ops", AccessLevel: dtos.READER}, &ctxB, "", dtos.ADMIN},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := EffectiveAccessLevel(&test.user, test.contextId, test.namespace); got != test.want {
+				t.Errorf("EffectiveAccessLevel() = %s, want %s", got.String(), test.want.String())
+			}
+		})
+	}
+}
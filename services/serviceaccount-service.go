@@ -0,0 +1,186 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mogenius/punq/dtos"
+	"github.com/mogenius/punq/kubernetes"
+	"github.com/mogenius/punq/logger"
+	"github.com/mogenius/punq/utils"
+)
+
+const (
+	ServiceAccountUsernamePrefix  = "system:serviceaccount:"
+	ServiceAccountWildcard        = "*"
+	ServiceAccountLoginsPerMinute = 30 // TokenReviews per ip, the endpoint is unauthenticated
+)
+
+var ErrServiceAccountLoginThrottled = errors.New("too many ServiceAccount logins, please retry later")
+
+type tokenReviewWindow struct {
+	Start time.Time
+	Count int
+}
+
+var tokenReviewWindows = map[string]*tokenReviewWindow{}
+var tokenReviewWindowsLock sync.Mutex
+
+func ServiceAccountLoginEnabled() bool {
+	return len(utils.CONFIG.Auth.ServiceAccounts) > 0
+}
+
+// ServiceAccountLogin validates a ServiceAccount token with the TokenReview api of the own context and returns the
+// punq user configured for the ServiceAccount. The ip lockout of password logins applies, TokenReviews are throttled
+// per ip and locked users are rejected. The caller applies the MFA policy of the user like for password logins.
+func ServiceAccountLogin(token string, clientIp string) (*dtos.PunqUser, error) {
+	if !ServiceAccountLoginEnabled() {
+		return nil, errors.New("ServiceAccount login is not enabled")
+	}
+	if isIpLocked(clientIp) {
+		logger.Log.Warningf("ServiceAccount login from locked ip '%s' rejected.", clientIp)
+		return nil, errors.New("invalid ServiceAccount token")
+	}
+	if !allowTokenReview(clientIp) {
+		logger.Log.Warningf("ServiceAccount login from ip '%s' throttled.", clientIp)
+		return nil, ErrServiceAccountLoginThrottled
+	}
+
+	user, err := reviewServiceAccountToken(token)
+	if err != nil {
+		registerIpFailure(clientIp)
+		return nil, err
+	}
+	if user.IsLocked() {
+		registerIpFailure(clientIp)
+		logger.Log.Warningf("ServiceAccount login for locked user '%s' from ip '%s' rejected (locked until %s).", user.Id, clientIp, user.LockedUntil)
+		return nil, errors.New("invalid ServiceAccount token")
+	}
+	return user, nil
+}
+
+func reviewServiceAccountToken(token string) (*dtos.PunqUser, error) {
+	status, err := kubernetes.ReviewToken(token, utils.CONFIG.Auth.ServiceAccountAudiences, nil)
+	if err != nil {
+		logger.Log.Errorf("TokenReview failed: %s", err.Error())
+		return nil, errors.New("failed to review ServiceAccount token")
+	}
+	if !status.Authenticated {
+		if status.Error != "" {
+			logger.Log.Warningf("ServiceAccount token rejected: %s", status.Error)
+		}
+		return nil, errors.New("invalid ServiceAccount token")
+	}
+
+	namespace, name, err := parseServiceAccountUsername(status.User.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	target, ok := serviceAccountTarget(namespace, name)
+	if !ok {
+		logger.Log.Warningf("ServiceAccount '%s/%s' is not allowed to log in.", namespace, name)
+		return nil, fmt.Errorf("ServiceAccount '%s/%s' is not allowed to log in", namespace, name)
+	}
+	return serviceAccountUser(namespace, name, target)
+}
+
+// allowTokenReview counts the TokenReviews of an ip per minute.
+func allowTokenReview(clientIp string) bool {
+	tokenReviewWindowsLock.Lock()
+	defer tokenReviewWindowsLock.Unlock()
+
+	for ip, window := range tokenReviewWindows {
+		if time.Since(window.Start) > time.Minute {
+			delete(tokenReviewWindows, ip)
+		}
+	}
+
+	window, exists := tokenReviewWindows[clientIp]
+	if !exists {
+		window = &tokenReviewWindow{Start: time.Now()}
+		tokenReviewWindows[clientIp] = window
+	}
+	window.Count++
+	return window.Count <= ServiceAccountLoginsPerMinute
+}
+
+// parseServiceAccountUsername splits "system:serviceaccount:<namespace>:<name>".
+func parseServiceAccountUsername(username string) (string, string, error) {
+	if !strings.HasPrefix(username, ServiceAccountUsernamePrefix) {
+		return "", "", fmt.Errorf("'%s' is not a ServiceAccount", username)
+	}
+	parts := strings.Split(strings.TrimPrefix(username, ServiceAccountUsernamePrefix), ":")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("malformed ServiceAccount username '%s'", username)
+	}
+	return parts[0], parts[1], nil
+}
+
+// serviceAccountTarget returns the configured target of a ServiceAccount. Exact entries win over namespace wildcards.
+func serviceAccountTarget(namespace string, name string) (string, bool) {
+	wildcardTarget := ""
+	for _, entry := range utils.CONFIG.Auth.ServiceAccounts {
+		serviceAccount, target, found := strings.Cut(strings.TrimSpace(entry), "=")
+		entryNamespace, entryName, validName := strings.Cut(strings.TrimSpace(serviceAccount), "/")
+		target = strings.TrimSpace(target)
+		if !found || !validName || target == "" {
+			logger.Log.Errorf("Invalid ServiceAccount mapping '%s' (expected 'namespace/name=target').", entry)
+			continue
+		}
+		if entryNamespace != namespace {
+			continue
+		}
+		if entryName == name {
+			return target, true
+		}
+		if entryName == ServiceAccountWildcard && wildcardTarget == "" {
+			wildcardTarget = target
+		}
+	}
+	return wildcardTarget, wildcardTarget != ""
+}
+
+// serviceAccountUser resolves the target of a mapping. Existing users are referenced by id or email. For an
+// AccessLevel a dedicated user is created for the ServiceAccount (and kept in sync with the configuration).
+func serviceAccountUser(namespace string, name string, target string) (*dtos.PunqUser, error) {
+	if !utils.ContainsEqual([]string{"READER", "USER", "ADMIN"}, strings.ToUpper(target)) {
+		if user, err := GetUser(target); err == nil {
+			return user, nil
+		}
+		user, err := GetUserByEmail(target)
+		if err != nil {
+			return nil, fmt.Errorf("user '%s' mapped to ServiceAccount '%s/%s' not found", target, namespace, name)
+		}
+		return user, nil
+	}
+
+	accessLevel := dtos.AccessLevelFromString(target)
+	serviceAccount := fmt.Sprintf("%s/%s", namespace, name)
+	for _, user := range ListUsers() {
+		if user.ServiceAccount == serviceAccount {
+			if user.AccessLevel != accessLevel {
+				user.AccessLevel = accessLevel
+				return UpdateUser(user)
+			}
+			return &user, nil
+		}
+	}
+
+	// password login stays unusable
+	newUser, err := AddUser(dtos.PunqUserCreateInput{
+		Email:          fmt.Sprintf("%s%s:%s", ServiceAccountUsernamePrefix, namespace, name),
+		Password:       utils.NanoIdExtraLong(),
+		DisplayName:    serviceAccount,
+		AccessLevel:    accessLevel,
+		ServiceAccount: serviceAccount,
+	})
+	if err != nil {
+		return nil, err
+	}
+	logger.Log.Noticef("Created user '%s' for ServiceAccount '%s'.", newUser.Id, serviceAccount)
+	return newUser, nil
+}
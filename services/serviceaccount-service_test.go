@@ -0,0 +1,63 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mogenius/punq/utils"
+)
+
+func TestAllowTokenReviewThrottlesPerIp(t *testing.T) {
+	for i := 0; i < ServiceAccountLoginsPerMinute; i++ {
+		if !allowTokenReview("192.0.2.1") {
+			t.Fatalf("review %d has been throttled", i+1)
+		}
+	}
+	if allowTokenReview("192.0.2.1") {
+		t.Error("review above the limit has been allowed")
+	}
+	if !allowTokenReview("192.0.2.2") {
+		t.Error("another ip has been throttled")
+	}
+
+	// the window of the ip expires after a minute
+	tokenReviewWindowsLock.Lock()
+	tokenReviewWindows["192.0.2.1"].Start = time.Now().Add(-2 * time.Minute)
+	tokenReviewWindowsLock.Unlock()
+	if !allowTokenReview("192.0.2.1") {
+		t.Error("ip is still throttled after the window expired")
+	}
+}
+
+func TestServiceAccountLoginRejectsBeforeTokenReview(t *testing.T) {
+	utils.CONFIG.Auth.ServiceAccounts = []string{"ci/*=READER"}
+	t.Cleanup(func() { utils.CONFIG.Auth.ServiceAccounts = nil })
+
+	ipLoginAttemptsLock.Lock()
+	ipLoginAttempts["192.0.2.10"] = &loginAttempts{Failures: LoginMaxIpFailures, LockedUntil: time.Now().Add(time.Minute), LastFailure: time.Now()}
+	ipLoginAttemptsLock.Unlock()
+	tokenReviewWindowsLock.Lock()
+	tokenReviewWindows["192.0.2.11"] = &tokenReviewWindow{Start: time.Now(), Count: ServiceAccountLoginsPerMinute}
+	tokenReviewWindowsLock.Unlock()
+
+	tests := []struct {
+		name     string
+		clientIp string
+		wantErr  string
+	}{
+		// without a cluster a TokenReview would fail with "failed to review ServiceAccount token"
+		{"locked ip", "192.0.2.10", "invalid ServiceAccount token"},
+		{"throttled ip", "192.0.2.11", ErrServiceAccountLoginThrottled.Error()},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ServiceAccountLogin("token", test.clientIp)
+			if err == nil {
+				t.Fatal("login has been accepted")
+			}
+			if err.Error() != test.wantErr {
+				t.Errorf("got error '%s', want '%s'", err.Error(), test.wantErr)
+			}
+		})
+	}
+}
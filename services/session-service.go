@@ -0,0 +1,303 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mogenius/punq/dtos"
+	"github.com/mogenius/punq/logger"
+	"github.com/mogenius/punq/store"
+	"github.com/mogenius/punq/utils"
+)
+
+const (
+	SessionsReloadSeconds      = 30
+	SessionActivityMinutes     = 1  // LastActivity is persisted at most once per interval
+	SessionWatchSeconds        = 10 // how often open connections check whether their session still exists
+	sessionsUnknownIdReloadGap = 5 * time.Second
+)
+
+// session id -> session (reloaded periodically so sign-outs of other processes are picked up)
+var sessions = map[string]dtos.PunqSession{}
+var sessionsLoaded time.Time
+var sessionsLock sync.Mutex
+
+type sessionConnection struct {
+	sessionId string
+	close     func()
+}
+
+// long-lived connections (e.g. exec websockets) which are closed on forced sign-out
+var sessionConnections = map[*sessionConnection]bool{}
+var sessionConnectionsLock sync.Mutex
+
+// newSession creates an unsaved session. ExpiresAt is set when its first tokens are issued.
+func newSession(userId string, clientIp string, userAgent string) *dtos.PunqSession {
+	return &dtos.PunqSession{
+		Id:        utils.NanoId(),
+		UserId:    userId,
+		ClientIp:  clientIp,
+		UserAgent: userAgent,
+		Created:   time.Now().Format(time.RFC3339),
+	}
+}
+
+func parseSessions(data map[string][]byte) map[string]dtos.PunqSession {
+	result := map[string]dtos.PunqSession{}
+	for id, sessionRaw := range data {
+		session := dtos.PunqSession{}
+		err := json.Unmarshal(sessionRaw, &session)
+		if err != nil {
+			logger.Log.Errorf("Failed to Unmarshal session '%s'.", id)
+			continue
+		}
+		result[id] = session
+	}
+	return result
+}
+
+func loadSessions() (map[string]dtos.PunqSession, error) {
+	values, err := store.Values(utils.SESSIONSSECRET)
+	if err != nil {
+		return nil, err
+	}
+	return parseSessions(values), nil
+}
+
+func cachedSession(id string) (dtos.PunqSession, bool) {
+	sessionsLock.Lock()
+	defer sessionsLock.Unlock()
+
+	_, known := sessions[id]
+	// sessions started by other replicas are unknown until the next reload
+	if time.Since(sessionsLoaded) > SessionsReloadSeconds*time.Second || (!known && time.Since(sessionsLoaded) > sessionsUnknownIdReloadGap) {
+		loaded, err := loadSessions()
+		if err == nil {
+			sessions = loaded
+			sessionsLoaded = time.Now()
+		}
+	}
+
+	session, exists := sessions[id]
+	return session, exists
+}
+
+// IsSessionActive reports whether a session has neither been signed out nor expired. Tokens from before session
+// tracking carry no session id and stay valid until they expire.
+func IsSessionActive(id string) bool {
+	if id == "" {
+		return true
+	}
+	session, exists := cachedSession(id)
+	return exists && !session.IsExpired()
+}
+
+// ListSessions returns the active sessions of a user (all sessions if userId is empty).
+func ListSessions(userId string) []dtos.PunqSession {
+	result := []dtos.PunqSession{}
+
+	loaded, err := loadSessions()
+	if err != nil {
+		logger.Log.Errorf("Failed to list '%s': %s", utils.SESSIONSSECRET, err.Error())
+		return result
+	}
+
+	for _, session := range loaded {
+		if session.IsExpired() {
+			continue
+		}
+		if userId != "" && session.UserId != userId {
+			continue
+		}
+		result = append(result, session)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Created < result[j].Created
+	})
+	return result
+}
+
+// DeleteSession signs a session out: all its tokens are rejected and its open connections are closed. If userId
+// is not empty, the session must belong to that user.
+func DeleteSession(id string, userId string) error {
+	if userId != "" {
+		sessionRaw, err := store.Value(utils.SESSIONSSECRET, id)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			return err
+		}
+		session := dtos.PunqSession{}
+		if err != nil || json.Unmarshal(sessionRaw, &session) != nil || session.UserId != userId {
+			return fmt.Errorf("session '%s' not found", id)
+		}
+	}
+
+	err := store.Remove(utils.SESSIONSSECRET, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return fmt.Errorf("session '%s' not found", id)
+	}
+	if err != nil {
+		return err
+	}
+
+	sessionsLock.Lock()
+	delete(sessions, id)
+	sessionsLock.Unlock()
+
+	closeSessionConnections(id)
+	logger.Log.Noticef("Session '%s' has been signed out.", id)
+	return nil
+}
+
+// DeleteSessionsForUser is called when a user is deleted.
+func DeleteSessionsForUser(userId string) {
+	for _, session := range ListSessions(userId) {
+		err := DeleteSession(session.Id, userId)
+		if err != nil {
+			logger.Log.Errorf("Failed to delete session '%s': %s", session.Id, err.Error())
+		}
+	}
+}
+
+// TouchSession records the last activity of a session.
+func TouchSession(id string) {
+	if id == "" {
+		return
+	}
+	session, exists := cachedSession(id)
+	if !exists {
+		return
+	}
+	if session.LastActivity != "" {
+		lastActivity, err := time.Parse(time.RFC3339, session.LastActivity)
+		if err == nil && time.Since(lastActivity) < SessionActivityMinutes*time.Minute {
+			return
+		}
+	}
+	session.LastActivity = time.Now().Format(time.RFC3339)
+	err := writeSession(session, false)
+	if err != nil {
+		logger.Log.Errorf("Failed to update last activity of session '%s': %s", id, err.Error())
+	}
+}
+
+// writeSession persists a session. Existing sessions are only updated if they have not been signed out in the
+// meantime; new sessions also drop the expired ones.
+func writeSession(session dtos.PunqSession, isNew bool) error {
+	rawData, err := json.Marshal(session)
+	if err != nil {
+		errStr := fmt.Sprintf("failed to Marshal session '%s'", session.Id)
+		logger.Log.Error(errStr)
+		return errors.New(errStr)
+	}
+
+	if isNew {
+		err = store.Create(utils.SESSIONSSECRET, session.Id, rawData)
+	} else {
+		err = store.Modify(utils.SESSIONSSECRET, session.Id, func(current []byte, exists bool) ([]byte, error) {
+			if !exists {
+				return nil, fmt.Errorf("session '%s' has been signed out", session.Id)
+			}
+			return rawData, nil
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	sessionsLock.Lock()
+	sessions[session.Id] = session
+	sessionsLock.Unlock()
+
+	if isNew {
+		pruneExpiredSessions()
+	}
+	return nil
+}
+
+func pruneExpiredSessions() {
+	loaded, err := loadSessions()
+	if err != nil {
+		logger.Log.Errorf("Failed to list '%s': %s", utils.SESSIONSSECRET, err.Error())
+		return
+	}
+	for id, session := range loaded {
+		if !session.IsExpired() {
+			continue
+		}
+		err := store.Remove(utils.SESSIONSSECRET, id)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			logger.Log.Errorf("Failed to delete expired session '%s': %s", id, err.Error())
+			continue
+		}
+		sessionsLock.Lock()
+		delete(sessions, id)
+		sessionsLock.Unlock()
+	}
+}
+
+// TrackSessionConnection closes a long-lived connection as soon as its session is signed out (locally right away,
+// by another process within SessionsReloadSeconds + SessionWatchSeconds). The returned func must be called when
+// the connection ends.
+func TrackSessionConnection(sessionId string, closeConnection func()) func() {
+	if sessionId == "" {
+		return func() {}
+	}
+
+	var closeOnce sync.Once
+	connection := &sessionConnection{
+		sessionId: sessionId,
+		close:     func() { closeOnce.Do(closeConnection) },
+	}
+	sessionConnectionsLock.Lock()
+	sessionConnections[connection] = true
+	sessionConnectionsLock.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(SessionWatchSeconds * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if !IsSessionActive(sessionId) {
+					logger.Log.Noticef("Closing connection of signed out session '%s'.", sessionId)
+					connection.close()
+					return
+				}
+			}
+		}
+	}()
+
+	var releaseOnce sync.Once
+	return func() {
+		releaseOnce.Do(func() {
+			close(done)
+			sessionConnectionsLock.Lock()
+			delete(sessionConnections, connection)
+			sessionConnectionsLock.Unlock()
+		})
+	}
+}
+
+func closeSessionConnections(sessionId string) {
+	sessionConnectionsLock.Lock()
+	toClose := []*sessionConnection{}
+	for connection := range sessionConnections {
+		if connection.sessionId == sessionId {
+			toClose = append(toClose, connection)
+		}
+	}
+	sessionConnectionsLock.Unlock()
+
+	for _, connection := range toClose {
+		logger.Log.Noticef("Closing connection of signed out session '%s'.", sessionId)
+		connection.close()
+	}
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/mogenius/punq/logger"
+	"github.com/mogenius/punq/store"
+)
+
+// InitStore prepares all collections in the configured store.
+func InitStore() {
+	for _, collection := range store.Collections {
+		err := store.Current().Init(collection)
+		if err != nil {
+			logger.Log.Errorf("Failed to init '%s' in %s store: %s", collection, store.Current().Type(), err.Error())
+		}
+	}
+}
+
+// MigrateStore copies the complete state from one store type into another. The source is left untouched.
+func MigrateStore(fromType string, toType string) (int, error) {
+	if fromType == toType {
+		return 0, fmt.Errorf("source and target store are both '%s'", fromType)
+	}
+	from, err := store.New(fromType)
+	if err != nil {
+		return 0, err
+	}
+	to, err := store.New(toType)
+	if err != nil {
+		return 0, err
+	}
+	return store.Migrate(from, to)
+}
+
+// RemoveState deletes all collections of the configured store (used by clean).
+func RemoveState() {
+	for _, collection := range store.Collections {
+		fmt.Printf("Deleting %s from %s store ...\n", collection, store.Current().Type())
+		err := store.Current().Drop(collection)
+		if err != nil {
+			logger.Log.Error(err)
+			continue
+		}
+		fmt.Printf("Deleted %s from %s store. ✅\n", collection, store.Current().Type())
+	}
+}
@@ -1,7 +1,6 @@
 package services
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,53 +10,18 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"golang.org/x/crypto/bcrypt"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/mogenius/punq/dtos"
-	"github.com/mogenius/punq/kubernetes"
 	"github.com/mogenius/punq/logger"
+	"github.com/mogenius/punq/store"
 	"github.com/mogenius/punq/utils"
 )
 
 const PunqAdminIdKey = "admin_id"
 
-func InitUserService() {
-	CreateUserSecret()
-}
-
-func CreateUserSecret() {
-	provider, err := kubernetes.NewKubeProvider(nil)
-	if provider == nil || err != nil {
-		logger.Log.Fatal(err.Error())
-	}
-
-	secretClient := provider.ClientSet.CoreV1().Secrets(utils.CONFIG.Kubernetes.OwnNamespace)
-	existingSecret, getErr := secretClient.Get(context.TODO(), utils.USERSSECRET, metav1.GetOptions{})
-
-	secret := utils.InitSecret()
-	secret.ObjectMeta.Name = utils.USERSSECRET
-	secret.ObjectMeta.Namespace = utils.CONFIG.Kubernetes.OwnNamespace
-	delete(secret.StringData, "exampleData") // delete example data
-
-	// if not exist
-	if existingSecret == nil || getErr != nil {
-		fmt.Println("Creating new punq-auth secret ...")
-		_, err := secretClient.Create(context.TODO(), &secret, kubernetes.MoCreateOptions())
-		if err != nil {
-			logger.Log.Error(err)
-			return
-		}
-		fmt.Println("Created new punq-auth secret. ✅")
-	}
-}
-
 func CreateAdminUser() {
-	secret := kubernetes.SecretFor(utils.CONFIG.Kubernetes.OwnNamespace, utils.USERSSECRET, nil)
-	if secret == nil {
-		return
-	}
-
-	if secret.Data[PunqAdminIdKey] != nil {
+	if _, err := store.Value(utils.USERSSECRET, PunqAdminIdKey); !errors.Is(err, store.ErrNotFound) {
+		// admin exists (or the store is not available)
 		return
 	}
 
@@ -76,28 +40,27 @@ func CreateAdminUser() {
 	utils.PrintInfo("Please store following admin user credentials in a safe place:")
 	displayAdminUser.PrintToTerminalWithPwd()
 
-	secret = kubernetes.SecretFor(utils.CONFIG.Kubernetes.OwnNamespace, utils.USERSSECRET, nil)
-	strData := make(map[string]string)
-	strData[PunqAdminIdKey] = adminUser.Id
-	secret.StringData = strData
-	kubernetes.UpdateK8sSecret(*secret, nil)
+	err := store.Set(utils.USERSSECRET, PunqAdminIdKey, []byte(adminUser.Id))
+	if err != nil {
+		logger.Log.Error(err)
+	}
 }
 
 func ListUsers() []dtos.PunqUser {
 	users := []dtos.PunqUser{}
 
-	secret := kubernetes.SecretFor(utils.CONFIG.Kubernetes.OwnNamespace, utils.USERSSECRET, nil)
-	if secret == nil {
-		logger.Log.Errorf("Failed to get '%s/%s' secret.", utils.CONFIG.Kubernetes.OwnNamespace, utils.USERSSECRET)
+	values, err := store.Values(utils.USERSSECRET)
+	if err != nil {
+		logger.Log.Errorf("Failed to list '%s': %s", utils.USERSSECRET, err.Error())
 		return users
 	}
 
-	for userId, userRaw := range secret.Data {
+	for userId, userRaw := range values {
 		if userId == PunqAdminIdKey {
 			continue
 		}
 		user := dtos.PunqUser{}
-		err := json.Unmarshal(userRaw, &user)
+		err := dtos.UnmarshalStoredUser(userRaw, &user)
 		if err != nil {
 			logger.Log.Error("Failed to Unmarshal user '%s'.", userId)
 		}
@@ -108,13 +71,13 @@ func ListUsers() []dtos.PunqUser {
 }
 
 func AddUser(userCreateInput dtos.PunqUserCreateInput) (*dtos.PunqUser, error) {
-	secret := kubernetes.SecretFor(utils.CONFIG.Kubernetes.OwnNamespace, utils.USERSSECRET, nil)
-	if secret == nil {
-		return nil, errors.New(fmt.Sprintf("failed to get '%s/%s' secret", utils.CONFIG.Kubernetes.OwnNamespace, utils.USERSSECRET))
+	values, err := store.Values(utils.USERSSECRET)
+	if err != nil {
+		return nil, err
 	}
 
 	// check for duplicates
-	for _, data := range secret.Data {
+	for _, data := range values {
 		userDto := &dtos.PunqUser{}
 		err := json.Unmarshal(data, userDto)
 		if err == nil {
@@ -152,31 +115,24 @@ func AddUser(userCreateInput dtos.PunqUserCreateInput) (*dtos.PunqUser, error) {
 		return nil, errors.New(errStr)
 	}
 
-	rawData, err := json.Marshal(user)
+	rawData, err := dtos.MarshalStoredUser(user)
 	if err != nil {
 		errStr := fmt.Sprintf("failed to Marshal user '%s'", user.Id)
 		logger.Log.Error(errStr)
 		return nil, errors.New(errStr)
 	}
 
-	if secret.StringData == nil {
-		secret.StringData = make(map[string]string)
+	err = store.Create(utils.USERSSECRET, user.Id, rawData)
+	if err != nil {
+		return nil, err
 	}
-	secret.StringData[user.Id] = string(rawData)
-
-	// add user to secret
-	kubernetes.UpdateK8sSecret(*secret, nil)
 
 	return &user, nil
 }
 
+// UpdateUser fails if the user has been modified since it was read (e.g. by another admin).
 func UpdateUser(userUpdateInput dtos.PunqUser) (*dtos.PunqUser, error) {
-	secret := kubernetes.SecretFor(utils.CONFIG.Kubernetes.OwnNamespace, utils.USERSSECRET, nil)
-	if secret == nil {
-		return nil, errors.New(fmt.Sprintf("failed to get '%s/%s' secret", utils.CONFIG.Kubernetes.OwnNamespace, utils.USERSSECRET))
-	}
-
-	user, err := GetUser(userUpdateInput.Id)
+	user, entry, err := getUserEntry(userUpdateInput.Id)
 	if err != nil {
 		return nil, err
 	}
@@ -191,6 +147,14 @@ func UpdateUser(userUpdateInput dtos.PunqUser) (*dtos.PunqUser, error) {
 		}
 	}
 
+	// end existing sessions if password or access level changes
+	tokensValidAfter := user.TokensValidAfter
+	tokenGeneration := user.TokenGeneration
+	if user.AccessLevel != userUpdateInput.AccessLevel || (userUpdateInput.Password != "" && user.Password != userUpdateInput.Password) {
+		tokensValidAfter = time.Now().Unix()
+		tokenGeneration++
+	}
+
 	// hash new password
 	if userUpdateInput.Password != "" && user.Password != userUpdateInput.Password {
 		// hash password
@@ -215,75 +179,84 @@ func UpdateUser(userUpdateInput dtos.PunqUser) (*dtos.PunqUser, error) {
 		return nil, errors.New(errStr)
 	}
 
-	rawData, err := json.Marshal(user)
-	secret.Data[userUpdateInput.Id] = rawData
+	user.TokensValidAfter = tokensValidAfter
+	user.TokenGeneration = tokenGeneration
+
+	entry.Value, err = dtos.MarshalStoredUser(*user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to Marshal user '%s'", user.Id)
+	}
 
 	// update user
-	kubernetes.UpdateK8sSecret(*secret, nil)
+	err = store.Current().Put(utils.USERSSECRET, *entry)
+	if errors.Is(err, store.ErrConflict) {
+		return nil, fmt.Errorf("user '%s' has been modified concurrently, please retry", user.Id)
+	}
+	if err != nil {
+		return nil, err
+	}
 
 	return user, nil
 }
 
 func DeleteUser(id string) error {
-	secret := kubernetes.SecretFor(utils.CONFIG.Kubernetes.OwnNamespace, utils.USERSSECRET, nil)
-	if secret == nil {
-		return errors.New(fmt.Sprintf("failed to get '%s/%s' secret", utils.CONFIG.Kubernetes.OwnNamespace, utils.USERSSECRET))
-	}
-
 	if id == utils.USERADMIN {
 		return errors.New("admin user cannot be deleted")
 	}
 
-	if secret.Data[id] != nil {
-		delete(secret.Data, id)
-	} else {
+	err := store.Remove(utils.USERSSECRET, id)
+	if errors.Is(err, store.ErrNotFound) {
 		return errors.New(fmt.Sprintf("USer '%s' not found.", id))
 	}
-
-	result := kubernetes.UpdateK8sSecret(*secret, nil)
-	if result.Error == nil && result.Result == nil {
-		// success
-		result.Result = fmt.Sprintf("User %s successfully deleted.", id)
+	if err != nil {
+		return err
 	}
+
+	DeleteRoleBindingsForUser(id)
+	RemoveUserFromAllGroups(id)
+	RevokeApiTokensForUser(id)
+	DeleteSessionsForUser(id)
 	return nil
 }
 
 func GetUser(id string) (*dtos.PunqUser, error) {
-	secret := kubernetes.SecretFor(utils.CONFIG.Kubernetes.OwnNamespace, utils.USERSSECRET, nil)
-	if secret == nil {
-		msg := fmt.Sprintf("Failed to get '%s/%s' secret.", utils.CONFIG.Kubernetes.OwnNamespace, utils.USERSSECRET)
-		logger.Log.Error(msg)
-		return nil, errors.New(msg)
-	}
+	user, _, err := getUserEntry(id)
+	return user, err
+}
 
-	if secret.Data[id] != nil {
-		user := dtos.PunqUser{}
-		err := json.Unmarshal(secret.Data[id], &user)
-		if err != nil {
-			msg := fmt.Sprintf("Failed to Unmarshal user '%s'.", id)
-			logger.Log.Error(msg)
-			return nil, errors.New(msg)
+func getUserEntry(id string) (*dtos.PunqUser, *store.Entry, error) {
+	entry, err := store.Current().Get(utils.USERSSECRET, id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, nil, errors.New("user not found")
 		}
-		return &user, nil
+		logger.Log.Error(err)
+		return nil, nil, err
 	}
 
-	return nil, errors.New("user not found")
+	user := dtos.PunqUser{}
+	err = dtos.UnmarshalStoredUser(entry.Value, &user)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to Unmarshal user '%s'.", id)
+		logger.Log.Error(msg)
+		return nil, nil, errors.New(msg)
+	}
+	return &user, entry, nil
 }
 
 func GetUserByEmail(email string) (*dtos.PunqUser, error) {
-	secret := kubernetes.SecretFor(utils.CONFIG.Kubernetes.OwnNamespace, utils.USERSSECRET, nil)
-	if secret == nil {
-		msg := fmt.Sprintf("Failed to get '%s/%s' secret.", utils.CONFIG.Kubernetes.OwnNamespace, utils.USERSSECRET)
-		logger.Log.Error(msg)
-		return nil, errors.New(msg)
+	values, err := store.Values(utils.USERSSECRET)
+	if err != nil {
+		logger.Log.Error(err)
+		return nil, err
 	}
 
-	for userId, userRaw := range secret.Data {
+	for userId, userRaw := range values {
 		if userId == PunqAdminIdKey {
 			continue
 		}
 		user := dtos.PunqUser{}
-		err := json.Unmarshal(userRaw, &user)
+		err := dtos.UnmarshalStoredUser(userRaw, &user)
 		if err != nil {
 			msg := fmt.Sprintf("Failed to Unmarshal user '%s'.", userId)
 			logger.Log.Error(msg)
@@ -298,16 +271,13 @@ func GetUserByEmail(email string) (*dtos.PunqUser, error) {
 }
 
 func GetAdmin() (*dtos.PunqUser, error) {
-	secret := kubernetes.SecretFor(utils.CONFIG.Kubernetes.OwnNamespace, utils.USERSSECRET, nil)
-	if secret == nil {
-		err := fmt.Errorf("failed to get '%s/%s' secret", utils.CONFIG.Kubernetes.OwnNamespace, utils.USERSSECRET)
+	adminId, err := store.Value(utils.USERSSECRET, PunqAdminIdKey)
+	if err != nil {
 		logger.Log.Error(err)
 		return nil, err
 	}
 
-	adminId := string(secret.Data[PunqAdminIdKey])
-
-	adminUser, err := GetUser(adminId)
+	adminUser, err := GetUser(string(adminId))
 	if err != nil {
 		return nil, err
 	}
@@ -326,3 +296,35 @@ func GetGinContextUser(c *gin.Context) *dtos.PunqUser {
 	}
 	return nil
 }
+
+// modifyUser applies a change to the current state of a user (retried on concurrent modifications). Fields not
+// touched by modify keep their stored value (no password hashing, no duplicate checks).
+func modifyUser(id string, modify func(user *dtos.PunqUser) error) (*dtos.PunqUser, error) {
+	result := dtos.PunqUser{}
+	err := store.Modify(utils.USERSSECRET, id, func(current []byte, exists bool) ([]byte, error) {
+		if !exists {
+			return nil, errors.New("user not found")
+		}
+		user := dtos.PunqUser{}
+		err := dtos.UnmarshalStoredUser(current, &user)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to Unmarshal user '%s'.", id)
+		}
+
+		err = modify(&user)
+		if err != nil {
+			return nil, err
+		}
+		result = user
+
+		rawData, err := dtos.MarshalStoredUser(user)
+		if err != nil {
+			return nil, fmt.Errorf("failed to Marshal user '%s'", user.Id)
+		}
+		return rawData, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
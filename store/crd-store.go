@@ -0,0 +1,283 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensions "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/mogenius/punq/kubernetes"
+	"github.com/mogenius/punq/logger"
+	"github.com/mogenius/punq/utils"
+)
+
+const (
+	CrdGroup           = "punq.dev"
+	CrdVersion         = "v1"
+	CrdKind            = "PunqState"
+	CrdPlural          = "punqstates"
+	CrdCollectionLabel = "punq.dev/collection"
+	crdEstablishWait   = 30 * time.Second
+)
+
+var crdResource = schema.GroupVersionResource{Group: CrdGroup, Version: CrdVersion, Resource: CrdPlural}
+
+// CrdStore keeps every entry in its own PunqState object of the own namespace. Updates are guarded by the
+// resourceVersion of the object (optimistic concurrency per entry) and no collection hits the Secret size limit.
+type CrdStore struct {
+	crdLock  sync.Mutex
+	crdReady bool
+}
+
+func NewCrdStore() *CrdStore {
+	return &CrdStore{}
+}
+
+func (s *CrdStore) Type() string {
+	return TypeCrd
+}
+
+func (s *CrdStore) client() (dynamic.ResourceInterface, error) {
+	provider, err := kubernetes.NewKubeProvider(nil)
+	if err != nil {
+		return nil, err
+	}
+	client, err := dynamic.NewForConfig(&provider.ClientConfig)
+	if err != nil {
+		return nil, err
+	}
+	return client.Resource(crdResource).Namespace(utils.CONFIG.Kubernetes.OwnNamespace), nil
+}
+
+// objectName derives a valid object name from any key.
+func crdObjectName(collection string, key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return fmt.Sprintf("%s.%s", collection, hex.EncodeToString(hash[:])[:20])
+}
+
+func crdEntry(object *unstructured.Unstructured) (*Entry, error) {
+	key, _, _ := unstructured.NestedString(object.Object, "spec", "key")
+	encoded, _, _ := unstructured.NestedString(object.Object, "spec", "value")
+	value, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode '%s': %s", object.GetName(), err.Error())
+	}
+	return &Entry{Key: key, Value: value, Version: object.GetResourceVersion()}, nil
+}
+
+func crdObject(collection string, entry Entry) *unstructured.Unstructured {
+	object := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": fmt.Sprintf("%s/%s", CrdGroup, CrdVersion),
+		"kind":       CrdKind,
+		"metadata": map[string]interface{}{
+			"name":      crdObjectName(collection, entry.Key),
+			"namespace": utils.CONFIG.Kubernetes.OwnNamespace,
+			"labels": map[string]interface{}{
+				CrdCollectionLabel: collection,
+			},
+		},
+		"spec": map[string]interface{}{
+			"collection": collection,
+			"key":        entry.Key,
+			"value":      base64.StdEncoding.EncodeToString(entry.Value),
+		},
+	}}
+	if entry.Version != "" {
+		object.SetResourceVersion(entry.Version)
+	}
+	return object
+}
+
+// Init installs the PunqState CRD (once per process). This requires permissions to create CRDs.
+func (s *CrdStore) Init(collection string) error {
+	s.crdLock.Lock()
+	defer s.crdLock.Unlock()
+	if s.crdReady {
+		return nil
+	}
+
+	provider, err := kubernetes.NewKubeProvider(nil)
+	if err != nil {
+		return err
+	}
+	client, err := apiextensions.NewForConfig(&provider.ClientConfig)
+	if err != nil {
+		return err
+	}
+	crdClient := client.ApiextensionsV1().CustomResourceDefinitions()
+
+	name := fmt.Sprintf("%s.%s", CrdPlural, CrdGroup)
+	_, err = crdClient.Get(context.TODO(), name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		fmt.Printf("Creating %s crd ...\n", name)
+		_, err = crdClient.Create(context.TODO(), punqStateCrd(name), kubernetes.MoCreateOptions())
+		if err != nil && !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+		err = waitForCrd(crdClient.Get, name)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Created %s crd. ✅\n", name)
+	} else if err != nil {
+		return err
+	}
+
+	s.crdReady = true
+	return nil
+}
+
+func waitForCrd(get func(ctx context.Context, name string, opts metav1.GetOptions) (*apiextensionsv1.CustomResourceDefinition, error), name string) error {
+	deadline := time.Now().Add(crdEstablishWait)
+	for time.Now().Before(deadline) {
+		crd, err := get(context.TODO(), name, metav1.GetOptions{})
+		if err == nil {
+			for _, condition := range crd.Status.Conditions {
+				if condition.Type == apiextensionsv1.Established && condition.Status == apiextensionsv1.ConditionTrue {
+					return nil
+				}
+			}
+		}
+		time.Sleep(time.Second)
+	}
+	return fmt.Errorf("crd '%s' has not been established within %s", name, crdEstablishWait)
+}
+
+func punqStateCrd(name string) *apiextensionsv1.CustomResourceDefinition {
+	return &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: CrdGroup,
+			Scope: apiextensionsv1.NamespaceScoped,
+			Names: apiextensionsv1.CustomResourceDefinitionNames{
+				Plural:   CrdPlural,
+				Singular: "punqstate",
+				Kind:     CrdKind,
+				ListKind: CrdKind + "List",
+			},
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{{
+				Name:    CrdVersion,
+				Served:  true,
+				Storage: true,
+				Schema: &apiextensionsv1.CustomResourceValidation{
+					OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
+						Type: "object",
+						Properties: map[string]apiextensionsv1.JSONSchemaProps{
+							"spec": {
+								Type:     "object",
+								Required: []string{"collection", "key", "value"},
+								Properties: map[string]apiextensionsv1.JSONSchemaProps{
+									"collection": {Type: "string"},
+									"key":        {Type: "string"},
+									"value":      {Type: "string", Format: "byte"},
+								},
+							},
+						},
+					},
+				},
+			}},
+		},
+	}
+}
+
+func (s *CrdStore) List(collection string) ([]Entry, error) {
+	client, err := s.client()
+	if err != nil {
+		return nil, err
+	}
+	list, err := client.List(context.TODO(), metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", CrdCollectionLabel, collection)})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			// crd is not installed (yet)
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	entries := []Entry{}
+	for index := range list.Items {
+		entry, err := crdEntry(&list.Items[index])
+		if err != nil {
+			logger.Log.Error(err.Error())
+			continue
+		}
+		entries = append(entries, *entry)
+	}
+	return entries, nil
+}
+
+func (s *CrdStore) Get(collection string, key string) (*Entry, error) {
+	client, err := s.client()
+	if err != nil {
+		return nil, err
+	}
+	object, err := client.Get(context.TODO(), crdObjectName(collection, key), metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return crdEntry(object)
+}
+
+func (s *CrdStore) Put(collection string, entry Entry) error {
+	err := s.Init(collection)
+	if err != nil {
+		return err
+	}
+	client, err := s.client()
+	if err != nil {
+		return err
+	}
+
+	object := crdObject(collection, entry)
+	if entry.Version == "" {
+		_, err = client.Create(context.TODO(), object, kubernetes.MoCreateOptions())
+		if apierrors.IsAlreadyExists(err) {
+			return ErrConflict
+		}
+		return err
+	}
+
+	_, err = client.Update(context.TODO(), object, kubernetes.MoUpdateOptions())
+	if apierrors.IsConflict(err) || apierrors.IsNotFound(err) {
+		return ErrConflict
+	}
+	return err
+}
+
+func (s *CrdStore) Delete(collection string, key string) error {
+	client, err := s.client()
+	if err != nil {
+		return err
+	}
+	err = client.Delete(context.TODO(), crdObjectName(collection, key), metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s *CrdStore) Drop(collection string) error {
+	client, err := s.client()
+	if err != nil {
+		return err
+	}
+	err = client.DeleteCollection(context.TODO(), metav1.DeleteOptions{}, metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", CrdCollectionLabel, collection)})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
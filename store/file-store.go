@@ -0,0 +1,205 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mogenius/punq/utils"
+)
+
+const (
+	fileStoreLockTimeout = 5 * time.Second
+	fileStoreLockStale   = 30 * time.Second // lock files of crashed processes are removed after this age
+)
+
+type fileEntry struct {
+	Value   []byte `json:"value"`
+	Version int64  `json:"version"`
+}
+
+// FileStore keeps each collection in a JSON file on the local machine (e.g. for 'punq local' without a cluster
+// installation). A lock file serializes writes of concurrent punq processes.
+type FileStore struct {
+	dir  string
+	lock sync.Mutex
+}
+
+// NewFileStore uses <config dir>/state if dir is empty.
+func NewFileStore(dir string) *FileStore {
+	if dir == "" {
+		configDir, _ := utils.GetDirectories("")
+		dir = filepath.Join(configDir, "state")
+	}
+	return &FileStore{dir: dir}
+}
+
+func (s *FileStore) Type() string {
+	return TypeFile
+}
+
+func (s *FileStore) path(collection string) string {
+	return filepath.Join(s.dir, collection+".json")
+}
+
+func (s *FileStore) Init(collection string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, err := os.Stat(s.path(collection)); err == nil {
+		return nil
+	}
+	return s.write(collection, map[string]fileEntry{})
+}
+
+func (s *FileStore) read(collection string) (map[string]fileEntry, error) {
+	data, err := os.ReadFile(s.path(collection))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	entries := map[string]fileEntry{}
+	err = json.Unmarshal(data, &entries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse '%s': %s", s.path(collection), err.Error())
+	}
+	return entries, nil
+}
+
+func (s *FileStore) write(collection string, entries map[string]fileEntry) error {
+	err := os.MkdirAll(s.dir, 0700)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// rename is atomic, readers never see partial files
+	tmpPath := s.path(collection) + ".tmp"
+	err = os.WriteFile(tmpPath, data, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, s.path(collection))
+}
+
+// modify runs a read-modify-write of a collection under the process and the file lock.
+func (s *FileStore) modify(collection string, modify func(entries map[string]fileEntry) error) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	unlock, err := s.lockFile(collection)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries, err := s.read(collection)
+	if errors.Is(err, ErrNotFound) {
+		entries = map[string]fileEntry{}
+	} else if err != nil {
+		return err
+	}
+
+	err = modify(entries)
+	if err != nil {
+		return err
+	}
+	return s.write(collection, entries)
+}
+
+func (s *FileStore) lockFile(collection string) (func(), error) {
+	err := os.MkdirAll(s.dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+	lockPath := s.path(collection) + ".lock"
+
+	deadline := time.Now().Add(fileStoreLockTimeout)
+	for {
+		file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			file.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if info, statErr := os.Stat(lockPath); statErr == nil && time.Since(info.ModTime()) > fileStoreLockStale {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timeout waiting for lock '%s'", lockPath)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func (s *FileStore) List(collection string) ([]Entry, error) {
+	entries, err := s.read(collection)
+	if err != nil {
+		return nil, err
+	}
+	result := []Entry{}
+	for key, entry := range entries {
+		result = append(result, Entry{Key: key, Value: entry.Value, Version: strconv.FormatInt(entry.Version, 10)})
+	}
+	return result, nil
+}
+
+func (s *FileStore) Get(collection string, key string) (*Entry, error) {
+	entries, err := s.read(collection)
+	if err != nil {
+		return nil, err
+	}
+	entry, exists := entries[key]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return &Entry{Key: key, Value: entry.Value, Version: strconv.FormatInt(entry.Version, 10)}, nil
+}
+
+func (s *FileStore) Put(collection string, entry Entry) error {
+	return s.modify(collection, func(entries map[string]fileEntry) error {
+		current, exists := entries[entry.Key]
+		if entry.Version == "" && exists {
+			return ErrConflict
+		}
+		if entry.Version != "" && (!exists || strconv.FormatInt(current.Version, 10) != entry.Version) {
+			return ErrConflict
+		}
+		entries[entry.Key] = fileEntry{Value: entry.Value, Version: current.Version + 1}
+		return nil
+	})
+}
+
+func (s *FileStore) Delete(collection string, key string) error {
+	return s.modify(collection, func(entries map[string]fileEntry) error {
+		if _, exists := entries[key]; !exists {
+			return ErrNotFound
+		}
+		delete(entries, key)
+		return nil
+	})
+}
+
+func (s *FileStore) Drop(collection string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	err := os.Remove(s.path(collection))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/mogenius/punq/kubernetes"
+	"github.com/mogenius/punq/utils"
+)
+
+// SecretStore keeps each collection in one Secret of the own namespace (the storage of older punq versions).
+// Versions are content hashes; concurrent writes to the same Secret are detected by its resourceVersion.
+type SecretStore struct{}
+
+func NewSecretStore() *SecretStore {
+	return &SecretStore{}
+}
+
+func (s *SecretStore) Type() string {
+	return TypeSecret
+}
+
+func (s *SecretStore) client() (v1.SecretInterface, error) {
+	provider, err := kubernetes.NewKubeProvider(nil)
+	if err != nil {
+		return nil, err
+	}
+	return provider.ClientSet.CoreV1().Secrets(utils.CONFIG.Kubernetes.OwnNamespace), nil
+}
+
+func secretEntryVersion(value []byte) string {
+	hash := sha256.Sum256(value)
+	return hex.EncodeToString(hash[:])
+}
+
+func (s *SecretStore) Init(collection string) error {
+	client, err := s.client()
+	if err != nil {
+		return err
+	}
+	_, err = client.Get(context.TODO(), collection, metav1.GetOptions{})
+	if err == nil || !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	secret := utils.InitSecret()
+	secret.ObjectMeta.Name = collection
+	secret.ObjectMeta.Namespace = utils.CONFIG.Kubernetes.OwnNamespace
+	delete(secret.StringData, "exampleData") // delete example data
+	_, err = client.Create(context.TODO(), &secret, kubernetes.MoCreateOptions())
+	if apierrors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
+
+func (s *SecretStore) List(collection string) ([]Entry, error) {
+	client, err := s.client()
+	if err != nil {
+		return nil, err
+	}
+	secret, err := client.Get(context.TODO(), collection, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	entries := []Entry{}
+	for key, value := range secret.Data {
+		entries = append(entries, Entry{Key: key, Value: value, Version: secretEntryVersion(value)})
+	}
+	return entries, nil
+}
+
+func (s *SecretStore) Get(collection string, key string) (*Entry, error) {
+	client, err := s.client()
+	if err != nil {
+		return nil, err
+	}
+	secret, err := client.Get(context.TODO(), collection, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	value, exists := secret.Data[key]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return &Entry{Key: key, Value: value, Version: secretEntryVersion(value)}, nil
+}
+
+func (s *SecretStore) Put(collection string, entry Entry) error {
+	client, err := s.client()
+	if err != nil {
+		return err
+	}
+	secret, err := client.Get(context.TODO(), collection, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) && entry.Version == "" {
+		err = s.Init(collection)
+		if err != nil {
+			return err
+		}
+		secret, err = client.Get(context.TODO(), collection, metav1.GetOptions{})
+	}
+	if err != nil {
+		return err
+	}
+
+	current, exists := secret.Data[entry.Key]
+	if entry.Version == "" && exists {
+		return ErrConflict
+	}
+	if entry.Version != "" && (!exists || secretEntryVersion(current) != entry.Version) {
+		return ErrConflict
+	}
+
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data[entry.Key] = entry.Value
+
+	// the resourceVersion of the read Secret makes the update fail if any other entry changed in between
+	_, err = client.Update(context.TODO(), secret, metav1.UpdateOptions{})
+	if apierrors.IsConflict(err) {
+		return ErrConflict
+	}
+	return err
+}
+
+func (s *SecretStore) Delete(collection string, key string) error {
+	client, err := s.client()
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; attempt < ModifyRetries; attempt++ {
+		secret, err := client.Get(context.TODO(), collection, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return ErrNotFound
+			}
+			return err
+		}
+		if _, exists := secret.Data[key]; !exists {
+			return ErrNotFound
+		}
+		delete(secret.Data, key)
+
+		_, err = client.Update(context.TODO(), secret, metav1.UpdateOptions{})
+		if !apierrors.IsConflict(err) {
+			return err
+		}
+	}
+	return ErrConflict
+}
+
+func (s *SecretStore) Drop(collection string) error {
+	client, err := s.client()
+	if err != nil {
+		return err
+	}
+	deletePolicy := metav1.DeletePropagationForeground
+	err = client.Delete(context.TODO(), collection, metav1.DeleteOptions{PropagationPolicy: &deletePolicy})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/mogenius/punq/logger"
+	"github.com/mogenius/punq/utils"
+)
+
+const (
+	TypeSecret = "secret"
+	TypeCrd    = "crd"
+	TypeFile   = "file"
+
+	// attempts of Modify before a conflict is returned to the caller
+	ModifyRetries = 5
+)
+
+var ErrNotFound = errors.New("entry not found")
+var ErrConflict = errors.New("entry has been modified concurrently")
+
+// An Entry is a single object (e.g. a user) of a collection. Version is opaque and only meaningful to the store
+// that returned it.
+type Entry struct {
+	Key     string
+	Value   []byte
+	Version string
+}
+
+// A Store persists punq's state (users, contexts, keys, ...) as collections of key/value entries. The collection
+// names are the names of the Secrets punq has always used (e.g. utils.USERSSECRET).
+type Store interface {
+	Type() string
+	// Init prepares the storage of a collection. It is idempotent.
+	Init(collection string) error
+	List(collection string) ([]Entry, error)
+	// Get returns ErrNotFound if the key does not exist.
+	Get(collection string, key string) (*Entry, error)
+	// Put creates the entry if its Version is empty (ErrConflict if it exists) or updates it if the stored entry
+	// still has this Version (ErrConflict otherwise).
+	Put(collection string, entry Entry) error
+	// Delete returns ErrNotFound if the key does not exist.
+	Delete(collection string, key string) error
+	// Drop removes a collection with all its entries.
+	Drop(collection string) error
+}
+
+// Collections lists everything that is migrated between stores.
+var Collections = []string{
+	utils.USERSSECRET,
+	utils.JWTSECRET,
+	utils.CONTEXTSSECRET,
+	utils.ROLEBINDINGSSECRET,
+	utils.GROUPSSECRET,
+	utils.APITOKENSSECRET,
+	utils.SESSIONSSECRET,
+	utils.KEYSSECRET,
+}
+
+var current Store
+var currentLock sync.Mutex
+
+// Current returns the store configured in utils.CONFIG.Store.Type.
+func Current() Store {
+	currentLock.Lock()
+	defer currentLock.Unlock()
+
+	if current == nil {
+		store, err := New(utils.CONFIG.Store.Type)
+		if err != nil {
+			logger.Log.Fatal(err.Error())
+		}
+		current = store
+	}
+	return current
+}
+
+func New(storeType string) (Store, error) {
+	switch storeType {
+	case TypeSecret, "":
+		return NewSecretStore(), nil
+	case TypeCrd:
+		return NewCrdStore(), nil
+	case TypeFile:
+		return NewFileStore(utils.CONFIG.Store.FilePath), nil
+	default:
+		return nil, fmt.Errorf("unknown store type '%s' (valid: %s, %s, %s)", storeType, TypeSecret, TypeCrd, TypeFile)
+	}
+}
+
+// Values returns all values of a collection by key. Unlike List, a missing collection is no error.
+func Values(collection string) (map[string][]byte, error) {
+	result := map[string][]byte{}
+	entries, err := Current().List(collection)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return result, nil
+		}
+		return nil, err
+	}
+	for _, entry := range entries {
+		result[entry.Key] = entry.Value
+	}
+	return result, nil
+}
+
+// Value returns the value of a key or ErrNotFound.
+func Value(collection string, key string) ([]byte, error) {
+	entry, err := Current().Get(collection, key)
+	if err != nil {
+		return nil, err
+	}
+	return entry.Value, nil
+}
+
+// Create adds a new entry (ErrConflict if the key exists).
+func Create(collection string, key string, value []byte) error {
+	return Current().Put(collection, Entry{Key: key, Value: value})
+}
+
+// Set writes a value regardless of concurrent modifications (last write wins).
+func Set(collection string, key string, value []byte) error {
+	return Modify(collection, key, func(current []byte, exists bool) ([]byte, error) {
+		return value, nil
+	})
+}
+
+// Modify applies a read-modify-write of a single entry and retries if the entry has been changed concurrently.
+// modify receives the current value (nil if it does not exist yet) and returns the new one.
+func Modify(collection string, key string, modify func(current []byte, exists bool) ([]byte, error)) error {
+	store := Current()
+	for attempt := 0; attempt < ModifyRetries; attempt++ {
+		entry, err := store.Get(collection, key)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		if entry == nil {
+			entry = &Entry{Key: key}
+		}
+
+		value, err := modify(entry.Value, entry.Version != "")
+		if err != nil {
+			return err
+		}
+		entry.Value = value
+
+		err = store.Put(collection, *entry)
+		if errors.Is(err, ErrConflict) {
+			logger.Log.Warningf("Concurrent modification of '%s/%s' (attempt %d).", collection, key, attempt+1)
+			continue
+		}
+		return err
+	}
+	return fmt.Errorf("failed to modify '%s/%s': %w", collection, key, ErrConflict)
+}
+
+// Remove deletes a key. A missing key is reported as ErrNotFound.
+func Remove(collection string, key string) error {
+	return Current().Delete(collection, key)
+}
+
+// Migrate copies all collections from one store into another. Existing entries of the target are overwritten.
+func Migrate(from Store, to Store) (int, error) {
+	count := 0
+	for _, collection := range Collections {
+		entries, err := from.List(collection)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			return count, fmt.Errorf("failed to read '%s' from %s store: %w", collection, from.Type(), err)
+		}
+
+		err = to.Init(collection)
+		if err != nil {
+			return count, fmt.Errorf("failed to init '%s' in %s store: %w", collection, to.Type(), err)
+		}
+
+		for _, entry := range entries {
+			target := Entry{Key: entry.Key, Value: entry.Value}
+			existing, err := to.Get(collection, entry.Key)
+			if err == nil {
+				target.Version = existing.Version
+			}
+			err = to.Put(collection, target)
+			if err != nil {
+				return count, fmt.Errorf("failed to write '%s/%s' to %s store: %w", collection, entry.Key, to.Type(), err)
+			}
+			count++
+		}
+		logger.Log.Noticef("Migrated %d entries of '%s' from %s to %s store.", len(entries), collection, from.Type(), to.Type())
+	}
+	return count, nil
+}
@@ -0,0 +1,124 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/mogenius/punq/utils"
+)
+
+// TestMain runs all tests against a file store in a temporary directory.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "punq-store-test")
+	if err != nil {
+		panic(err)
+	}
+	utils.CONFIG.Store.Type = TypeFile
+	utils.CONFIG.Store.FilePath = dir
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestModifyRetriesOnConflict(t *testing.T) {
+	// a second FileStore on the same directory acts like another punq process
+	other := NewFileStore(utils.CONFIG.Store.FilePath)
+	errAbort := errors.New("abort")
+
+	tests := []struct {
+		name          string
+		initial       string
+		interferences int // concurrent writes of the other process during the first attempts
+		modifyErr     error
+		wantErr       error
+		wantCalls     int
+		wantValue     string
+	}{
+		{"missing entry is created", "", 0, nil, nil, 1, "1"},
+		{"no conflict", "1", 0, nil, nil, 1, "2"},
+		// the other process appends a 0, the retry increments what it wrote
+		{"concurrent write is retried", "1", 1, nil, nil, 2, "11"},
+		{"conflict on every attempt", "1", ModifyRetries, nil, ErrConflict, ModifyRetries, "100000"},
+		{"error of modify aborts", "1", 0, errAbort, errAbort, 1, "1"},
+	}
+	for index, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			collection := utils.USERSSECRET
+			key := "modify-" + strconv.Itoa(index)
+			if test.initial != "" {
+				if err := Create(collection, key, []byte(test.initial)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			t.Cleanup(func() { Remove(collection, key) })
+
+			calls := 0
+			err := Modify(collection, key, func(current []byte, exists bool) ([]byte, error) {
+				calls++
+				if test.modifyErr != nil {
+					return nil, test.modifyErr
+				}
+				if calls <= test.interferences {
+					entry, err := other.Get(collection, key)
+					if err != nil {
+						t.Fatal(err)
+					}
+					entry.Value = append(entry.Value, '0')
+					if err := other.Put(collection, *entry); err != nil {
+						t.Fatal(err)
+					}
+				}
+				value, _ := strconv.Atoi(string(current))
+				return []byte(strconv.Itoa(value + 1)), nil
+			})
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("Modify() error = %v, want %v", err, test.wantErr)
+			}
+			if calls != test.wantCalls {
+				t.Errorf("modify called %d times, want %d", calls, test.wantCalls)
+			}
+
+			value, err := Value(collection, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(value) != test.wantValue {
+				t.Errorf("value '%s', want '%s'", value, test.wantValue)
+			}
+		})
+	}
+}
+
+func TestFileStorePutVersions(t *testing.T) {
+	fileStore := NewFileStore(utils.CONFIG.Store.FilePath)
+	collection := utils.GROUPSSECRET
+	if err := fileStore.Put(collection, Entry{Key: "versioned", Value: []byte("v1")}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { fileStore.Delete(collection, "versioned") })
+	stored, err := fileStore.Get(collection, "versioned")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		entry   Entry
+		wantErr error
+	}{
+		{"create of an existing key", Entry{Key: "versioned", Value: []byte("x")}, ErrConflict},
+		{"update of a missing key", Entry{Key: "missing", Value: []byte("x"), Version: "1"}, ErrConflict},
+		{"update with the current version", Entry{Key: "versioned", Value: []byte("v2"), Version: stored.Version}, nil},
+		{"update with a stale version", Entry{Key: "versioned", Value: []byte("v3"), Version: stored.Version}, ErrConflict},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := fileStore.Put(collection, test.entry); !errors.Is(err, test.wantErr) {
+				t.Errorf("Put() error = %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -24,6 +24,11 @@ const JWTSECRET = "punq-jwt"
 const USERADMIN = "admin"
 const CONTEXTSSECRET = "punq-contexts"
 const CONTEXTOWN = "own-context"
+const ROLEBINDINGSSECRET = "punq-role-bindings"
+const GROUPSSECRET = "punq-groups"
+const APITOKENSSECRET = "punq-api-tokens"
+const SESSIONSSECRET = "punq-sessions"
+const KEYSSECRET = "punq-keys"
 
 // This object will initially created in secrets when the software is installed into the cluster for the first time (resource: secret -> mogenius/mogenius)
 type ClusterSecret struct {
@@ -41,18 +46,56 @@ type Config struct {
 		Port int    `yaml:"port" env:"frontend_port" env-description:"Port of the frontend server."`
 	} `yaml:"frontend"`
 	Backend struct {
-		Host string `yaml:"host" env:"backend_host" env-description:"Host of the backend server."`
-		Port int    `yaml:"port" env:"backend_port" env-description:"Port of the backend server."`
+		Host           string   `yaml:"host" env:"backend_host" env-description:"Host of the backend server."`
+		Port           int      `yaml:"port" env:"backend_port" env-description:"Port of the backend server."`
+		TrustedProxies []string `yaml:"trusted_proxies" env:"backend_trusted_proxies" env-description:"IPs or CIDRs of reverse proxies whose X-Forwarded-For header is trusted (empty: the client ip is the remote address)." env-default:""`
 	} `yaml:"backend"`
 	Websocket struct {
 		Host string `yaml:"host" env:"websocket_host" env-description:"Host of the websocket server."`
 		Port int    `yaml:"port" env:"websocket_port" env-description:"Port of the websocket server."`
 	} `yaml:"websocket"`
 	Kubernetes struct {
-		ClusterName  string `yaml:"cluster_name" env:"cluster_name" env-description:"The Name of the Kubernetes Cluster"`
-		OwnNamespace string `yaml:"own_namespace" env:"OWN_NAMESPACE" env-description:"The Namespace of mogenius platform"`
-		RunInCluster bool   `yaml:"run_in_cluster" env:"run_in_cluster" env-description:"If set to true, the application will run in the cluster (using the service account token). Otherwise it will try to load your local default context." env-default:"false"`
+		ClusterName           string  `yaml:"cluster_name" env:"cluster_name" env-description:"The Name of the Kubernetes Cluster"`
+		OwnNamespace          string  `yaml:"own_namespace" env:"OWN_NAMESPACE" env-description:"The Namespace of mogenius platform"`
+		RunInCluster          bool    `yaml:"run_in_cluster" env:"run_in_cluster" env-description:"If set to true, the application will run in the cluster (using the service account token). Otherwise it will try to load your local default context." env-default:"false"`
+		HealthCheckSeconds    int     `yaml:"health_check_seconds" env:"health_check_seconds" env-description:"Interval of the context health checks of the operator (0 disables them)." env-default:"60"`
+		CredentialWarningDays int     `yaml:"credential_warning_days" env:"credential_warning_days" env-description:"Warn this many days before the credentials of a context expire." env-default:"14"`
+		ClientQps             float32 `yaml:"client_qps" env:"client_qps" env-description:"Requests per second of all clients of a context (shared by all users)." env-default:"50"`
+		ClientBurst           int     `yaml:"client_burst" env:"client_burst" env-description:"Burst of requests per context on top of client_qps." env-default:"100"`
+		InformerIdleMinutes   int     `yaml:"informer_idle_minutes" env:"informer_idle_minutes" env-description:"Informer caches of a context are stopped after this many minutes without list requests." env-default:"10"`
+		ConfirmDeleteSelector string  `yaml:"confirm_delete_selector" env:"confirm_delete_selector" env-description:"Deletes in contexts matching this label selector must be confirmed with the context name (X-Confirm-Context header). Empty disables the confirmation." env-default:"environment=production"`
 	} `yaml:"kubernetes"`
+	Oidc struct {
+		Enabled            bool     `yaml:"enabled" env:"oidc_enabled" env-description:"If set to true, users can sign in via an OIDC provider." env-default:"false"`
+		Issuer             string   `yaml:"issuer" env:"oidc_issuer" env-description:"Issuer URL of the OIDC provider (used for discovery)." env-default:""`
+		ClientId           string   `yaml:"client_id" env:"oidc_client_id" env-description:"OIDC client id." env-default:""`
+		ClientSecret       string   `yaml:"client_secret" env:"oidc_client_secret" env-description:"OIDC client secret." env-default:""`
+		RedirectUrl        string   `yaml:"redirect_url" env:"oidc_redirect_url" env-description:"Callback URL registered at the OIDC provider (ends with /auth/oidc/callback)." env-default:""`
+		FrontendUrl        string   `yaml:"frontend_url" env:"oidc_frontend_url" env-description:"If set, the callback redirects here with #token=...&refreshToken=...&expiresAt=... or the MFA challenge #mfaToken=...&enrollmentRequired=...&expiresAt=..." env-default:""`
+		Scopes             []string `yaml:"scopes" env:"oidc_scopes" env-description:"Requested scopes." env-default:"openid,email,profile"`
+		AccessLevelClaim   string   `yaml:"access_level_claim" env:"oidc_access_level_claim" env-description:"Claim used to derive the AccessLevel (string or list of strings)." env-default:"groups"`
+		AdminValues        []string `yaml:"admin_values" env:"oidc_admin_values" env-description:"Claim values which grant ADMIN." env-default:""`
+		UserValues         []string `yaml:"user_values" env:"oidc_user_values" env-description:"Claim values which grant USER." env-default:""`
+		DefaultAccessLevel string   `yaml:"default_access_level" env:"oidc_default_access_level" env-description:"AccessLevel for users without a matching claim value (READER, USER or ADMIN)." env-default:"READER"`
+	} `yaml:"oidc"`
+	Auth struct {
+		JwtKeyRotationDays      int      `yaml:"jwt_key_rotation_days" env:"jwt_key_rotation_days" env-description:"Rotate the jwt signing key automatically after this many days (0 disables the rotation)." env-default:"0"`
+		ServiceAccounts         []string `yaml:"service_accounts" env:"auth_service_accounts" env-description:"ServiceAccounts allowed to log in via TokenReview: 'namespace/name=target' where name may be * and target is a user id, an email or READER, USER or ADMIN." env-default:""`
+		ServiceAccountAudiences []string `yaml:"service_account_audiences" env:"auth_service_account_audiences" env-description:"Audiences a ServiceAccount token must be issued for (empty: audience of the api server)." env-default:""`
+	} `yaml:"auth"`
+	Store struct {
+		Type     string `yaml:"type" env:"store_type" env-description:"Storage of users, contexts, keys etc.: secret, crd or file (local machine only)." env-default:"secret"`
+		FilePath string `yaml:"file_path" env:"store_file_path" env-description:"Directory of the file store (default: <config dir>/state)." env-default:""`
+	} `yaml:"store"`
+	Encryption struct {
+		MasterKey              string   `yaml:"master_key" env:"encryption_master_key" env-description:"Base64 encoded 32 byte key (e.g. 'openssl rand -base64 32') wrapping the data keys of stored kubeconfigs. Prefer master_key_file." env-default:""`
+		MasterKeyFile          string   `yaml:"master_key_file" env:"encryption_master_key_file" env-description:"File containing the master key. Kubeconfigs are stored unencrypted if neither master_key nor master_key_file is set." env-default:""`
+		PreviousMasterKeyFiles []string `yaml:"previous_master_key_files" env:"encryption_previous_master_key_files" env-description:"Files of replaced master keys (only needed until 'punq system rotate-encryption-key' re-wrapped all data keys)." env-default:""`
+	} `yaml:"encryption"`
+	Audit struct {
+		Enabled       bool `yaml:"enabled" env:"audit_enabled" env-description:"If set to true, all mutating api calls and exec sessions are recorded." env-default:"true"`
+		RetentionDays int  `yaml:"retention_days" env:"audit_retention_days" env-description:"Days the audit log is kept (0 keeps it forever)." env-default:"30"`
+	} `yaml:"audit"`
 	Misc struct {
 		Stage              string   `yaml:"stage" env:"stage" env-description:"Stage to run in" env-default:"prod"`
 		Debug              bool     `yaml:"debug" env:"debug" env-description:"If set to true, debug features will be enabled." env-default:"false"`
@@ -140,11 +183,38 @@ func PrintSettings() {
 	fmt.Printf("\nBackend\n")
 	fmt.Printf("Host:                     %s\n", CONFIG.Backend.Host)
 	fmt.Printf("Port:                     %d\n", CONFIG.Backend.Port)
+	fmt.Printf("TrustedProxies:           %s\n", strings.Join(CONFIG.Backend.TrustedProxies, ","))
 
 	fmt.Printf("\nKUBERNETES\n")
 	fmt.Printf("ClusterName:              %s\n", CONFIG.Kubernetes.ClusterName)
 	fmt.Printf("OwnNamespace:             %s\n", CONFIG.Kubernetes.OwnNamespace)
 	fmt.Printf("RunInCluster:             %t\n", CONFIG.Kubernetes.RunInCluster)
+	fmt.Printf("HealthCheckSeconds:       %d\n", CONFIG.Kubernetes.HealthCheckSeconds)
+	fmt.Printf("CredentialWarningDays:    %d\n", CONFIG.Kubernetes.CredentialWarningDays)
+	fmt.Printf("ClientQps:                %.1f\n", CONFIG.Kubernetes.ClientQps)
+	fmt.Printf("ClientBurst:              %d\n", CONFIG.Kubernetes.ClientBurst)
+	fmt.Printf("InformerIdleMinutes:      %d\n", CONFIG.Kubernetes.InformerIdleMinutes)
+	fmt.Printf("ConfirmDeleteSelector:    %s\n", CONFIG.Kubernetes.ConfirmDeleteSelector)
+
+	fmt.Printf("\nOIDC\n")
+	fmt.Printf("Enabled:                  %t\n", CONFIG.Oidc.Enabled)
+	fmt.Printf("Issuer:                   %s\n", CONFIG.Oidc.Issuer)
+	fmt.Printf("ClientId:                 %s\n", CONFIG.Oidc.ClientId)
+
+	fmt.Printf("\nAUTH\n")
+	fmt.Printf("JwtKeyRotationDays:       %d\n", CONFIG.Auth.JwtKeyRotationDays)
+	fmt.Printf("ServiceAccounts:          %s\n", strings.Join(CONFIG.Auth.ServiceAccounts, ","))
+
+	fmt.Printf("\nSTORE\n")
+	fmt.Printf("Type:                     %s\n", CONFIG.Store.Type)
+
+	fmt.Printf("\nENCRYPTION\n")
+	fmt.Printf("Enabled:                  %t\n", CONFIG.Encryption.MasterKey != "" || CONFIG.Encryption.MasterKeyFile != "")
+	fmt.Printf("MasterKeyFile:            %s\n", CONFIG.Encryption.MasterKeyFile)
+
+	fmt.Printf("\nAUDIT\n")
+	fmt.Printf("Enabled:                  %t\n", CONFIG.Audit.Enabled)
+	fmt.Printf("RetentionDays:            %d\n", CONFIG.Audit.RetentionDays)
 
 	fmt.Printf("\nMISC\n")
 	fmt.Printf("Stage:                    %s\n", CONFIG.Misc.Stage)
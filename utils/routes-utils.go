@@ -11,8 +11,9 @@ import (
 )
 
 type K8sWorkloadResult struct {
-	Result interface{} `json:"result,omitempty"`
-	Error  interface{} `json:"error,omitempty"`
+	Result     interface{} `json:"result,omitempty"`
+	Error      interface{} `json:"error,omitempty"`
+	StatusCode int         `json:"statusCode,omitempty"` // http status of the api server (if it rejected the request)
 }
 
 func PrintPrettyPost(c *gin.Context) {
@@ -71,6 +72,8 @@ func MalformedMessage(c *gin.Context, msg string) {
 func HttpRespondForWorkloadResult(c *gin.Context, workloadResult K8sWorkloadResult) {
 	if workloadResult.Error == nil {
 		c.JSON(http.StatusOK, workloadResult)
+	} else if workloadResult.StatusCode >= http.StatusBadRequest {
+		c.JSON(workloadResult.StatusCode, workloadResult)
 	} else {
 		c.JSON(http.StatusBadRequest, workloadResult)
 	}
@@ -324,6 +324,20 @@ func ConfirmTask(s string) bool {
 	return strings.ToLower(strings.TrimSpace(res)) == "y"
 }
 
+// ConfirmWithInput asks the user to type expected (e.g. the name of a production context) before a dangerous task.
+func ConfirmWithInput(s string, expected string) bool {
+	r := bufio.NewReader(os.Stdin)
+
+	fmt.Printf("%s Type '%s' to confirm: ", s, expected)
+
+	res, err := r.ReadString('\n')
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return strings.TrimSpace(res) == expected
+}
+
 func HashString(data string) string {
 	var buf bytes.Buffer
 